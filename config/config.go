@@ -2,15 +2,82 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 )
 
 // Config holds the application configuration
 type Config struct {
+	Env             string `json:"env"`
 	ListenAddr      string `json:"listen_addr"`
+	ListenSocket    string `json:"listen_socket"`
+	TLSCert         string `json:"tls_cert"`
+	TLSKey          string `json:"tls_key"`
 	APIKey          string `json:"api_key"`
 	WhatsappDataDir string `json:"whatsapp_data_dir"`
+	BulkConcurrency int    `json:"bulk_concurrency"`
+	BulkDelayMs     int    `json:"bulk_delay_ms"`
+	MaxClients      int    `json:"max_clients"`
+
+	MessagesPerMinute   int `json:"messages_per_minute"`
+	SendConcurrency     int `json:"send_concurrency"`
+	MessageHistoryLimit int `json:"message_history_limit"`
+	EventLogSize        int `json:"event_log_size"`
+
+	SendRetries        int `json:"send_retries"`
+	SendRetryBackoffMs int `json:"send_retry_backoff_ms"`
+
+	DownloadMedia        bool `json:"download_media"`
+	MediaRetentionHours  int  `json:"media_retention_hours"`
+	WebhookInlineMediaKB int  `json:"webhook_inline_media_kb"`
+
+	LogLevel  string `json:"log_level"`
+	LogFormat string `json:"log_format"`
+
+	DebugEvents bool `json:"debug_events"`
+
+	MetricsPublic bool `json:"metrics_public"`
+
+	AutoReconnect       bool `json:"auto_reconnect"`
+	PingIntervalSeconds int  `json:"ping_interval_seconds"`
+
+	StateSaveIntervalSeconds int `json:"state_save_interval_seconds"`
+
+	JWTSecret     string `json:"jwt_secret"`
+	JWTTTLSeconds int    `json:"jwt_ttl_seconds"`
+
+	APIKeysFile string      `json:"api_keys_file"`
+	ScopedKeys  []ScopedKey `json:"scoped_keys"`
+
+	DBDriver string `json:"db_driver"`
+	DBDSN    string `json:"db_dsn"`
+
+	EncryptionKey string `json:"encryption_key"`
+
+	DeviceName string `json:"device_name"`
+
+	ProxyURL string `json:"proxy_url"`
+
+	WebhookURL    string `json:"webhook_url"`
+	WebhookSecret string `json:"webhook_secret"`
+
+	DefaultCountryCode string `json:"default_country_code"`
+
+	DryRun bool `json:"dry_run"`
+
+	CORSAllowedOrigins []string `json:"cors_allowed_origins"`
+	CORSAllowedMethods []string `json:"cors_allowed_methods"`
+	CORSAllowedHeaders []string `json:"cors_allowed_headers"`
+
+	LoginMaxAttempts    int `json:"login_max_attempts"`
+	LoginWindowSeconds  int `json:"login_window_seconds"`
+	LoginLockoutSeconds int `json:"login_lockout_seconds"`
+
+	RequestTimeoutSeconds int `json:"request_timeout_seconds"`
+	MaxBodySizeMB         int `json:"max_body_size_mb"`
 }
 
 // Load reads configuration from a file or environment variables
@@ -18,8 +85,44 @@ func Load(configFile string) (*Config, error) {
 	// Default configuration
 	cfg := &Config{
 		ListenAddr:      ":8080",
-		APIKey:          "changeme",
+		APIKey:          DefaultAPIKey,
 		WhatsappDataDir: "./whatsapp-data",
+		BulkConcurrency: 1,
+		BulkDelayMs:     1000,
+
+		MessagesPerMinute:   20,
+		SendConcurrency:     1,
+		MessageHistoryLimit: 1000,
+		EventLogSize:        100,
+
+		SendRetries:        3,
+		SendRetryBackoffMs: 1000,
+
+		MediaRetentionHours: 72,
+
+		LogLevel:  "info",
+		LogFormat: "text",
+
+		AutoReconnect:       true,
+		PingIntervalSeconds: 60,
+
+		StateSaveIntervalSeconds: 300,
+
+		JWTTTLSeconds: 3600,
+
+		DBDriver: "sqlite3",
+
+		DeviceName: "Go WA Gateway",
+
+		CORSAllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		CORSAllowedHeaders: []string{"Content-Type", "X-API-Key", "Authorization"},
+
+		LoginMaxAttempts:    5,
+		LoginWindowSeconds:  60,
+		LoginLockoutSeconds: 300,
+
+		RequestTimeoutSeconds: 60,
+		MaxBodySizeMB:         10,
 	}
 
 	// Load from config file if provided
@@ -30,9 +133,25 @@ func Load(configFile string) (*Config, error) {
 	}
 
 	// Override with environment variables if present
+	if v := os.Getenv("ENV"); v != "" {
+		cfg.Env = v
+	}
 	if addr := os.Getenv("LISTEN_ADDR"); addr != "" {
 		cfg.ListenAddr = addr
 	}
+	if v := os.Getenv("LISTEN_SOCKET"); v != "" {
+		cfg.ListenSocket = v
+	}
+	if v := os.Getenv("TLS_CERT"); v != "" {
+		cfg.TLSCert = v
+	}
+	if v := os.Getenv("TLS_KEY"); v != "" {
+		cfg.TLSKey = v
+	}
+	if (cfg.TLSCert == "") != (cfg.TLSKey == "") {
+		return nil, fmt.Errorf("TLS_CERT and TLS_KEY must be set together")
+	}
+
 	if key := os.Getenv("API_KEY"); key != "" {
 		cfg.APIKey = key
 	}
@@ -40,6 +159,265 @@ func Load(configFile string) (*Config, error) {
 		cfg.WhatsappDataDir = dir
 	}
 
+	if v := os.Getenv("BULK_CONCURRENCY"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid BULK_CONCURRENCY: %q", v)
+		}
+		cfg.BulkConcurrency = n
+	}
+	if v := os.Getenv("BULK_DELAY_MS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid BULK_DELAY_MS: %q", v)
+		}
+		cfg.BulkDelayMs = n
+	}
+	if v := os.Getenv("MAX_CLIENTS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid MAX_CLIENTS: %q", v)
+		}
+		cfg.MaxClients = n
+	}
+
+	if v := os.Getenv("MESSAGES_PER_MINUTE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid MESSAGES_PER_MINUTE: %q", v)
+		}
+		cfg.MessagesPerMinute = n
+	}
+
+	if v := os.Getenv("SEND_CONCURRENCY"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid SEND_CONCURRENCY: %q", v)
+		}
+		cfg.SendConcurrency = n
+	}
+
+	if v := os.Getenv("SEND_RETRIES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid SEND_RETRIES: %q", v)
+		}
+		cfg.SendRetries = n
+	}
+	if v := os.Getenv("SEND_RETRY_BACKOFF_MS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid SEND_RETRY_BACKOFF_MS: %q", v)
+		}
+		cfg.SendRetryBackoffMs = n
+	}
+
+	if v := os.Getenv("MESSAGE_HISTORY_LIMIT"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid MESSAGE_HISTORY_LIMIT: %q", v)
+		}
+		cfg.MessageHistoryLimit = n
+	}
+	if v := os.Getenv("EVENT_LOG_SIZE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid EVENT_LOG_SIZE: %q", v)
+		}
+		cfg.EventLogSize = n
+	}
+
+	if v := os.Getenv("DOWNLOAD_MEDIA"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DOWNLOAD_MEDIA: %q", v)
+		}
+		cfg.DownloadMedia = b
+	}
+	if v := os.Getenv("MEDIA_RETENTION_HOURS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid MEDIA_RETENTION_HOURS: %q", v)
+		}
+		cfg.MediaRetentionHours = n
+	}
+	if v := os.Getenv("WEBHOOK_INLINE_MEDIA_KB"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid WEBHOOK_INLINE_MEDIA_KB: %q", v)
+		}
+		cfg.WebhookInlineMediaKB = n
+	}
+
+	if v := os.Getenv("LOG_LEVEL"); v != "" {
+		cfg.LogLevel = v
+	}
+	if v := os.Getenv("LOG_FORMAT"); v != "" {
+		cfg.LogFormat = v
+	}
+
+	if v := os.Getenv("DEBUG_EVENTS"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DEBUG_EVENTS: %q", v)
+		}
+		cfg.DebugEvents = b
+	}
+
+	if v := os.Getenv("METRICS_PUBLIC"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid METRICS_PUBLIC: %q", v)
+		}
+		cfg.MetricsPublic = b
+	}
+
+	if v := os.Getenv("AUTO_RECONNECT"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid AUTO_RECONNECT: %q", v)
+		}
+		cfg.AutoReconnect = b
+	}
+	if v := os.Getenv("PING_INTERVAL_SECONDS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid PING_INTERVAL_SECONDS: %q", v)
+		}
+		cfg.PingIntervalSeconds = n
+	}
+
+	if v := os.Getenv("STATE_SAVE_INTERVAL"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid STATE_SAVE_INTERVAL: %q", v)
+		}
+		cfg.StateSaveIntervalSeconds = n
+	}
+
+	if v := os.Getenv("JWT_SECRET"); v != "" {
+		cfg.JWTSecret = v
+	}
+	if v := os.Getenv("JWT_TTL_SECONDS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid JWT_TTL_SECONDS: %q", v)
+		}
+		cfg.JWTTTLSeconds = n
+	}
+
+	if v := os.Getenv("API_KEYS_FILE"); v != "" {
+		cfg.APIKeysFile = v
+	}
+	if cfg.APIKeysFile != "" {
+		keys, err := loadScopedKeys(cfg.APIKeysFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.ScopedKeys = keys
+	}
+
+	if v := os.Getenv("DB_DRIVER"); v != "" {
+		cfg.DBDriver = v
+	}
+	if v := os.Getenv("DB_DSN"); v != "" {
+		cfg.DBDSN = v
+	}
+	switch cfg.DBDriver {
+	case "sqlite", "sqlite3":
+		cfg.DBDriver = "sqlite3"
+	case "postgres", "postgresql":
+		cfg.DBDriver = "postgres"
+		if cfg.DBDSN == "" {
+			return nil, fmt.Errorf("DB_DSN is required when DB_DRIVER is %q", cfg.DBDriver)
+		}
+	default:
+		return nil, fmt.Errorf("invalid DB_DRIVER: %q", cfg.DBDriver)
+	}
+	if v := os.Getenv("ENCRYPTION_KEY"); v != "" {
+		cfg.EncryptionKey = v
+	}
+
+	if v := os.Getenv("DEVICE_NAME"); v != "" {
+		cfg.DeviceName = v
+	}
+
+	if v := os.Getenv("PROXY_URL"); v != "" {
+		cfg.ProxyURL = v
+	}
+
+	if v := os.Getenv("WEBHOOK_URL"); v != "" {
+		cfg.WebhookURL = v
+	}
+	if v := os.Getenv("WEBHOOK_SECRET"); v != "" {
+		cfg.WebhookSecret = v
+	}
+
+	if v := os.Getenv("DEFAULT_COUNTRY_CODE"); v != "" {
+		cfg.DefaultCountryCode = v
+	}
+	cfg.DefaultCountryCode = strings.TrimPrefix(cfg.DefaultCountryCode, "+")
+	if cfg.DefaultCountryCode != "" {
+		if _, err := strconv.Atoi(cfg.DefaultCountryCode); err != nil || len(cfg.DefaultCountryCode) > 3 {
+			return nil, fmt.Errorf("invalid DEFAULT_COUNTRY_CODE: %q", cfg.DefaultCountryCode)
+		}
+	}
+
+	if v := os.Getenv("DRY_RUN"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DRY_RUN: %q", v)
+		}
+		cfg.DryRun = b
+	}
+
+	if v := os.Getenv("CORS_ALLOWED_ORIGINS"); v != "" {
+		cfg.CORSAllowedOrigins = splitList(v)
+	}
+	if v := os.Getenv("CORS_ALLOWED_METHODS"); v != "" {
+		cfg.CORSAllowedMethods = splitList(v)
+	}
+	if v := os.Getenv("CORS_ALLOWED_HEADERS"); v != "" {
+		cfg.CORSAllowedHeaders = splitList(v)
+	}
+
+	if v := os.Getenv("LOGIN_MAX_ATTEMPTS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid LOGIN_MAX_ATTEMPTS: %q", v)
+		}
+		cfg.LoginMaxAttempts = n
+	}
+	if v := os.Getenv("LOGIN_WINDOW_SECONDS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid LOGIN_WINDOW_SECONDS: %q", v)
+		}
+		cfg.LoginWindowSeconds = n
+	}
+	if v := os.Getenv("LOGIN_LOCKOUT_SECONDS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid LOGIN_LOCKOUT_SECONDS: %q", v)
+		}
+		cfg.LoginLockoutSeconds = n
+	}
+
+	if v := os.Getenv("REQUEST_TIMEOUT_SECONDS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid REQUEST_TIMEOUT_SECONDS: %q", v)
+		}
+		cfg.RequestTimeoutSeconds = n
+	}
+	if v := os.Getenv("MAX_BODY_SIZE_MB"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid MAX_BODY_SIZE_MB: %q", v)
+		}
+		cfg.MaxBodySizeMB = n
+	}
+
 	// Ensure the WhatsApp data directory exists
 	if err := os.MkdirAll(cfg.WhatsappDataDir, 0755); err != nil {
 		return nil, err
@@ -48,6 +426,17 @@ func Load(configFile string) (*Config, error) {
 	return cfg, nil
 }
 
+// splitList splits a comma separated list, dropping empty entries
+func splitList(v string) []string {
+	var list []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 // loadFromFile loads configuration from a JSON file
 func loadFromFile(filename string, cfg *Config) error {
 	data, err := os.ReadFile(filename)
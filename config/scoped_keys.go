@@ -0,0 +1,62 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// minScopedKeyLength is the shortest scoped API key accepted
+const minScopedKeyLength = 16
+
+// ScopedKey is an API key that only works on the routes of some clients,
+// for tenants sharing a gateway
+type ScopedKey struct {
+	// Name identifies the key's holder in logs
+	Name string `json:"name"`
+	Key  string `json:"key"`
+	// Clients are the IDs of the clients the key may access
+	Clients []string `json:"clients"`
+}
+
+// loadScopedKeys reads a JSON list of scoped keys from a file
+func loadScopedKeys(filename string) ([]ScopedKey, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API_KEYS_FILE: %w", err)
+	}
+	var keys []ScopedKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("invalid API_KEYS_FILE: %w", err)
+	}
+	return keys, nil
+}
+
+// validateScopedKeys checks that every scoped key is long enough, names its
+// clients and can't be mistaken for another key
+func validateScopedKeys(keys []ScopedKey, apiKey string) error {
+	seen := make(map[string]bool, len(keys))
+	for i, key := range keys {
+		name := key.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		switch {
+		case len(key.Key) < minScopedKeyLength:
+			return fmt.Errorf("scoped key %s must be at least %d characters", name, minScopedKeyLength)
+		case key.Key == apiKey:
+			return fmt.Errorf("scoped key %s is the same as API_KEY", name)
+		case seen[key.Key]:
+			return fmt.Errorf("scoped key %s is used more than once", name)
+		case len(key.Clients) == 0:
+			return fmt.Errorf("scoped key %s has no clients", name)
+		}
+		for _, id := range key.Clients {
+			if id == "" {
+				return fmt.Errorf("scoped key %s has an empty client ID", name)
+			}
+		}
+		seen[key.Key] = true
+	}
+	return nil
+}
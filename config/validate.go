@@ -0,0 +1,138 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DefaultAPIKey is the placeholder API key used when none is configured
+const DefaultAPIKey = "changeme"
+
+// minJWTSecretLength is the shortest JWT_SECRET accepted for signing tokens
+const minJWTSecretLength = 32
+
+// minEncryptionKeyLength is the shortest ENCRYPTION_KEY accepted for
+// encrypting session stores
+const minEncryptionKeyLength = 16
+
+// EnvProduction is the ENV value that turns configuration warnings into
+// startup errors
+const EnvProduction = "production"
+
+// IsProduction reports whether the gateway runs with ENV=production
+func (cfg *Config) IsProduction() bool {
+	return strings.EqualFold(cfg.Env, EnvProduction)
+}
+
+// Validate checks the configuration for problems that would only show up
+// after startup. It returns an error for settings the gateway can't run
+// with, and warnings for insecure settings it tolerates outside production.
+func (cfg *Config) Validate() ([]string, error) {
+	var warnings []string
+	var errs []error
+
+	if cfg.APIKey == "" || cfg.APIKey == DefaultAPIKey {
+		if cfg.IsProduction() {
+			errs = append(errs, errors.New("API_KEY must be changed from the default in production"))
+		} else {
+			warnings = append(warnings, "API_KEY is still the default; anyone can use the API. Set API_KEY before exposing the gateway")
+		}
+	}
+
+	// The listen address is unused when serving on a unix socket
+	if cfg.ListenSocket == "" {
+		if err := validateListenAddr(cfg.ListenAddr); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if cfg.ProxyURL != "" {
+		if err := validateProxyURL(cfg.ProxyURL); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if cfg.JWTSecret != "" && len(cfg.JWTSecret) < minJWTSecretLength {
+		errs = append(errs, fmt.Errorf("JWT_SECRET must be at least %d characters", minJWTSecretLength))
+	}
+
+	if cfg.EncryptionKey != "" {
+		if len(cfg.EncryptionKey) < minEncryptionKeyLength {
+			errs = append(errs, fmt.Errorf("ENCRYPTION_KEY must be at least %d characters", minEncryptionKeyLength))
+		}
+		if cfg.DBDriver != "sqlite3" {
+			errs = append(errs, errors.New("ENCRYPTION_KEY only applies to the sqlite3 driver; encrypt the Postgres database instead"))
+		}
+	}
+
+	if err := validateScopedKeys(cfg.ScopedKeys, cfg.APIKey); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := checkWritable(cfg.WhatsappDataDir); err != nil {
+		errs = append(errs, fmt.Errorf("WHATSAPP_DATA_DIR is not writable: %w", err))
+	}
+
+	return warnings, errors.Join(errs...)
+}
+
+// validateListenAddr checks that addr is a host:port with a valid port
+func validateListenAddr(addr string) error {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid LISTEN_ADDR %q: %w", addr, err)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		return fmt.Errorf("invalid LISTEN_ADDR %q: bad port %q", addr, port)
+	}
+	return nil
+}
+
+// validateProxyURL checks that raw is an http, https or socks5 proxy URL with
+// a host
+func validateProxyURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		// The parse error quotes the URL, credentials included
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("invalid PROXY_URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return fmt.Errorf("invalid PROXY_URL: scheme must be http, https or socks5, not %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return errors.New("invalid PROXY_URL: missing host")
+	}
+	return nil
+}
+
+// RedactedProxyURL returns the proxy URL without credentials, for logging
+func (cfg *Config) RedactedProxyURL() string {
+	u, err := url.Parse(cfg.ProxyURL)
+	if err != nil {
+		return ""
+	}
+	u.User = nil
+	return u.String()
+}
+
+// checkWritable creates and removes a file in dir to prove it can be written
+func checkWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	f.Close()
+	return os.Remove(name)
+}
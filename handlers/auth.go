@@ -1,15 +1,26 @@
 package handlers
 
 import (
+	"crypto/subtle"
+	"log/slog"
 	"net/http"
-	"os"
+	"slices"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+
+	"go-simple-whatsapp-gateway2/config"
+	"go-simple-whatsapp-gateway2/whatsapp"
 )
 
-// APIKeyMiddleware creates a middleware for API key authentication
-func APIKeyMiddleware(apiKey string) gin.HandlerFunc {
+// APIKeyMiddleware creates a middleware for API key authentication.
+// The global API key grants access to everything. Routes for a single client
+// (/api/clients/:id/...) also accept that client's own API key, so tenants
+// can be handed a key that only works for their client. Scoped keys and
+// bearer tokens, when tokens is set, work on the routes of the clients they
+// name. Admin tokens issued to the dashboard work everywhere the global key
+// does, except routes restricted by AdminOnlyMiddleware.
+func APIKeyMiddleware(apiKey string, scopedKeys []config.ScopedKey, tokens *TokenIssuer, clientManager *whatsapp.ClientManager) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Skip for UI pages
 		if strings.HasPrefix(c.Request.URL.Path, "/ui/") {
@@ -17,55 +28,123 @@ func APIKeyMiddleware(apiKey string) gin.HandlerFunc {
 			return
 		}
 
-		// Get API key from header
-		key := c.GetHeader("X-API-Key")
-		if key == "" {
-			// Also check query parameter for convenience
-			key = c.Query("api_key")
+		key := requestAPIKey(c)
+
+		// Admin key works everywhere
+		if subtle.ConstantTimeCompare([]byte(key), []byte(apiKey)) == 1 {
+			c.Next()
+			return
 		}
 
-		// Verify API key
-		if key != apiKey {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid API key",
-			})
-			c.Abort()
+		// Scoped keys only work on the routes of the clients they name
+		if scoped := findScopedKey(scopedKeys, key); scoped != nil {
+			if id := clientRouteID(c); id == "" || !slices.Contains(scoped.Clients, id) {
+				slog.Debug("Scoped API key used outside its clients", "key", scoped.Name, "path", c.FullPath(), "client", id)
+				c.AbortWithStatusJSON(http.StatusForbidden, ErrorResponse{Error: "API key doesn't grant access to this route", Code: CodeForbidden})
+				return
+			}
+			c.Next()
 			return
 		}
 
-		c.Next()
+		// Tokens only work on the routes of the clients they name
+		if token := bearerToken(c); token != "" && tokens != nil {
+			claims, err := tokens.Verify(token)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid or expired token", Code: CodeUnauthorized})
+				return
+			}
+			if claims.Admin {
+				c.Next()
+				return
+			}
+			if id := clientRouteID(c); id == "" || !claims.allows(id) {
+				c.AbortWithStatusJSON(http.StatusForbidden, ErrorResponse{Error: "Token doesn't grant access to this route", Code: CodeForbidden})
+				return
+			}
+			c.Next()
+			return
+		}
+
+		// Otherwise accept the client's own key on its routes
+		if id := clientRouteID(c); id != "" {
+			if client, err := clientManager.GetClient(id); err == nil && client.CheckAPIKey(key) {
+				c.Next()
+				return
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid API key", Code: CodeUnauthorized})
 	}
 }
 
-// UIAuthMiddleware creates a middleware for UI authentication
-// In a real production system, you'd want a more robust auth system
-func UIAuthMiddleware() gin.HandlerFunc {
+// AdminOnlyMiddleware restricts a route to the global API key, for client
+// routes that tenants holding only their client's key shouldn't reach
+func AdminOnlyMiddleware(apiKey string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Skip auth for login page
-		if c.Request.URL.Path == "/ui/login" {
-			c.Next()
+		if subtle.ConstantTimeCompare([]byte(requestAPIKey(c)), []byte(apiKey)) != 1 {
+			c.AbortWithStatusJSON(http.StatusForbidden, ErrorResponse{Error: "Admin API key required", Code: CodeForbidden})
 			return
 		}
+		c.Next()
+	}
+}
 
-		// Check for API key in cookie
-		apiKey, err := c.Cookie("api_key")
-		if err != nil || apiKey == "" {
-			// Redirect to login page
-			c.Redirect(http.StatusFound, "/ui/login")
-			c.Abort()
-			return
+// findScopedKey returns the scoped key matching key, if any. Every key is
+// compared so the time taken doesn't tell which one matched.
+func findScopedKey(scopedKeys []config.ScopedKey, key string) *config.ScopedKey {
+	if key == "" {
+		return nil
+	}
+	var found *config.ScopedKey
+	for i := range scopedKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(scopedKeys[i].Key)) == 1 {
+			found = &scopedKeys[i]
 		}
+	}
+	return found
+}
+
+// clientRouteID returns the client a request is for when it's on a route of
+// a single client, or "" otherwise
+func clientRouteID(c *gin.Context) string {
+	if id := c.Param("id"); id != "" && strings.HasPrefix(c.FullPath(), "/api/clients/:id") {
+		return id
+	}
+	return ""
+}
 
-		// Get API key from config for comparison
-		expectedAPIKey := os.Getenv("API_KEY")
-		if expectedAPIKey == "" {
-			expectedAPIKey = "changeme" // Default from .env
+// requestAPIKey returns the API key sent with a request
+func requestAPIKey(c *gin.Context) string {
+	// Get API key from header
+	key := c.GetHeader("X-API-Key")
+	if key == "" {
+		// Also check query parameter for convenience
+		key = c.Query("api_key")
+	}
+	return key
+}
+
+// UIAuthMiddleware creates a middleware for UI authentication. Requests
+// must carry the cookie of a live session created at login.
+func UIAuthMiddleware(sessions *SessionStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Skip auth for login page
+		if c.Request.URL.Path == "/ui/login" {
+			c.Next()
+			return
 		}
 
-		// Verify API key
-		if apiKey != expectedAPIKey {
-			// Invalid API key, clear cookie and redirect to login
-			c.SetCookie("api_key", "", -1, "/", "", false, true)
+		// Check for a valid session
+		token, err := c.Cookie(sessionCookie)
+		if err != nil || !sessions.Valid(token) {
+			// Clear any stale cookie and redirect to login page
+			c.SetCookie(sessionCookie, "", -1, "/", "", false, true)
+			// Scripts asking for a token need a status, not the login page
+			if c.Request.URL.Path == "/ui/api-token" {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{Error: "Session expired", Code: CodeUnauthorized})
+				return
+			}
 			c.Redirect(http.StatusFound, "/ui/login")
 			c.Abort()
 			return
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"go-simple-whatsapp-gateway2/config"
+	"go-simple-whatsapp-gateway2/whatsapp"
+)
+
+func TestAPIKeyMiddlewareScopedKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const adminKey = "admin-key-0123456789"
+	scopedKeys := []config.ScopedKey{
+		{Name: "tenant-a", Key: "tenant-a-key-0123456789", Clients: []string{"client-a"}},
+		{Name: "tenant-bc", Key: "tenant-bc-key-0123456789", Clients: []string{"client-b", "client-c"}},
+	}
+
+	clientManager := whatsapp.NewClientManager(t.TempDir(), whatsapp.Options{})
+	t.Cleanup(func() {
+		clientManager.Close()
+	})
+	client, err := clientManager.CreateClient("client-a", "")
+	if err != nil {
+		t.Fatalf("CreateClient: %v", err)
+	}
+	clientKey := client.APIKey()
+
+	router := gin.New()
+	api := router.Group("/api", APIKeyMiddleware(adminKey, scopedKeys, nil, clientManager))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	api.GET("/clients", ok)
+	api.GET("/clients/:id/status", ok)
+
+	tests := []struct {
+		name       string
+		path       string
+		key        string
+		query      bool
+		wantStatus int
+	}{
+		{name: "admin key on a client", path: "/api/clients/client-a/status", key: adminKey, wantStatus: http.StatusOK},
+		{name: "admin key on another client", path: "/api/clients/client-z/status", key: adminKey, wantStatus: http.StatusOK},
+		{name: "admin key on the client list", path: "/api/clients", key: adminKey, wantStatus: http.StatusOK},
+		{name: "scoped key on its client", path: "/api/clients/client-a/status", key: "tenant-a-key-0123456789", wantStatus: http.StatusOK},
+		{name: "scoped key in the query", path: "/api/clients/client-a/status", key: "tenant-a-key-0123456789", query: true, wantStatus: http.StatusOK},
+		{name: "scoped key on its second client", path: "/api/clients/client-c/status", key: "tenant-bc-key-0123456789", wantStatus: http.StatusOK},
+		{name: "scoped key on another client", path: "/api/clients/client-b/status", key: "tenant-a-key-0123456789", wantStatus: http.StatusForbidden},
+		{name: "scoped key on the client list", path: "/api/clients", key: "tenant-a-key-0123456789", wantStatus: http.StatusForbidden},
+		{name: "client key on its client", path: "/api/clients/client-a/status", key: clientKey, wantStatus: http.StatusOK},
+		{name: "client key on another client", path: "/api/clients/client-b/status", key: clientKey, wantStatus: http.StatusUnauthorized},
+		{name: "unknown key", path: "/api/clients/client-a/status", key: "not-a-key-0123456789", wantStatus: http.StatusUnauthorized},
+		{name: "no key", path: "/api/clients/client-a/status", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := tt.path
+			if tt.query {
+				path += "?api_key=" + tt.key
+			}
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			if !tt.query && tt.key != "" {
+				req.Header.Set("X-API-Key", tt.key)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("GET %s status = %d, want %d", tt.path, rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
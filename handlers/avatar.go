@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"go-simple-whatsapp-gateway2/whatsapp"
+)
+
+// getAvatar returns a contact's profile picture URL, or the image itself
+// with ?download=true
+// @Summary Get a contact's profile picture
+// @Description Returns the picture's URL, or the image itself with download=true.
+// @Tags contacts
+// @Produce json
+// @Produce jpeg
+// @Param id path string true "Client ID"
+// @Param jid query string true "Phone number, user JID or group JID"
+// @Param download query bool false "Return the image instead of its URL"
+// @Success 200 {object} whatsapp.ProfilePicture
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/avatar [get]
+func (h *ClientsHandler) getAvatar(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	jid := c.Query("jid")
+	if jid == "" {
+		respondStatus(c, http.StatusBadRequest, "jid is required")
+		return
+	}
+
+	picture, err := client.GetProfilePicture(jid)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if c.Query("download") != "true" {
+		c.JSON(http.StatusOK, picture)
+		return
+	}
+
+	data, err := whatsapp.DownloadProfilePicture(c.Request.Context(), picture)
+	if err != nil {
+		respondStatus(c, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	c.Data(http.StatusOK, "image/jpeg", data)
+}
+
+// maxAvatarUpload caps the size of an uploaded profile picture
+const maxAvatarUpload = 5 << 20
+
+// setAvatar changes the client's own profile picture from a multipart upload
+// in the "image" field
+// @Summary Set a client's profile picture
+// @Tags clients
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param image formData file true "JPEG or PNG image, up to 5 MB"
+// @Success 200 {object} object{success=bool,picture_id=string}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/avatar [put]
+func (h *ClientsHandler) setAvatar(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxAvatarUpload)
+	file, _, err := c.Request.FormFile("image")
+	if err != nil {
+		respondStatus(c, http.StatusBadRequest, "image file is required")
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		respondStatus(c, http.StatusBadRequest, "Failed to read image")
+		return
+	}
+
+	pictureID, err := client.SetProfilePicture(data)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"picture_id": pictureID,
+	})
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getBanStatus reports whether WhatsApp has temporarily banned a client
+// @Summary Get a client's ban status
+// @Description Reports a temporary ban with its reason code and expiry, and the last connection WhatsApp refused. While banned, the client only reconnects once the ban expires.
+// @Tags clients
+// @Produce json
+// @Param id path string true "Client ID"
+// @Success 200 {object} whatsapp.BanStatus
+// @Failure 404 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/ban-status [get]
+func (h *ClientsHandler) getBanStatus(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, client.BanStatus())
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBundleUpload bounds the size of an uploaded session bundle
+const maxBundleUpload = 256 << 20
+
+// exportClient downloads a client's session as an encrypted bundle. The
+// bundle is encrypted with the gateway API key, which importing needs too.
+// @Summary Export a client's session
+// @Description Downloads the client's session store and state as a bundle encrypted with the gateway API key. The client keeps running. Only supported with the sqlite3 driver.
+// @Tags clients
+// @Produce octet-stream
+// @Param id path string true "Client ID"
+// @Success 200 {file} file
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 501 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/export [get]
+func (h *ClientsHandler) exportClient(c *gin.Context) {
+	id := c.Param("id")
+	bundle, err := h.clientManager.ExportClient(id, h.cfg.APIKey)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+id+`.wabundle"`)
+	c.Data(http.StatusOK, "application/octet-stream", bundle)
+}
+
+// importClient restores a client from a bundle sent as the request body.
+// Existing clients are only replaced with ?force=true.
+// @Summary Import a client's session
+// @Description Restores a client from a bundle made by the export endpoint on a gateway with the same API key. The client connects right away if it was connected when exported.
+// @Tags clients
+// @Accept octet-stream
+// @Produce json
+// @Param bundle body string true "Session bundle"
+// @Param force query bool false "Replace an existing client with the same ID"
+// @Success 201 {object} whatsapp.ClientState
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 501 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/import [post]
+func (h *ClientsHandler) importClient(c *gin.Context) {
+	bundle, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBundleUpload))
+	if err != nil {
+		respondStatus(c, http.StatusRequestEntityTooLarge, "Bundle is too large")
+		return
+	}
+
+	client, err := h.clientManager.ImportClient(bundle, h.cfg.APIKey, c.Query("force") == "true")
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, client.GetState())
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go-simple-whatsapp-gateway2/whatsapp"
+)
+
+// ChatSettingsRequest changes a chat's settings. Fields left out are not
+// changed.
+type ChatSettingsRequest struct {
+	Muted *bool `json:"muted"`
+	// MuteSeconds is how long to mute the chat for; 0 mutes it until it's
+	// unmuted
+	MuteSeconds int   `json:"mute_seconds"`
+	Archived    *bool `json:"archived"`
+	Pinned      *bool `json:"pinned"`
+}
+
+// setChatSettings mutes, archives or pins a chat and returns its new settings
+// @Summary Change a chat's settings
+// @Description Mutes, archives or pins a chat on every linked device. Archiving a chat also unpins it, so archived and pinned can't both be true.
+// @Tags clients
+// @Accept json
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param chatjid path string true "Phone number, user JID or group JID of the chat"
+// @Param request body ChatSettingsRequest true "Settings to change"
+// @Success 200 {object} whatsapp.ChatSettings
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/chats/{chatjid}/settings [put]
+func (h *ClientsHandler) setChatSettings(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req ChatSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondStatus(c, http.StatusBadRequest, "Invalid request")
+		return
+	}
+	switch {
+	case req.Muted == nil && req.Archived == nil && req.Pinned == nil:
+		respondStatus(c, http.StatusBadRequest, "muted, archived or pinned is required")
+		return
+	case req.MuteSeconds < 0:
+		respondStatus(c, http.StatusBadRequest, "mute_seconds cannot be negative")
+		return
+	case req.MuteSeconds > 0 && (req.Muted == nil || !*req.Muted):
+		respondStatus(c, http.StatusBadRequest, "mute_seconds requires muted")
+		return
+	case req.Archived != nil && *req.Archived && req.Pinned != nil && *req.Pinned:
+		respondStatus(c, http.StatusBadRequest, "An archived chat can't be pinned")
+		return
+	}
+
+	chatJID := c.Param("chatjid")
+	if req.Muted != nil {
+		duration := time.Duration(0)
+		if *req.Muted {
+			duration = whatsapp.MuteForever
+			if req.MuteSeconds > 0 {
+				duration = time.Duration(req.MuteSeconds) * time.Second
+			}
+		}
+		if err := client.MuteChat(chatJID, duration); err != nil {
+			respondError(c, err)
+			return
+		}
+	}
+	if req.Archived != nil {
+		if err := client.ArchiveChat(chatJID, *req.Archived); err != nil {
+			respondError(c, err)
+			return
+		}
+	}
+	if req.Pinned != nil {
+		if err := client.PinChat(chatJID, *req.Pinned); err != nil {
+			respondError(c, err)
+			return
+		}
+	}
+
+	settings, err := client.GetChatSettings(chatJID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
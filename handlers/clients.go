@@ -1,18 +1,31 @@
 package handlers
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"go-simple-whatsapp-gateway2/config"
 	"go-simple-whatsapp-gateway2/whatsapp"
 )
 
 // ClientRequest represents a client creation/update request
 type ClientRequest struct {
 	ID string `json:"id" binding:"required"`
+
+	// QRWebhook opts the client in to qr webhook events
+	QRWebhook bool `json:"qr_webhook"`
+
+	// DeviceName overrides DEVICE_NAME in the phone's linked devices list
+	DeviceName string `json:"device_name"`
+}
+
+// QRWebhookRequest represents a request to turn the qr webhook on or off
+type QRWebhookRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
 }
 
 // DefaultClientRequest represents a request to set the default client
@@ -25,72 +38,308 @@ type PairingRequest struct {
 	PhoneNumber string `json:"phone_number" binding:"required"`
 }
 
-// MessageRequest represents a message sending request
+// MessageRequest represents a message sending request. The text is either
+// given as message or rendered from a saved template and its variables. It
+// goes to either one recipient or each of a list of recipients.
 type MessageRequest struct {
+	Recipient  string            `json:"recipient"`
+	Recipients []string          `json:"recipients"`
+	Message    string            `json:"message"`
+	Template   string            `json:"template"`
+	Variables  map[string]string `json:"variables"`
+
+	// Optional reply context
+	QuotedMessageID string `json:"quoted_message_id"`
+	QuotedSender    string `json:"quoted_sender"`
+	QuotedText      string `json:"quoted_text"`
+
+	// Optional disappearing message timer, in seconds
+	EphemeralSeconds int `json:"ephemeral_seconds"`
+
+	// AllowSelf allows sending to the client's own number
+	AllowSelf bool `json:"allow_self"`
+
+	// Preview attaches a preview of the first link in the message
+	Preview bool `json:"preview"`
+
+	// CallbackURL receives a POST for each delivery and read receipt of
+	// the message
+	CallbackURL string `json:"callback_url"`
+
+	// Mentions are the phone numbers or user JIDs to tag
+	Mentions []string `json:"mentions"`
+}
+
+// sendOptions converts the optional request fields to send options
+func (r MessageRequest) sendOptions() whatsapp.SendOptions {
+	return whatsapp.SendOptions{
+		QuotedMessageID:  r.QuotedMessageID,
+		QuotedSender:     r.QuotedSender,
+		QuotedText:       r.QuotedText,
+		EphemeralSeconds: r.EphemeralSeconds,
+		AllowSelf:        r.AllowSelf,
+		LinkPreview:      r.Preview,
+		CallbackURL:      r.CallbackURL,
+		Mentions:         r.Mentions,
+	}
+}
+
+// GroupMessageRequest represents a group message sending request
+type GroupMessageRequest struct {
+	GroupJID string `json:"group_jid" binding:"required"`
+	Message  string `json:"message" binding:"required"`
+
+	// Optional reply context; quoted_sender is required with
+	// quoted_message_id in groups
+	QuotedMessageID string `json:"quoted_message_id"`
+	QuotedSender    string `json:"quoted_sender"`
+	QuotedText      string `json:"quoted_text"`
+
+	// Mentions are the phone numbers or user JIDs to tag
+	Mentions []string `json:"mentions"`
+}
+
+// sendOptions returns the send options of a group message request
+func (r GroupMessageRequest) sendOptions() whatsapp.SendOptions {
+	return whatsapp.SendOptions{
+		QuotedMessageID: r.QuotedMessageID,
+		QuotedSender:    r.QuotedSender,
+		QuotedText:      r.QuotedText,
+		Mentions:        r.Mentions,
+	}
+}
+
+// CheckNumbersRequest represents a request to check numbers on WhatsApp
+type CheckNumbersRequest struct {
+	Numbers []string `json:"numbers" binding:"required,min=1"`
+}
+
+// PresenceRequest represents a presence update request. State is one of
+// composing, paused, available or unavailable; chat_jid is only needed for
+// the chat states composing and paused.
+type PresenceRequest struct {
+	ChatJID string `json:"chat_jid"`
+	State   string `json:"state" binding:"required"`
+}
+
+// PresenceSubscribeRequest represents a request to follow a contact's presence
+type PresenceSubscribeRequest struct {
+	ContactJID string `json:"contact_jid" binding:"required"`
+}
+
+// RevokeRequest represents a request to delete a sent message
+type RevokeRequest struct {
+	ChatJID   string `json:"chat_jid" binding:"required"`
+	MessageID string `json:"message_id" binding:"required"`
+}
+
+// EditRequest represents a request to change the text of a sent message
+type EditRequest struct {
+	ChatJID   string `json:"chat_jid" binding:"required"`
+	MessageID string `json:"message_id" binding:"required"`
+	Message   string `json:"message" binding:"required"`
+}
+
+// DisappearingRequest represents a request to change a chat's disappearing
+// message timer. Seconds is 0 (off), 86400, 604800 or 7776000.
+type DisappearingRequest struct {
+	ChatJID string `json:"chat_jid" binding:"required"`
+	Seconds int    `json:"seconds"`
+}
+
+// ScheduleRequest represents a request to send a message at a later time
+type ScheduleRequest struct {
 	Recipient string `json:"recipient" binding:"required"`
 	Message   string `json:"message" binding:"required"`
+	SendAt    string `json:"send_at" binding:"required"`
+}
+
+// BulkMessageRequest represents a request to send one message to many recipients
+type BulkMessageRequest struct {
+	Recipients  []string `json:"recipients" binding:"required,min=1"`
+	Message     string   `json:"message" binding:"required"`
+	Concurrency int      `json:"concurrency"`
+	Ordered     bool     `json:"ordered"`
+}
+
+// BatchMessageRequest represents a request to send several messages to one
+// recipient in order
+type BatchMessageRequest struct {
+	Recipient       string                  `json:"recipient" binding:"required"`
+	Messages        []whatsapp.BatchMessage `json:"messages" binding:"required,min=1"`
+	ContinueOnError bool                    `json:"continue_on_error"`
 }
 
 // ClientsHandler handles multi-client API endpoints
 type ClientsHandler struct {
 	clientManager *whatsapp.ClientManager
+	cfg           *config.Config
 }
 
 // NewClientsHandler creates a new clients handler
-func NewClientsHandler(clientManager *whatsapp.ClientManager) *ClientsHandler {
+func NewClientsHandler(clientManager *whatsapp.ClientManager, cfg *config.Config) *ClientsHandler {
 	return &ClientsHandler{
 		clientManager: clientManager,
+		cfg:           cfg,
 	}
 }
 
 // RegisterRoutes registers the client API routes
 func (h *ClientsHandler) RegisterRoutes(router *gin.RouterGroup) {
 	router.GET("/clients", h.listClients)
+	router.GET("/clients.csv", h.exportClientsCSV)
 	router.POST("/clients", h.createClient)
 	router.POST("/clients/default", h.setDefaultClient)
+	router.POST("/clients/import", h.importClient)
 	router.GET("/clients/:id", h.getClient)
 	router.DELETE("/clients/:id", h.deleteClient)
+	router.POST("/clients/:id/rotate-key", h.rotateAPIKey)
+	router.GET("/clients/:id/export", h.exportClient)
+	router.PUT("/clients/:id/qr-webhook", h.setQRWebhook)
+	router.PATCH("/clients/:id/metadata", h.updateMetadata)
+	router.PUT("/clients/:id/filters", h.setFilters)
 	router.GET("/clients/:id/qr", h.generateQR)
 	router.POST("/clients/:id/pair", h.pairPhone)
 	router.GET("/clients/:id/paircode", h.getPairingCode)
 	router.POST("/clients/:id/send", h.sendMessage)
+	router.GET("/clients/:id/queue", h.getQueue)
+	router.GET("/clients/:id/events", h.getEvents)
+	router.GET("/clients/:id/debug", AdminOnlyMiddleware(h.cfg.APIKey), h.getDebug)
+	router.GET("/clients/:id/ban-status", h.getBanStatus)
+	router.GET("/clients/:id/deadletter", h.listDeadLetters)
+	router.POST("/clients/:id/deadletter/retry", h.retryDeadLetters)
+	router.POST("/clients/:id/send/bulk", h.sendBulk)
+	router.POST("/clients/:id/send/batch", h.sendBatch)
+	router.POST("/clients/:id/schedule", h.scheduleMessage)
+	router.GET("/clients/:id/schedule", h.listScheduled)
+	router.DELETE("/clients/:id/schedule/:jobid", h.cancelScheduled)
+	router.POST("/clients/:id/templates", h.saveTemplate)
+	router.GET("/clients/:id/templates", h.listTemplates)
+	router.DELETE("/clients/:id/templates/:name", h.deleteTemplate)
+	router.POST("/clients/:id/send/group", h.sendGroupMessage)
+	router.POST("/clients/:id/send/buttons", h.sendButtons)
+	router.POST("/clients/:id/send/list", h.sendList)
+	router.POST("/clients/:id/send/sticker", h.sendSticker)
+	router.GET("/clients/:id/messages", h.getMessages)
+	router.GET("/clients/:id/messages/:messageid", h.getMessage)
+	router.GET("/clients/:id/contacts", h.getContacts)
+	router.GET("/clients/:id/media/:chatjid/:messageid", h.getMedia)
+	router.GET("/clients/:id/receipts/:messageid", h.getReceipt)
+	router.POST("/clients/:id/revoke", h.revokeMessage)
+	router.POST("/clients/:id/edit", h.editMessage)
+	router.PUT("/clients/:id/disappearing", h.setDisappearing)
+	router.PUT("/clients/:id/chats/:chatjid/settings", h.setChatSettings)
+	router.POST("/clients/:id/check", h.checkNumbers)
+	router.POST("/clients/:id/presence", h.sendPresence)
+	router.POST("/clients/:id/presence/subscribe", h.subscribePresence)
+	router.GET("/clients/:id/lastseen", h.getLastSeen)
+	router.GET("/clients/:id/profile", h.getProfile)
+	router.PUT("/clients/:id/profile", h.updateProfile)
+	router.GET("/clients/:id/avatar", h.getAvatar)
+	router.PUT("/clients/:id/avatar", h.setAvatar)
+	router.GET("/clients/:id/newsletters", h.listNewsletters)
+	router.POST("/clients/:id/newsletters/:nid/send", h.sendNewsletter)
+	router.GET("/clients/:id/groups", h.listGroups)
+	router.GET("/clients/:id/groups/:gid", h.getGroupInfo)
+	router.POST("/clients/:id/groups", h.createGroup)
+	router.PUT("/clients/:id/groups/:gid", h.updateGroup)
+	router.POST("/clients/:id/groups/:gid/participants", h.addParticipants)
+	router.DELETE("/clients/:id/groups/:gid/participants", h.removeParticipants)
+	router.POST("/clients/:id/groups/:gid/admins", h.promoteParticipants)
+	router.DELETE("/clients/:id/groups/:gid/admins", h.demoteParticipants)
+	router.POST("/clients/:id/groups/:gid/leave", h.leaveGroup)
+	router.GET("/clients/:id/groups/:gid/invite", h.getGroupInvite)
+	router.POST("/clients/:id/groups/:gid/invite/reset", h.resetGroupInvite)
+	router.POST("/clients/:id/groups/join", h.joinGroup)
 	router.POST("/clients/:id/connect", h.connectClient)
+	router.POST("/clients/:id/resync", h.resyncClient)
 	router.POST("/clients/:id/disconnect", h.disconnectClient)
 	router.POST("/clients/:id/logout", h.logoutClient)
+	router.POST("/clients/:id/reset", h.resetClient)
 }
 
-// listClients lists all clients
+// listClients lists all clients, optionally only those carrying a label
+// @Summary List clients
+// @Tags clients
+// @Produce json
+// @Param label query string false "Only list clients with this label"
+// @Success 200 {object} object{clients=[]whatsapp.ClientState,default_client=string}
+// @Security ApiKeyAuth
+// @Router /clients [get]
 func (h *ClientsHandler) listClients(c *gin.Context) {
-	clients := h.clientManager.ListClients()
 	c.JSON(http.StatusOK, gin.H{
-		"clients":        clients,
+		"clients":        h.filteredClients(c),
 		"default_client": h.clientManager.GetDefaultClient(),
 	})
 }
 
+// filteredClients lists the clients matching the request's list filters
+func (h *ClientsHandler) filteredClients(c *gin.Context) []whatsapp.ClientState {
+	clients := h.clientManager.ListClients()
+	if label := c.Query("label"); label != "" {
+		filtered := make([]whatsapp.ClientState, 0, len(clients))
+		for _, state := range clients {
+			if state.HasLabel(label) {
+				filtered = append(filtered, state)
+			}
+		}
+		clients = filtered
+	}
+	return clients
+}
+
 // createClient creates a new client
+// @Summary Create a client
+// @Description The response holds the client's own API key, which is only shown here and on rotation.
+// @Tags clients
+// @Accept json
+// @Produce json
+// @Param request body ClientRequest true "Client to create"
+// @Success 201 {object} whatsapp.ClientState
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 429 {object} ClientLimitResponse
+// @Security ApiKeyAuth
+// @Router /clients [post]
 func (h *ClientsHandler) createClient(c *gin.Context) {
 	var req ClientRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		respondStatus(c, http.StatusBadRequest, "Invalid request")
 		return
 	}
 
-	client, err := h.clientManager.CreateClient(req.ID)
+	client, err := h.clientManager.CreateClient(req.ID, strings.TrimSpace(req.DeviceName))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, client.GetState())
+	if req.QRWebhook {
+		if err := client.SetQRWebhook(true); err != nil {
+			slog.Warn("Failed to enable QR webhook", "client", client.ID, "error", err)
+		}
+	}
+
+	// The client's API key is only ever shown here and on rotation
+	state := client.GetState()
+	state.APIKey = client.APIKey()
+	c.JSON(http.StatusCreated, state)
 }
 
 // getClient gets a client by ID
+// @Summary Get a client
+// @Tags clients
+// @Produce json
+// @Param id path string true "Client ID"
+// @Success 200 {object} whatsapp.ClientState
+// @Failure 404 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id} [get]
 func (h *ClientsHandler) getClient(c *gin.Context) {
 	id := c.Param("id")
 	client, err := h.clientManager.GetClient(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -98,26 +347,189 @@ func (h *ClientsHandler) getClient(c *gin.Context) {
 }
 
 // deleteClient deletes a client
+// @Summary Delete a client
+// @Description Logs the client out and removes all of its data.
+// @Tags clients
+// @Produce json
+// @Param id path string true "Client ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id} [delete]
 func (h *ClientsHandler) deleteClient(c *gin.Context) {
 	id := c.Param("id")
 	if err := h.clientManager.DeleteClient(id); err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
 
+// updateMetadata changes a client's labels and metadata
+// @Summary Update a client's labels and metadata
+// @Description Labels replace the current ones when given. Metadata entries are merged into the current ones; a null value removes the key.
+// @Tags clients
+// @Accept json
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param request body whatsapp.MetadataUpdate true "Labels and metadata"
+// @Success 200 {object} object{labels=[]string,metadata=map[string]string}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/metadata [patch]
+func (h *ClientsHandler) updateMetadata(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req whatsapp.MetadataUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondStatus(c, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	state, err := client.UpdateMetadata(req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"labels":   state.Labels,
+		"metadata": state.Metadata,
+	})
+}
+
+// setFilters replaces the filters deciding which received messages are
+// forwarded to the webhook
+// @Summary Set a client's inbound message filters
+// @Description A message is forwarded when it matches every list that is set; empty lists match everything. Sending empty filters forwards all messages again.
+// @Tags clients
+// @Accept json
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param request body whatsapp.InboundFilters true "Inbound filters"
+// @Success 200 {object} object{filters=whatsapp.InboundFilters}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/filters [put]
+func (h *ClientsHandler) setFilters(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req whatsapp.InboundFilters
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondStatus(c, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	filters, err := client.SetFilters(req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if filters == nil {
+		filters = &whatsapp.InboundFilters{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"filters": filters})
+}
+
+// setQRWebhook turns the qr webhook on or off for a client
+// @Summary Turn a client's qr webhook on or off
+// @Description While on, every QR code of a login is posted to the webhook as a qr event.
+// @Tags clients
+// @Accept json
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param request body QRWebhookRequest true "Whether to post QR codes"
+// @Success 200 {object} object{success=bool,enabled=bool}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/qr-webhook [put]
+func (h *ClientsHandler) setQRWebhook(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req QRWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondStatus(c, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	if err := client.SetQRWebhook(*req.Enabled); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "enabled": *req.Enabled})
+}
+
+// rotateAPIKey replaces a client's API key
+// @Summary Rotate a client's API key
+// @Tags clients
+// @Produce json
+// @Param id path string true "Client ID"
+// @Success 200 {object} object{api_key=string}
+// @Failure 404 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/rotate-key [post]
+func (h *ClientsHandler) rotateAPIKey(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	key, err := client.RotateAPIKey()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"api_key": key})
+}
+
 // setDefaultClient sets the default client
+// @Summary Set the default client
+// @Description The default client serves the legacy single-client endpoints.
+// @Tags clients
+// @Accept json
+// @Produce json
+// @Param request body DefaultClientRequest true "Client to make the default"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/default [post]
 func (h *ClientsHandler) setDefaultClient(c *gin.Context) {
 	var req DefaultClientRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		respondStatus(c, http.StatusBadRequest, "Invalid request")
 		return
 	}
 
 	if err := h.clientManager.SetDefaultClient(req.ID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -125,57 +537,82 @@ func (h *ClientsHandler) setDefaultClient(c *gin.Context) {
 }
 
 // generateQR generates a QR code for a client
+// @Summary Get a login QR code
+// @Description Starts linking the client and returns the first QR code to scan.
+// @Tags clients
+// @Produce json
+// @Param id path string true "Client ID"
+// @Success 200 {object} object{qr_code=string}
+// @Failure 409 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/qr [get]
 func (h *ClientsHandler) generateQR(c *gin.Context) {
 	id := c.Param("id")
-	log.Printf("Generating QR code for client: %s", id) // Add logging
-	
+	slog.Debug("Generating QR code", "client", id)
+
 	client, err := h.clientManager.GetClient(id)
 	if err != nil {
-		log.Printf("Error getting client %s: %v", id, err) // Add logging
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		slog.Warn("QR code requested for unknown client", "client", id, "error", err)
+		respondError(c, err)
 		return
 	}
 
 	// If the client is already logged in, return an appropriate error
 	state := client.GetState()
 	if state.LoggedIn {
-		log.Printf("Client %s is already logged in, no need for QR code", id)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Client is already logged in. Logout first if you want to reconnect.",
+		slog.Debug("Client already logged in, no QR code needed", "client", id)
+		c.JSON(http.StatusConflict, gin.H{
+			"error":     "Client is already logged in. Logout first if you want to reconnect.",
+			"code":      CodeAlreadyLoggedIn,
 			"logged_in": true,
 		})
 		return
 	}
 
 	// Try to generate the QR code
-	qrCode, err := client.GenerateQR()
+	qrCode, err := client.GenerateQR(c.Request.Context())
 	if err != nil {
-		log.Printf("Error generating QR code for client %s: %v", id, err) // Add logging
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error("Failed to generate QR code", "client", id, "error", err)
+		respondError(c, err)
 		return
 	}
 
-	log.Printf("QR code generated successfully for client %s", id) // Add logging
+	slog.Debug("QR code generated", "client", id)
 	c.JSON(http.StatusOK, gin.H{"qr_code": qrCode})
 }
 
 // pairPhone pairs a client with a phone number (currently not supported)
+// @Summary Pair a client with a phone number
+// @Description Not supported by the current WhatsApp library; always fails. Use the QR code instead.
+// @Tags clients
+// @Accept json
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param request body PairingRequest true "Phone number to pair with"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/pair [post]
 func (h *ClientsHandler) pairPhone(c *gin.Context) {
 	id := c.Param("id")
 	client, err := h.clientManager.GetClient(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
 	var req PairingRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		respondStatus(c, http.StatusBadRequest, "Invalid request")
 		return
 	}
 
 	if err := client.PairPhone(req.PhoneNumber); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -183,17 +620,27 @@ func (h *ClientsHandler) pairPhone(c *gin.Context) {
 }
 
 // getPairingCode gets the pairing code after a PairPhone request (currently not supported)
+// @Summary Get a client's pairing code
+// @Description Not supported by the current WhatsApp library; always fails. Use the QR code instead.
+// @Tags clients
+// @Produce json
+// @Param id path string true "Client ID"
+// @Success 200 {object} object{code=string}
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/paircode [get]
 func (h *ClientsHandler) getPairingCode(c *gin.Context) {
 	id := c.Param("id")
 	client, err := h.clientManager.GetClient(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
 	code, err := client.GetPairingCode()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -201,59 +648,792 @@ func (h *ClientsHandler) getPairingCode(c *gin.Context) {
 }
 
 // sendMessage sends a message from a client
+// @Summary Send a text message
+// @Description Waits until the message is sent unless async is set, in which case it is only queued. With recipients instead of recipient, the message goes to each of them in turn and the response is a MultiSendResult with one result per recipient.
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param request body MessageRequest true "Message to send"
+// @Param async query bool false "Queue the message and return a job ID"
+// @Param dry_run query bool false "Validate the message and return it without sending"
+// @Param jid_type query string false "Treat a bare recipient as a phone number or lid" Enums(auto, phone, lid)
+// @Success 200 {object} SendResult "Sent, DryRunResult in dry-run mode, or MultiSendResult with recipients"
+// @Success 202 {object} object{success=bool,queued=bool,job_id=string}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 408 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/send [post]
 func (h *ClientsHandler) sendMessage(c *gin.Context) {
 	id := c.Param("id")
 	client, err := h.clientManager.GetClient(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
 	var req MessageRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		respondStatus(c, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	// ?jid_type=lid marks a bare recipient as a lid rather than a phone number
+	if err := req.checkRecipients(c.Query("jid_type")); err != nil {
+		respondInvalid(c, err)
+		return
+	}
+
+	// Render the message from a template when one is named
+	if err := resolveMessage(client, &req); err != nil {
+		respondInvalid(c, err)
+		return
+	}
+
+	// A list of recipients gets a result for each of them
+	if len(req.Recipients) > 0 {
+		sendToRecipients(c, client, h.cfg, req)
+		return
+	}
+
+	// With ?dry_run=true the message is only validated and shown
+	if isDryRun(c, h.cfg) {
+		preview, err := client.PreviewMessage(req.Recipient, req.Message, req.sendOptions())
+		respondDryRun(c, client.ID, req.Recipient, preview, err)
+		return
+	}
+
+	// With ?async=true the message is only queued and the job ID returned
+	if c.Query("async") == "true" {
+		jobID, err := client.EnqueueMessage(req.Recipient, req.Message, req.sendOptions())
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{
+			"success": true,
+			"queued":  true,
+			"job_id":  jobID,
+		})
+		return
+	}
+
+	messageID, err := client.SendMessageContext(c.Request.Context(), req.Recipient, req.Message, req.sendOptions())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newSendResult(client.ID, messageID, req.Recipient))
+}
+
+// getQueue reports the state of a client's send queue
+// @Summary Get a client's send queue
+// @Tags messages
+// @Produce json
+// @Param id path string true "Client ID"
+// @Success 200 {object} whatsapp.QueueStatus
+// @Failure 404 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/queue [get]
+func (h *ClientsHandler) getQueue(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, client.QueueStatus())
+}
+
+// scheduleMessage schedules a message for later delivery
+// @Summary Schedule a message
+// @Description The message is queued for sending once send_at has passed. Scheduled messages are saved, so they survive a restart.
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param request body ScheduleRequest true "Message to schedule, with send_at as an RFC3339 timestamp"
+// @Param dry_run query bool false "Validate the message and return it without scheduling"
+// @Success 201 {object} whatsapp.ScheduledMessage
+// @Success 200 {object} DryRunResult "Dry-run mode"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/schedule [post]
+func (h *ClientsHandler) scheduleMessage(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req ScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondStatus(c, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	sendAt, err := time.Parse(time.RFC3339, req.SendAt)
+	if err != nil {
+		respondStatus(c, http.StatusBadRequest, "send_at must be an RFC3339 timestamp")
+		return
+	}
+	if !sendAt.After(time.Now()) {
+		respondStatus(c, http.StatusBadRequest, "send_at must be in the future")
+		return
+	}
+
+	// With ?dry_run=true the message is only validated and shown
+	if isDryRun(c, h.cfg) {
+		preview, err := client.PreviewMessage(req.Recipient, req.Message, whatsapp.SendOptions{})
+		respondDryRun(c, client.ID, req.Recipient, preview, err)
+		return
+	}
+
+	scheduled, err := client.ScheduleMessage(req.Recipient, req.Message, sendAt)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, scheduled)
+}
+
+// listScheduled lists a client's pending scheduled messages
+// @Summary List scheduled messages
+// @Tags messages
+// @Produce json
+// @Param id path string true "Client ID"
+// @Success 200 {object} object{scheduled=[]whatsapp.ScheduledMessage}
+// @Failure 404 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/schedule [get]
+func (h *ClientsHandler) listScheduled(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"scheduled": client.ListScheduled()})
+}
+
+// cancelScheduled cancels a pending scheduled message
+// @Summary Cancel a scheduled message
+// @Tags messages
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param jobid path string true "Scheduled message ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/schedule/{jobid} [delete]
+func (h *ClientsHandler) cancelScheduled(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if err := client.CancelScheduled(c.Param("jobid")); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// sendGroupMessage sends a message to a group from a client
+// @Summary Send a message to a group
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param request body GroupMessageRequest true "Message to send"
+// @Param dry_run query bool false "Validate the message and return it without sending"
+// @Success 200 {object} SendResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/send/group [post]
+func (h *ClientsHandler) sendGroupMessage(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req GroupMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondStatus(c, http.StatusBadRequest, "Invalid request")
 		return
 	}
 
-	if err := client.SendMessage(req.Recipient, req.Message); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if isDryRun(c, h.cfg) {
+		preview, err := client.PreviewGroupMessage(req.GroupJID, req.Message, req.sendOptions())
+		respondDryRun(c, client.ID, req.GroupJID, preview, err)
 		return
 	}
 
+	messageID, err := client.SendGroupMessage(req.GroupJID, req.Message, req.sendOptions())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newSendResult(client.ID, messageID, req.GroupJID))
+}
+
+// sendBulk sends the same message to several recipients from a client.
+// With ?stream=true the results are streamed as server-sent events: one
+// "result" event per recipient followed by a final "summary" event.
+// @Summary Send a message to many recipients
+// @Description With stream=true, results are sent as server-sent events while the send runs.
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param request body BulkMessageRequest true "Message and recipients"
+// @Param stream query bool false "Stream results as server-sent events"
+// @Param dry_run query bool false "Only validate each recipient"
+// @Success 200 {object} object{results=[]whatsapp.BulkResult,summary=whatsapp.BulkSummary}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/send/bulk [post]
+func (h *ClientsHandler) sendBulk(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req BulkMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondStatus(c, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	// Never exceed the configured concurrency
+	concurrency := h.cfg.BulkConcurrency
+	if req.Concurrency > 0 && req.Concurrency < concurrency {
+		concurrency = req.Concurrency
+	}
+	opts := whatsapp.BulkOptions{
+		Concurrency: concurrency,
+		Delay:       time.Duration(h.cfg.BulkDelayMs) * time.Millisecond,
+		Ordered:     req.Ordered,
+		DryRun:      isDryRun(c, h.cfg),
+	}
+
+	// The request context is cancelled when the caller goes away, which stops
+	// any remaining sends
+	ctx := c.Request.Context()
+
+	if c.Query("stream") != "true" {
+		results, summary := client.SendBulk(ctx, req.Recipients, req.Message, opts, nil)
+		c.JSON(http.StatusOK, gin.H{
+			"results": results,
+			"summary": summary,
+		})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+
+	_, summary := client.SendBulk(ctx, req.Recipients, req.Message, opts, func(result whatsapp.BulkResult) {
+		if ctx.Err() != nil {
+			return
+		}
+		c.SSEvent("result", result)
+		c.Writer.Flush()
+	})
+
+	if ctx.Err() != nil {
+		slog.Info("Bulk send stream closed by caller", "client", id)
+		return
+	}
+	c.SSEvent("summary", summary)
+	c.Writer.Flush()
+}
+
+// sendBatch sends several messages to one recipient, one after another.
+// Sending stops at the first failure unless continue_on_error is set; the
+// messages after it are reported as skipped.
+// @Summary Send several messages to one recipient in order
+// @Description Every message is validated before the first one is sent. Types are text, buttons and list, with the same fields as the matching send endpoints.
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param request body BatchMessageRequest true "Recipient and messages"
+// @Param dry_run query bool false "Only validate the messages"
+// @Success 200 {object} object{success=bool,client_id=string,recipient=string,results=[]whatsapp.BatchResult}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/send/batch [post]
+func (h *ClientsHandler) sendBatch(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req BatchMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondStatus(c, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	opts := whatsapp.BatchOptions{
+		ContinueOnError: req.ContinueOnError,
+		DryRun:          isDryRun(c, h.cfg),
+	}
+	results, err := client.SendBatch(c.Request.Context(), req.Recipient, req.Messages, opts)
+	if err != nil {
+		respondInvalid(c, err)
+		return
+	}
+
+	success := true
+	for _, result := range results {
+		if result.Status == whatsapp.BatchStatusFailed || result.Status == whatsapp.BatchStatusSkipped {
+			success = false
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"sent_at": time.Now(),
+		"success":   success,
+		"client_id": client.ID,
+		"recipient": req.Recipient,
+		"results":   results,
 	})
 }
 
+// revokeMessage deletes a previously sent message for everyone
+// @Summary Delete a sent message for everyone
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param request body RevokeRequest true "Message to delete"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/revoke [post]
+func (h *ClientsHandler) revokeMessage(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req RevokeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondStatus(c, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	if err := client.RevokeMessage(req.ChatJID, req.MessageID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// editMessage changes the text of a previously sent message
+// @Summary Edit a sent message
+// @Description WhatsApp only accepts edits within 15 minutes of sending; later edits fail with 409.
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param request body EditRequest true "Message to edit and its new text"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/edit [post]
+func (h *ClientsHandler) editMessage(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req EditRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondStatus(c, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	if err := client.EditMessage(req.ChatJID, req.MessageID, req.Message); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// setDisappearing turns disappearing messages on or off for a chat
+// @Summary Set a chat's disappearing message timer
+// @Description seconds is 0 to turn disappearing messages off, or 86400, 604800 or 7776000.
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param request body DisappearingRequest true "Chat and timer"
+// @Success 200 {object} object{success=bool,chat_jid=string,seconds=int}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/disappearing [put]
+func (h *ClientsHandler) setDisappearing(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req DisappearingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondStatus(c, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	if err := client.SetDisappearingTimer(req.ChatJID, time.Duration(req.Seconds)*time.Second); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "chat_jid": req.ChatJID, "seconds": req.Seconds})
+}
+
+// checkNumbers checks whether phone numbers are registered on WhatsApp
+// @Summary Check phone numbers are on WhatsApp
+// @Tags contacts
+// @Accept json
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param request body CheckNumbersRequest true "Phone numbers to check"
+// @Success 200 {object} object{results=[]whatsapp.NumberStatus}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/check [post]
+func (h *ClientsHandler) checkNumbers(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req CheckNumbersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondStatus(c, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	results, err := client.CheckNumbers(req.Numbers)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+// sendPresence updates the typing indicator in a chat or the global online state
+// @Summary Send a typing indicator or set the online state
+// @Description composing and paused show or clear the typing indicator in chat_jid; available and unavailable set the account's online state.
+// @Tags contacts
+// @Accept json
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param request body PresenceRequest true "Presence to send"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/presence [post]
+func (h *ClientsHandler) sendPresence(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req PresenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondStatus(c, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	switch req.State {
+	case "composing", "paused":
+		if req.ChatJID == "" {
+			respondStatus(c, http.StatusBadRequest, "chat_jid is required for chat presence")
+			return
+		}
+		err = client.SendPresence(req.ChatJID, req.State == "composing")
+	case "available", "unavailable":
+		err = client.SetPresence(req.State == "available")
+	default:
+		respondStatus(c, http.StatusBadRequest, "state must be one of composing, paused, available, unavailable")
+		return
+	}
+
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// subscribePresence subscribes to a contact's online and typing updates
+// @Summary Follow a contact's presence
+// @Description The contact's online and typing updates are then posted to the webhook.
+// @Tags contacts
+// @Accept json
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param request body PresenceSubscribeRequest true "Contact to follow"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/presence/subscribe [post]
+func (h *ClientsHandler) subscribePresence(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req PresenceSubscribeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondStatus(c, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	if err := client.SubscribePresence(req.ContactJID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// getLastSeen reports whether a contact is online and when they were last
+// seen. This only works for contacts who share their last seen with the
+// account; last_seen is left out when they hide it.
+// @Summary Get a contact's last seen time
+// @Description last_seen is left out when the contact hides it.
+// @Tags contacts
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param jid query string true "Phone number or user JID of the contact"
+// @Success 200 {object} object{jid=string,online=bool,last_seen=string}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/lastseen [get]
+func (h *ClientsHandler) getLastSeen(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	jid := c.Query("jid")
+	if jid == "" {
+		respondStatus(c, http.StatusBadRequest, "jid is required")
+		return
+	}
+
+	lastSeen, online, err := client.GetLastSeen(jid)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	response := gin.H{
+		"jid":    jid,
+		"online": online,
+	}
+	if !lastSeen.IsZero() {
+		response["last_seen"] = lastSeen
+	}
+	c.JSON(http.StatusOK, response)
+}
+
 // connectClient connects a client
+// @Summary Connect a client
+// @Tags clients
+// @Produce json
+// @Param id path string true "Client ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/connect [post]
 func (h *ClientsHandler) connectClient(c *gin.Context) {
 	id := c.Param("id")
 	client, err := h.clientManager.GetClient(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
 	if err := client.Connect(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, client.GetState())
 }
 
+// resyncClient fetches the client's app state (contacts, chat settings)
+// from scratch
+// @Summary Resync a client's contacts and chat settings
+// @Description Useful after a client was offline for a long time. Each kind of app state is synced separately and reported in the results.
+// @Tags clients
+// @Produce json
+// @Param id path string true "Client ID"
+// @Success 200 {object} object{success=bool,results=[]whatsapp.ResyncResult}
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/resync [post]
+func (h *ClientsHandler) resyncClient(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	results, err := client.Resync(c.Request.Context())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	success := true
+	for _, result := range results {
+		if !result.Success {
+			success = false
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": success,
+		"results": results,
+	})
+}
+
 // disconnectClient disconnects a client
+// @Summary Disconnect a client
+// @Tags clients
+// @Produce json
+// @Param id path string true "Client ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/disconnect [post]
 func (h *ClientsHandler) disconnectClient(c *gin.Context) {
 	id := c.Param("id")
 	client, err := h.clientManager.GetClient(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
 	if err := client.Disconnect(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, client.GetState())
+}
+
+// resetClient wipes a client's session so it can be linked again under the
+// same ID
+// @Summary Reset a client's session
+// @Description Logs out and deletes the session store, keeping the client's settings, so a broken session can be linked again with a new QR code. The received message history is lost. Requires confirm=true.
+// @Tags clients
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param confirm query bool true "Confirm wiping the session"
+// @Success 200 {object} whatsapp.ClientState
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/reset [post]
+func (h *ClientsHandler) resetClient(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := h.clientManager.GetClient(id); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if c.Query("confirm") != "true" {
+		respondStatus(c, http.StatusBadRequest, "Resetting deletes the session and message history; repeat the request with ?confirm=true")
+		return
+	}
+
+	slog.Info("Resetting client session", "client", id)
+	client, err := h.clientManager.ResetClient(id)
+	if err != nil {
+		slog.Error("Failed to reset client", "client", id, "error", err)
+		respondError(c, err)
 		return
 	}
 
@@ -261,13 +1441,23 @@ func (h *ClientsHandler) disconnectClient(c *gin.Context) {
 }
 
 // logoutClient logs out a client
+// @Summary Log out a client
+// @Description Unlinks the device; a new QR code is needed to log in again.
+// @Tags clients
+// @Produce json
+// @Param id path string true "Client ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/logout [post]
 func (h *ClientsHandler) logoutClient(c *gin.Context) {
 	id := c.Param("id")
-	log.Printf("Logging out client: %s", id)
-	
+	slog.Info("Logging out client", "client", id)
+
 	client, err := h.clientManager.GetClient(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -278,11 +1468,11 @@ func (h *ClientsHandler) logoutClient(c *gin.Context) {
 	// Now attempt formal logout
 	err = client.Logout()
 	if err != nil {
-		log.Printf("Error logging out client %s: %v", id, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		slog.Error("Failed to log out client", "client", id, "error", err)
+		respondError(c, err)
 		return
 	}
 
-	log.Printf("Client %s logged out successfully", id)
+	slog.Info("Client logged out", "client", id)
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
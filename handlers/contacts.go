@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultContactsLimit = 100
+	maxContactsLimit     = 1000
+)
+
+// getContacts returns a page of the contacts known to a client. Supports
+// ?limit= and ?offset=; contacts are ordered by JID so pages are stable.
+// @Summary List a client's contacts
+// @Description Contacts are ordered by JID. next_offset is set while there are more pages.
+// @Tags contacts
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param limit query int false "Contacts per page, 1 to 1000" default(100)
+// @Param offset query int false "Contacts to skip"
+// @Success 200 {object} object{contacts=[]whatsapp.ContactInfo,total=int,next_offset=int}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/contacts [get]
+func (h *ClientsHandler) getContacts(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	limit := defaultContactsLimit
+	if v := c.Query("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxContactsLimit {
+			respondStatus(c, http.StatusBadRequest, "limit must be between 1 and 1000")
+			return
+		}
+	}
+	offset := 0
+	if v := c.Query("offset"); v != "" {
+		offset, err = strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			respondStatus(c, http.StatusBadRequest, "offset must be a non-negative number")
+			return
+		}
+	}
+
+	contacts, err := client.GetContacts()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	total := len(contacts)
+	start := min(offset, total)
+	end := min(start+limit, total)
+
+	response := gin.H{
+		"contacts": contacts[start:end],
+		"total":    total,
+	}
+	if end < total {
+		response["next_offset"] = end
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RetryDeadLettersRequest selects the dead letters to replay. An empty body
+// or list replays all of them.
+type RetryDeadLettersRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// listDeadLetters lists the messages a client failed to send
+// @Summary List messages that failed to send
+// @Tags messages
+// @Produce json
+// @Param id path string true "Client ID"
+// @Success 200 {object} object{dead_letters=[]whatsapp.DeadLetter}
+// @Failure 404 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/deadletter [get]
+func (h *ClientsHandler) listDeadLetters(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"dead_letters": client.ListDeadLetters()})
+}
+
+// retryDeadLetters queues failed messages for sending again
+// @Summary Send failed messages again
+// @Description Queues the listed dead letters, or all of them when ids is empty or the body is left out.
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param request body RetryDeadLettersRequest false "Dead letters to retry"
+// @Success 200 {object} object{results=[]whatsapp.DeadLetterRetry}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/deadletter/retry [post]
+func (h *ClientsHandler) retryDeadLetters(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req RetryDeadLettersRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondStatus(c, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	results, err := client.RetryDeadLetters(req.IDs)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"go-simple-whatsapp-gateway2/whatsapp"
+)
+
+// DebugResponse is returned by GET /api/clients/:id/debug
+type DebugResponse struct {
+	whatsapp.Diagnostics
+	WhatsmeowVersion string `json:"whatsmeow_version"`
+}
+
+// getDebug returns low-level connection diagnostics for a client, for
+// filing bug reports. Secrets are only reported as present or not.
+// @Summary Get a client's connection diagnostics
+// @Description Needs the global API key. Session keys are only reported as present or as a hash.
+// @Tags clients
+// @Produce json
+// @Param id path string true "Client ID"
+// @Success 200 {object} DebugResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/debug [get]
+func (h *ClientsHandler) getDebug(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, DebugResponse{
+		Diagnostics:      client.Diagnostics(),
+		WhatsmeowVersion: whatsmeowVersion(),
+	})
+}
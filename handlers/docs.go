@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+
+	// Generated OpenAPI spec, regenerate with `swag init`
+	_ "go-simple-whatsapp-gateway2/docs"
+)
+
+// ErrorResponse is the body of every failed API request
+type ErrorResponse struct {
+	Error string `json:"error" example:"client not found: my-client"`
+	// Code is a stable, machine-readable version of the error
+	Code string `json:"code" example:"client_not_found"`
+}
+
+// ClientLimitResponse is the error returned when MAX_CLIENTS is reached
+type ClientLimitResponse struct {
+	Error string `json:"error" example:"client limit reached: 10 of 10 clients in use"`
+	Code  string `json:"code" example:"client_limit_reached"`
+	// Count is the number of clients loaded
+	Count int `json:"count" example:"10"`
+	// Limit is MAX_CLIENTS
+	Limit int `json:"limit" example:"10"`
+}
+
+// SuccessResponse is the body of API requests that return nothing else
+type SuccessResponse struct {
+	Success bool `json:"success" example:"true"`
+}
+
+// registerDocs serves the OpenAPI spec and Swagger UI under /api/docs. They
+// describe the API rather than expose anything, so they need no API key.
+func registerDocs(router *gin.Engine) {
+	router.GET("/api/docs", func(c *gin.Context) {
+		c.Redirect(http.StatusFound, "/api/docs/index.html")
+	})
+	router.GET("/api/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+}
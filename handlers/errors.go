@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"go-simple-whatsapp-gateway2/whatsapp"
+)
+
+// Error codes returned in the "code" field of error responses. Messages may
+// change between versions; codes don't, so API consumers should branch on
+// them.
+const (
+	CodeInvalidRequest   = "invalid_request"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeClientNotFound   = "client_not_found"
+	CodeNoDefaultClient  = "no_default_client"
+	CodeConflict         = "conflict"
+	CodeNotConnected     = "not_connected"
+	CodeNotLoggedIn      = "not_logged_in"
+	CodeAlreadyLoggedIn  = "already_logged_in"
+	CodeAlreadyExists    = "already_exists"
+	CodeEditExpired      = "edit_window_expired"
+	CodeInvalidRecipient = "invalid_recipient"
+	CodeInvalidMessage   = "invalid_message"
+	CodeTimeout          = "timeout"
+	CodeTooLarge         = "too_large"
+	CodeClientOutdated   = "client_outdated"
+	CodeQueueFull        = "queue_full"
+	CodeClientLimit      = "client_limit_reached"
+	CodeUnavailable      = "unavailable"
+	CodeNotImplemented   = "not_implemented"
+	CodeUpstreamError    = "upstream_error"
+	CodeInternal         = "internal_error"
+)
+
+// errorMapping ties an error to the status and code it's reported with
+type errorMapping struct {
+	err     error
+	status  int
+	code    string
+	message string
+}
+
+// errorMappings lists the errors with a status of their own. The first one
+// an error wraps wins.
+var errorMappings = []errorMapping{
+	{err: whatsapp.ErrClientNotFound, status: http.StatusNotFound, code: CodeClientNotFound},
+	{err: whatsapp.ErrNoDefaultClient, status: http.StatusConflict, code: CodeNoDefaultClient, message: noDefaultClientMessage},
+	{err: whatsapp.ErrNotConnected, status: http.StatusConflict, code: CodeNotConnected},
+	{err: whatsapp.ErrNotLoggedIn, status: http.StatusConflict, code: CodeNotLoggedIn, message: "Client is not logged in"},
+	{err: whatsapp.ErrAlreadyLoggedIn, status: http.StatusConflict, code: CodeAlreadyLoggedIn},
+	{err: whatsapp.ErrAlreadyExists, status: http.StatusConflict, code: CodeAlreadyExists},
+	{err: whatsapp.ErrClientLimit, status: http.StatusTooManyRequests, code: CodeClientLimit},
+	{err: whatsapp.ErrClientBusy, status: http.StatusConflict, code: CodeConflict},
+	{err: whatsapp.ErrQRSuperseded, status: http.StatusConflict, code: CodeConflict},
+	{err: whatsapp.ErrEditWindowExpired, status: http.StatusConflict, code: CodeEditExpired},
+	{err: whatsapp.ErrInvalidRecipient, status: http.StatusBadRequest, code: CodeInvalidRecipient},
+	{err: whatsapp.ErrInvalidGroupJID, status: http.StatusBadRequest, code: CodeInvalidRecipient},
+	{err: whatsapp.ErrInvalidMessage, status: http.StatusBadRequest, code: CodeInvalidMessage},
+	{err: whatsapp.ErrInvalidClientID, status: http.StatusBadRequest, code: CodeInvalidRequest},
+	{err: whatsapp.ErrInvalidInviteLink, status: http.StatusBadRequest, code: CodeInvalidRequest},
+	{err: whatsapp.ErrInvalidTemplate, status: http.StatusBadRequest, code: CodeInvalidRequest},
+	{err: whatsapp.ErrInvalidMetadata, status: http.StatusBadRequest, code: CodeInvalidRequest},
+	{err: whatsapp.ErrInvalidBundle, status: http.StatusBadRequest, code: CodeInvalidRequest},
+	{err: whatsapp.ErrInvalidDisappearingTimer, status: http.StatusBadRequest, code: CodeInvalidRequest},
+	{err: whatsapp.ErrInvalidFilter, status: http.StatusBadRequest, code: CodeInvalidRequest},
+	{err: whatsapp.ErrMediaNotFound, status: http.StatusNotFound, code: CodeNotFound},
+	{err: whatsapp.ErrNoMedia, status: http.StatusNotFound, code: CodeNotFound},
+	{err: whatsapp.ErrReceiptNotFound, status: http.StatusNotFound, code: CodeNotFound},
+	{err: whatsapp.ErrMessageNotFound, status: http.StatusNotFound, code: CodeNotFound},
+	{err: whatsapp.ErrScheduledNotFound, status: http.StatusNotFound, code: CodeNotFound},
+	{err: whatsapp.ErrTemplateNotFound, status: http.StatusNotFound, code: CodeNotFound},
+	{err: whatsapp.ErrDeadLetterNotFound, status: http.StatusNotFound, code: CodeNotFound},
+	{err: whatsapp.ErrNoProfilePicture, status: http.StatusNotFound, code: CodeNotFound},
+	{err: whatsapp.ErrPresenceUnavailable, status: http.StatusNotFound, code: CodeNotFound},
+	{err: whatsapp.ErrQueueFull, status: http.StatusServiceUnavailable, code: CodeQueueFull},
+	{err: whatsapp.ErrClientOutdated, status: http.StatusServiceUnavailable, code: CodeClientOutdated},
+	{err: whatsapp.ErrExportUnsupported, status: http.StatusNotImplemented, code: CodeNotImplemented},
+	{err: context.DeadlineExceeded, status: http.StatusRequestTimeout, code: CodeTimeout, message: "Timed out waiting for WhatsApp"},
+}
+
+// statusCodes holds the code of errors reported without a known cause
+var statusCodes = map[int]string{
+	http.StatusBadRequest:            CodeInvalidRequest,
+	http.StatusUnauthorized:          CodeUnauthorized,
+	http.StatusForbidden:             CodeForbidden,
+	http.StatusNotFound:              CodeNotFound,
+	http.StatusRequestTimeout:        CodeTimeout,
+	http.StatusConflict:              CodeConflict,
+	http.StatusRequestEntityTooLarge: CodeTooLarge,
+	http.StatusNotImplemented:        CodeNotImplemented,
+	http.StatusBadGateway:            CodeUpstreamError,
+	http.StatusServiceUnavailable:    CodeUnavailable,
+}
+
+// errorStatus returns the status, code and message to report err with.
+// Unknown errors are internal errors.
+func errorStatus(err error) (int, string, string) {
+	for _, mapping := range errorMappings {
+		if errors.Is(err, mapping.err) {
+			message := mapping.message
+			if message == "" {
+				message = err.Error()
+			}
+			return mapping.status, mapping.code, message
+		}
+	}
+	return http.StatusInternalServerError, CodeInternal, err.Error()
+}
+
+// statusCode returns the generic code for an error status
+func statusCode(status int) string {
+	if code, ok := statusCodes[status]; ok {
+		return code
+	}
+	return CodeInternal
+}
+
+// respondError answers a failed request with the status and code matching err
+func respondError(c *gin.Context, err error) {
+	status, code, message := errorStatus(err)
+
+	// Tell callers what the cap is
+	var limitErr *whatsapp.ClientLimitError
+	if errors.As(err, &limitErr) {
+		c.JSON(status, ClientLimitResponse{Error: message, Code: code, Count: limitErr.Count, Limit: limitErr.Limit})
+		return
+	}
+
+	c.JSON(status, ErrorResponse{Error: message, Code: code})
+}
+
+// respondInvalid answers a request rejected by validation. Known errors keep
+// their own status and code; anything else is an invalid request.
+func respondInvalid(c *gin.Context, err error) {
+	status, code, message := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		status, code = http.StatusBadRequest, CodeInvalidRequest
+	}
+	c.JSON(status, ErrorResponse{Error: message, Code: code})
+}
+
+// respondStatus answers a failed request that has no error value, such as a
+// malformed body, with message and the generic code for status
+func respondStatus(c *gin.Context, status int, message string) {
+	c.JSON(status, ErrorResponse{Error: message, Code: statusCode(status)})
+}
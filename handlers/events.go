@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getEvents returns a client's recent connection events, newest first.
+// Supports ?limit= to only return the latest few.
+// @Summary Get a client's connection events
+// @Tags clients
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param limit query int false "Only return the latest events"
+// @Success 200 {object} object{client_id=string,events=[]whatsapp.LogEvent}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/events [get]
+func (h *ClientsHandler) getEvents(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	limit := 0
+	if v := c.Query("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			respondStatus(c, http.StatusBadRequest, "limit must be a positive number")
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"client_id": client.ID,
+		"events":    client.Events(limit),
+	})
+}
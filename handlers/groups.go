@@ -0,0 +1,454 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mau.fi/whatsmeow/types"
+
+	"go-simple-whatsapp-gateway2/whatsapp"
+)
+
+// listGroups lists the groups a client has joined
+// @Summary List joined groups
+// @Tags groups
+// @Produce json
+// @Param id path string true "Client ID"
+// @Success 200 {object} object{groups=[]whatsapp.GroupInfo}
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/groups [get]
+func (h *ClientsHandler) listGroups(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	groups, err := client.ListGroups()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"groups": groups})
+}
+
+// GroupParticipantResponse describes a group member
+type GroupParticipantResponse struct {
+	JID          string `json:"jid"`
+	PhoneNumber  string `json:"phone_number,omitempty"`
+	IsAdmin      bool   `json:"is_admin"`
+	IsSuperAdmin bool   `json:"is_super_admin"`
+}
+
+// GroupInfoResponse describes a group and its members
+type GroupInfoResponse struct {
+	JID          string                     `json:"jid"`
+	Name         string                     `json:"name"`
+	Topic        string                     `json:"topic,omitempty"`
+	CreatedAt    time.Time                  `json:"created_at"`
+	Participants []GroupParticipantResponse `json:"participants"`
+}
+
+// getGroupInfo gets the metadata and participants of a group
+// @Summary Get a group and its participants
+// @Tags groups
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param gid path string true "Group JID"
+// @Success 200 {object} GroupInfoResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/groups/{gid} [get]
+func (h *ClientsHandler) getGroupInfo(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	info, err := client.GetGroupInfo(c.Param("gid"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newGroupInfoResponse(info))
+}
+
+// newGroupInfoResponse converts whatsmeow group info to its API representation
+func newGroupInfoResponse(info *types.GroupInfo) GroupInfoResponse {
+	participants := make([]GroupParticipantResponse, 0, len(info.Participants))
+	for _, p := range info.Participants {
+		participant := GroupParticipantResponse{
+			JID:          p.JID.String(),
+			IsAdmin:      p.IsAdmin,
+			IsSuperAdmin: p.IsSuperAdmin,
+		}
+		if !p.PhoneNumber.IsEmpty() {
+			participant.PhoneNumber = p.PhoneNumber.User
+		}
+		participants = append(participants, participant)
+	}
+
+	return GroupInfoResponse{
+		JID:          info.JID.String(),
+		Name:         info.Name,
+		Topic:        info.Topic,
+		CreatedAt:    info.GroupCreated,
+		Participants: participants,
+	}
+}
+
+// CreateGroupRequest represents a request to create a group
+type CreateGroupRequest struct {
+	Name         string   `json:"name" binding:"required"`
+	Participants []string `json:"participants" binding:"required"`
+}
+
+// ParticipantsRequest represents a request to change group participants
+type ParticipantsRequest struct {
+	Participants []string `json:"participants" binding:"required"`
+}
+
+// createGroup creates a group
+// @Summary Create a group
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param request body CreateGroupRequest true "Group name and participants"
+// @Success 201 {object} GroupInfoResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/groups [post]
+func (h *ClientsHandler) createGroup(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req CreateGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondStatus(c, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	info, err := client.CreateGroup(req.Name, req.Participants)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, newGroupInfoResponse(info))
+}
+
+// UpdateGroupRequest represents a request to change a group's name or topic.
+// Fields left out are not changed.
+type UpdateGroupRequest struct {
+	Name  *string `json:"name"`
+	Topic *string `json:"topic"`
+}
+
+// updateGroup changes the name and/or topic of a group
+// @Summary Rename a group or change its topic
+// @Description Fields left out aren't changed.
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param gid path string true "Group JID"
+// @Param request body UpdateGroupRequest true "New name and/or topic"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/groups/{gid} [put]
+func (h *ClientsHandler) updateGroup(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req UpdateGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondStatus(c, http.StatusBadRequest, "Invalid request")
+		return
+	}
+	if req.Name == nil && req.Topic == nil {
+		respondStatus(c, http.StatusBadRequest, "name or topic is required")
+		return
+	}
+
+	// Check both fields up front so a bad topic doesn't leave a renamed group
+	if req.Name != nil {
+		if err := whatsapp.ValidateGroupName(*req.Name); err != nil {
+			respondInvalid(c, err)
+			return
+		}
+	}
+	if req.Topic != nil {
+		if err := whatsapp.ValidateGroupTopic(*req.Topic); err != nil {
+			respondInvalid(c, err)
+			return
+		}
+	}
+
+	gid := c.Param("gid")
+	if req.Name != nil {
+		if err := client.SetGroupName(gid, *req.Name); err != nil {
+			respondError(c, err)
+			return
+		}
+	}
+	if req.Topic != nil {
+		if err := client.SetGroupTopic(gid, *req.Topic); err != nil {
+			respondError(c, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// addParticipants adds participants to a group
+// @Summary Add group participants
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param gid path string true "Group JID"
+// @Param request body ParticipantsRequest true "Phone numbers or user JIDs"
+// @Success 200 {object} GroupInfoResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/groups/{gid}/participants [post]
+func (h *ClientsHandler) addParticipants(c *gin.Context) {
+	h.changeParticipants(c, (*whatsapp.Client).AddParticipants)
+}
+
+// removeParticipants removes participants from a group
+// @Summary Remove group participants
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param gid path string true "Group JID"
+// @Param request body ParticipantsRequest true "Phone numbers or user JIDs"
+// @Success 200 {object} GroupInfoResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/groups/{gid}/participants [delete]
+func (h *ClientsHandler) removeParticipants(c *gin.Context) {
+	h.changeParticipants(c, (*whatsapp.Client).RemoveParticipants)
+}
+
+// promoteParticipants makes participants group admins
+// @Summary Make participants group admins
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param gid path string true "Group JID"
+// @Param request body ParticipantsRequest true "Phone numbers or user JIDs"
+// @Success 200 {object} GroupInfoResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/groups/{gid}/admins [post]
+func (h *ClientsHandler) promoteParticipants(c *gin.Context) {
+	h.changeParticipants(c, (*whatsapp.Client).PromoteParticipants)
+}
+
+// demoteParticipants revokes the admin rights of participants
+// @Summary Revoke participants' admin rights
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param gid path string true "Group JID"
+// @Param request body ParticipantsRequest true "Phone numbers or user JIDs"
+// @Success 200 {object} GroupInfoResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/groups/{gid}/admins [delete]
+func (h *ClientsHandler) demoteParticipants(c *gin.Context) {
+	h.changeParticipants(c, (*whatsapp.Client).DemoteParticipants)
+}
+
+// changeParticipants applies a participant change and responds with the updated group
+func (h *ClientsHandler) changeParticipants(c *gin.Context, change func(*whatsapp.Client, string, []string) (*types.GroupInfo, error)) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req ParticipantsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondStatus(c, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	info, err := change(client, c.Param("gid"), req.Participants)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newGroupInfoResponse(info))
+}
+
+// leaveGroup makes the client leave a group
+// @Summary Leave a group
+// @Tags groups
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param gid path string true "Group JID"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/groups/{gid}/leave [post]
+func (h *ClientsHandler) leaveGroup(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if err := client.LeaveGroup(c.Param("gid")); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// JoinGroupRequest represents a request to join a group with an invite link
+type JoinGroupRequest struct {
+	Link string `json:"link" binding:"required"`
+}
+
+// getGroupInvite gets the invite link of a group
+// @Summary Get a group's invite link
+// @Tags groups
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param gid path string true "Group JID"
+// @Success 200 {object} object{link=string}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/groups/{gid}/invite [get]
+func (h *ClientsHandler) getGroupInvite(c *gin.Context) {
+	h.groupInviteLink(c, false)
+}
+
+// resetGroupInvite revokes the invite link of a group and returns the new one
+// @Summary Reset a group's invite link
+// @Description The old link stops working.
+// @Tags groups
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param gid path string true "Group JID"
+// @Success 200 {object} object{link=string}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/groups/{gid}/invite/reset [post]
+func (h *ClientsHandler) resetGroupInvite(c *gin.Context) {
+	h.groupInviteLink(c, true)
+}
+
+// groupInviteLink responds with the invite link of a group, optionally resetting it first
+func (h *ClientsHandler) groupInviteLink(c *gin.Context, reset bool) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	link, err := client.GetGroupInviteLink(c.Param("gid"), reset)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"link": link})
+}
+
+// joinGroup joins a group with an invite link
+// @Summary Join a group with an invite link
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param request body JoinGroupRequest true "Invite link or code"
+// @Success 200 {object} object{success=bool,group_jid=string}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/groups/join [post]
+func (h *ClientsHandler) joinGroup(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req JoinGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondStatus(c, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	groupJID, err := client.JoinGroupWithLink(req.Link)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "group_jid": groupJID})
+}
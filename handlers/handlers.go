@@ -5,40 +5,88 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
+	"go-simple-whatsapp-gateway2/config"
 	"go-simple-whatsapp-gateway2/whatsapp"
 )
 
 // RegisterHandlers registers all the handlers
-func RegisterHandlers(router *gin.Engine, clientManager *whatsapp.ClientManager, apiKey string) {
+func RegisterHandlers(router *gin.Engine, clientManager *whatsapp.ClientManager, cfg *config.Config, build BuildInfo) {
+	// CORS has to run before routing so preflight requests to any API
+	// path are answered
+	router.Use(CORSMiddleware(cfg.CORSAllowedOrigins, cfg.CORSAllowedMethods, cfg.CORSAllowedHeaders))
+
 	// Middleware for API authentication
-	apiAuthMiddleware := APIKeyMiddleware(apiKey)
-	uiAuthMiddleware := UIAuthMiddleware()
+	tokens := NewTokenIssuer(cfg.JWTSecret, time.Duration(cfg.JWTTTLSeconds)*time.Second)
+	// The dashboard needs tokens even without JWT_SECRET; they're then
+	// signed with a secret that only lives as long as the process
+	sessionTokens := tokens
+	if sessionTokens == nil {
+		sessionTokens = newProcessTokenIssuer(uiTokenTTL)
+	}
+	apiAuthMiddleware := APIKeyMiddleware(cfg.APIKey, cfg.ScopedKeys, sessionTokens, clientManager)
+	sessions := NewSessionStore()
+	uiAuthMiddleware := UIAuthMiddleware(sessions)
 
 	// API routes
 	apiGroup := router.Group("/api")
 	apiGroup.Use(apiAuthMiddleware)
 
+	// Bulk sends stream for as long as they take, and imported bundles are
+	// limited by their handler
+	apiGroup.Use(
+		TimeoutMiddleware(time.Duration(cfg.RequestTimeoutSeconds)*time.Second, "/api/clients/:id/send/bulk"),
+		BodyLimitMiddleware(int64(cfg.MaxBodySizeMB)<<20, "/api/clients/import"),
+	)
+
+	// Build information
+	apiGroup.GET("/version", versionHandler(build))
+
+	// Overview of all clients, for the admin key only
+	apiGroup.GET("/summary", summaryHandler(clientManager))
+
+	// Short-lived tokens for downstream services
+	apiGroup.POST("/token", AdminOnlyMiddleware(cfg.APIKey), tokenHandler(tokens))
+
 	// Legacy single-client API
-	whatsAppHandler := NewWhatsAppHandler(clientManager)
+	whatsAppHandler := NewWhatsAppHandler(clientManager, cfg)
 	whatsAppHandler.RegisterRoutes(apiGroup)
 
 	// Multi-client API
-	clientsHandler := NewClientsHandler(clientManager)
+	clientsHandler := NewClientsHandler(clientManager, cfg)
 	clientsHandler.RegisterRoutes(apiGroup)
 
+	// Live client status, authenticated like the API
+	router.GET("/ws/clients", apiAuthMiddleware, ClientStatusSocket(clientManager, cfg.CORSAllowedOrigins))
+
+	// Prometheus metrics, behind the API key unless configured public
+	metricsHandler := gin.WrapH(promhttp.Handler())
+	if cfg.MetricsPublic {
+		router.GET("/metrics", metricsHandler)
+	} else {
+		router.GET("/metrics", apiAuthMiddleware, metricsHandler)
+	}
+
+	// API documentation
+	registerDocs(router)
+
 	// UI routes
 	uiGroup := router.Group("/ui")
 	uiGroup.Use(uiAuthMiddleware)
 
-	uiHandler := NewUIHandler(clientManager)
+	uiHandler := NewUIHandler(clientManager, cfg.APIKey, sessions, NewLoginLimiter(
+		cfg.LoginMaxAttempts,
+		time.Duration(cfg.LoginWindowSeconds)*time.Second,
+		time.Duration(cfg.LoginLockoutSeconds)*time.Second,
+	), sessionTokens)
 	uiHandler.RegisterRoutes(uiGroup)
 
 	// Redirect root to UI
 	router.GET("/", func(c *gin.Context) {
 		// Check if user is authenticated
-		_, err := c.Cookie("api_key")
-		if err != nil {
+		token, err := c.Cookie(sessionCookie)
+		if err != nil || !sessions.Valid(token) {
 			// Not authenticated, redirect to login
 			c.Redirect(http.StatusFound, "/ui/login")
 			return
@@ -46,7 +94,7 @@ func RegisterHandlers(router *gin.Engine, clientManager *whatsapp.ClientManager,
 		// Authenticated, redirect to dashboard
 		c.Redirect(http.StatusFound, "/ui/dashboard")
 	})
-	
+
 	// Add a test route at root level for troubleshooting
 	router.GET("/test", func(c *gin.Context) {
 		c.HTML(http.StatusOK, "test_alt.html", gin.H{
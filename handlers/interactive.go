@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"go-simple-whatsapp-gateway2/whatsapp"
+)
+
+// ButtonsRequest represents a request to send a message with reply buttons
+type ButtonsRequest struct {
+	Recipient string            `json:"recipient" binding:"required"`
+	Body      string            `json:"body" binding:"required"`
+	Buttons   []whatsapp.Button `json:"buttons" binding:"required"`
+}
+
+// ListRequest represents a request to send a list message
+type ListRequest struct {
+	Recipient   string                 `json:"recipient" binding:"required"`
+	Title       string                 `json:"title"`
+	Description string                 `json:"description"`
+	ButtonText  string                 `json:"button_text" binding:"required"`
+	Sections    []whatsapp.ListSection `json:"sections" binding:"required"`
+}
+
+// sendButtons sends a message with quick-reply buttons
+// @Summary Send a buttons message
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param request body ButtonsRequest true "Message to send"
+// @Param dry_run query bool false "Validate the message and return it without sending"
+// @Success 200 {object} SendResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/send/buttons [post]
+func (h *ClientsHandler) sendButtons(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req ButtonsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondStatus(c, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	if isDryRun(c, h.cfg) {
+		preview, err := client.PreviewButtons(req.Recipient, req.Body, req.Buttons)
+		respondDryRun(c, client.ID, req.Recipient, preview, err)
+		return
+	}
+
+	messageID, err := client.SendButtons(req.Recipient, req.Body, req.Buttons)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newSendResult(client.ID, messageID, req.Recipient))
+}
+
+// sendList sends a list message
+// @Summary Send a list message
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param request body ListRequest true "Message to send"
+// @Param dry_run query bool false "Validate the message and return it without sending"
+// @Success 200 {object} SendResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/send/list [post]
+func (h *ClientsHandler) sendList(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req ListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondStatus(c, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	if isDryRun(c, h.cfg) {
+		preview, err := client.PreviewList(req.Recipient, req.Title, req.Description, req.ButtonText, req.Sections)
+		respondDryRun(c, client.ID, req.Recipient, preview, err)
+		return
+	}
+
+	messageID, err := client.SendList(req.Recipient, req.Title, req.Description, req.ButtonText, req.Sections)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newSendResult(client.ID, messageID, req.Recipient))
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go-simple-whatsapp-gateway2/whatsapp"
+)
+
+// tokenHeader is the JOSE header of every token; only HS256 is issued or
+// accepted
+var tokenHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// AllClients in a token's clients grants access to every client
+const AllClients = "*"
+
+// errInvalidToken is returned for tokens that are malformed, forged or expired
+var errInvalidToken = errors.New("invalid token")
+
+// TokenClaims are the claims of an API token
+type TokenClaims struct {
+	Subject string `json:"sub,omitempty"`
+	// Clients are the IDs of the clients the token may access
+	Clients []string `json:"clients"`
+	// Admin tokens stand in for the global API key. Only the dashboard gets
+	// them, for the session it was logged into with that key.
+	Admin     bool  `json:"admin,omitempty"`
+	IssuedAt  int64 `json:"iat"`
+	ExpiresAt int64 `json:"exp"`
+}
+
+// allows reports whether the token grants access to a client
+func (c TokenClaims) allows(clientID string) bool {
+	return slices.Contains(c.Clients, AllClients) || slices.Contains(c.Clients, clientID)
+}
+
+// TokenIssuer issues and verifies short-lived JWTs signed with HS256. They
+// give downstream services access to some clients' routes without handing
+// out the admin key.
+type TokenIssuer struct {
+	secret []byte
+	maxTTL time.Duration
+}
+
+// NewTokenIssuer creates a token issuer, or returns nil if no secret is set,
+// which turns tokens off. maxTTL is both the default and the longest
+// lifetime of a token.
+func NewTokenIssuer(secret string, maxTTL time.Duration) *TokenIssuer {
+	if secret == "" {
+		return nil
+	}
+	return &TokenIssuer{secret: []byte(secret), maxTTL: maxTTL}
+}
+
+// newProcessTokenIssuer creates a token issuer with a random secret, so its
+// tokens stop working when the process exits
+func newProcessTokenIssuer(maxTTL time.Duration) *TokenIssuer {
+	secret := make([]byte, 32)
+	rand.Read(secret) // Never fails since Go 1.24
+	return &TokenIssuer{secret: secret, maxTTL: maxTTL}
+}
+
+// Issue signs a token for the given clients that expires after ttl, or after
+// the maximum lifetime if ttl is 0
+func (t *TokenIssuer) Issue(subject string, clients []string, ttl time.Duration) (string, TokenClaims, error) {
+	if ttl == 0 {
+		ttl = t.maxTTL
+	}
+	if ttl < 0 || ttl > t.maxTTL {
+		return "", TokenClaims{}, fmt.Errorf("ttl must be between 1 and %d seconds", int(t.maxTTL.Seconds()))
+	}
+	if len(clients) == 0 {
+		return "", TokenClaims{}, errors.New("clients is required")
+	}
+	for _, id := range clients {
+		if id == AllClients {
+			continue
+		}
+		if err := whatsapp.ValidateClientID(id); err != nil {
+			return "", TokenClaims{}, err
+		}
+	}
+
+	return t.issue(TokenClaims{Subject: subject, Clients: clients}, ttl)
+}
+
+// IssueAdmin signs an admin token that expires after ttl, capped at the
+// maximum lifetime
+func (t *TokenIssuer) IssueAdmin(subject string, ttl time.Duration) (string, TokenClaims, error) {
+	return t.issue(TokenClaims{Subject: subject, Clients: []string{AllClients}, Admin: true}, min(ttl, t.maxTTL))
+}
+
+// issue stamps claims with their lifetime and signs them
+func (t *TokenIssuer) issue(claims TokenClaims, ttl time.Duration) (string, TokenClaims, error) {
+	now := time.Now()
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = now.Add(ttl).Unix()
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", TokenClaims{}, fmt.Errorf("failed to marshal claims: %w", err)
+	}
+
+	unsigned := tokenHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + t.sign(unsigned), claims, nil
+}
+
+// Verify checks a token's signature and expiry and returns its claims
+func (t *TokenIssuer) Verify(token string) (TokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != tokenHeader {
+		return TokenClaims{}, errInvalidToken
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(t.sign(parts[0]+"."+parts[1]))) {
+		return TokenClaims{}, errInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return TokenClaims{}, errInvalidToken
+	}
+	var claims TokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return TokenClaims{}, errInvalidToken
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return TokenClaims{}, fmt.Errorf("%w: expired", errInvalidToken)
+	}
+	return claims, nil
+}
+
+// sign returns the encoded HMAC-SHA256 of the signing input
+func (t *TokenIssuer) sign(input string) string {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(input))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// bearerToken returns the token from an "Authorization: Bearer" header
+func bearerToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return ""
+}
+
+// TokenRequest represents a request for an API token
+type TokenRequest struct {
+	// Subject names the service the token is for, for the logs
+	Subject string `json:"subject"`
+	// Clients are the client IDs the token may access; "*" allows all
+	Clients []string `json:"clients" binding:"required,min=1"`
+	// TTLSeconds is the token's lifetime, up to JWT_TTL_SECONDS
+	TTLSeconds int `json:"ttl_seconds"`
+}
+
+// TokenResponse is returned by POST /api/token
+type TokenResponse struct {
+	Token     string    `json:"token"`
+	Clients   []string  `json:"clients"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// tokenHandler returns a handler issuing API tokens
+// @Summary Issue an API token
+// @Description Needs the global API key. The token is sent as "Authorization: Bearer <token>" and only works on the routes of the clients it names. Tokens are off unless JWT_SECRET is set.
+// @Tags clients
+// @Accept json
+// @Produce json
+// @Param request body TokenRequest true "Token scope and lifetime"
+// @Success 200 {object} TokenResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 501 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /token [post]
+func tokenHandler(tokens *TokenIssuer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if tokens == nil {
+			respondStatus(c, http.StatusNotImplemented, "Tokens are disabled, set JWT_SECRET to enable them")
+			return
+		}
+
+		var req TokenRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondStatus(c, http.StatusBadRequest, "Invalid request")
+			return
+		}
+
+		token, claims, err := tokens.Issue(req.Subject, req.Clients, time.Duration(req.TTLSeconds)*time.Second)
+		if err != nil {
+			respondInvalid(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, TokenResponse{
+			Token:     token,
+			Clients:   claims.Clients,
+			ExpiresAt: time.Unix(claims.ExpiresAt, 0),
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TimeoutMiddleware gives each request a deadline. Handlers pass the request
+// context down to sends, which give up once it expires; a request that ran
+// out of time without responding gets a 408. Paths in exempt (route paths as
+// registered, e.g. "/api/clients/:id/send/bulk") are left alone. A timeout of
+// 0 disables the middleware.
+func TimeoutMiddleware(timeout time.Duration, exempt ...string) gin.HandlerFunc {
+	skip := make(map[string]bool, len(exempt))
+	for _, path := range exempt {
+		skip[path] = true
+	}
+
+	return func(c *gin.Context) {
+		if timeout <= 0 || skip[c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			c.AbortWithStatusJSON(http.StatusRequestTimeout, ErrorResponse{Error: "Request timed out", Code: CodeTimeout})
+		}
+	}
+}
+
+// BodyLimitMiddleware caps request bodies at limit bytes. Requests that
+// declare a larger body get a 413 straight away; bodies without a length stop
+// being read at the limit. Paths in exempt set their own limits.
+func BodyLimitMiddleware(limit int64, exempt ...string) gin.HandlerFunc {
+	skip := make(map[string]bool, len(exempt))
+	for _, path := range exempt {
+		skip[path] = true
+	}
+
+	return func(c *gin.Context) {
+		if limit <= 0 || skip[c.FullPath()] || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > limit {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, ErrorResponse{Error: "Request body too large", Code: CodeTooLarge})
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"sync"
+	"time"
+)
+
+// LoginLimiter throttles failed UI logins per client IP. Failures are counted
+// over a sliding window; reaching the limit locks the IP out for a while.
+type LoginLimiter struct {
+	mutex       sync.Mutex
+	maxAttempts int
+	window      time.Duration
+	lockout     time.Duration
+	failures    map[string][]time.Time
+	lockedUntil map[string]time.Time
+}
+
+// NewLoginLimiter creates a limiter allowing maxAttempts failed logins per
+// window before locking the IP out for lockout. A maxAttempts of 0 or less
+// disables limiting.
+func NewLoginLimiter(maxAttempts int, window time.Duration, lockout time.Duration) *LoginLimiter {
+	return &LoginLimiter{
+		maxAttempts: maxAttempts,
+		window:      window,
+		lockout:     lockout,
+		failures:    make(map[string][]time.Time),
+		lockedUntil: make(map[string]time.Time),
+	}
+}
+
+// Locked reports whether ip is locked out and for how much longer
+func (l *LoginLimiter) Locked(ip string) (bool, time.Duration) {
+	if l.maxAttempts <= 0 {
+		return false, 0
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	until, ok := l.lockedUntil[ip]
+	if !ok {
+		return false, 0
+	}
+	remaining := time.Until(until)
+	if remaining <= 0 {
+		delete(l.lockedUntil, ip)
+		return false, 0
+	}
+	return true, remaining
+}
+
+// Fail records a failed login from ip, locking it out once it has too many
+// failures within the window
+func (l *LoginLimiter) Fail(ip string) {
+	if l.maxAttempts <= 0 {
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+	l.prune(now)
+
+	attempts := append(l.failures[ip], now)
+	if len(attempts) >= l.maxAttempts {
+		l.lockedUntil[ip] = now.Add(l.lockout)
+		delete(l.failures, ip)
+		return
+	}
+	l.failures[ip] = attempts
+}
+
+// Succeed clears the failures recorded for ip
+func (l *LoginLimiter) Succeed(ip string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	delete(l.failures, ip)
+}
+
+// prune drops failures outside the window and expired lockouts so the maps
+// don't grow forever. Must hold mutex.
+func (l *LoginLimiter) prune(now time.Time) {
+	cutoff := now.Add(-l.window)
+	for ip, attempts := range l.failures {
+		i := 0
+		for i < len(attempts) && !attempts[i].After(cutoff) {
+			i++
+		}
+		if i == len(attempts) {
+			delete(l.failures, ip)
+		} else if i > 0 {
+			l.failures[ip] = attempts[i:]
+		}
+	}
+	for ip, until := range l.lockedUntil {
+		if now.After(until) {
+			delete(l.lockedUntil, ip)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go-simple-whatsapp-gateway2/whatsapp"
+)
+
+const (
+	defaultMessagesLimit = 50
+	maxMessagesLimit     = 500
+)
+
+// getMessages returns a page of a client's received message history.
+// Supports ?chat= to filter by chat JID, ?limit= and ?before= (RFC3339) to
+// page backwards through older messages.
+// @Summary List received messages
+// @Description Messages are newest first. next_before is set while there may be older messages; pass it as before to get the next page.
+// @Tags messages
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param chat query string false "Only messages in this chat JID"
+// @Param limit query int false "Messages per page, 1 to 500" default(50)
+// @Param before query string false "Only messages older than this RFC3339 timestamp"
+// @Success 200 {object} object{messages=[]whatsapp.StoredMessage,next_before=string}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/messages [get]
+func (h *ClientsHandler) getMessages(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	query := whatsapp.HistoryQuery{
+		ChatJID: c.Query("chat"),
+		Limit:   defaultMessagesLimit,
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxMessagesLimit {
+			respondStatus(c, http.StatusBadRequest, "limit must be between 1 and 500")
+			return
+		}
+		query.Limit = limit
+	}
+	if v := c.Query("before"); v != "" {
+		before, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			respondStatus(c, http.StatusBadRequest, "before must be an RFC3339 timestamp")
+			return
+		}
+		query.Before = before
+	}
+
+	messages, err := client.GetMessages(query)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	response := gin.H{"messages": messages}
+	if len(messages) == query.Limit {
+		response["next_before"] = messages[len(messages)-1].Timestamp.Format(time.RFC3339Nano)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// getMessage returns a stored message with a reference to its downloaded
+// media. Supports ?chat= to pick the chat when IDs collide.
+// @Summary Get a received message
+// @Description Returns the message in the shape of the message webhook. Media is never inlined; fetch it from media.media_url.
+// @Tags messages
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param messageid path string true "Message ID"
+// @Param chat query string false "Chat JID, since message IDs are only unique within a chat"
+// @Success 200 {object} whatsapp.MessageWebhookData
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/messages/{messageid} [get]
+func (h *ClientsHandler) getMessage(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	message, err := client.GetMessage(c.Query("chat"), c.Param("messageid"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, message)
+}
+
+// getMedia serves the downloaded attachment of a received message
+// @Summary Download a received message's media
+// @Description Needs DOWNLOAD_MEDIA. Images, audio and video are served inline, anything else as a download.
+// @Tags messages
+// @Produce octet-stream
+// @Param id path string true "Client ID"
+// @Param chatjid path string true "Chat JID of the message"
+// @Param messageid path string true "Message ID"
+// @Success 200 {file} file
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/media/{chatjid}/{messageid} [get]
+func (h *ClientsHandler) getMedia(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	path, info, err := client.GetMedia(c.Param("chatjid"), c.Param("messageid"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	// The type and file come from the sender, so the browser must neither
+	// guess a type nor run anything from the gateway's origin
+	contentType := info.ContentType()
+	params := map[string]string{}
+	if info.FileName != "" {
+		params["filename"] = info.FileName
+	}
+	c.Header("Content-Disposition", mime.FormatMediaType(mediaDisposition(contentType), params))
+	c.Header("Content-Type", contentType)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Security-Policy", "sandbox")
+	c.File(path)
+}
+
+// mediaDisposition returns inline for image, audio and video types the
+// browser can show safely, and attachment for anything else. SVG is an image
+// that can carry scripts, so it's downloaded too.
+func mediaDisposition(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "image/svg+xml" {
+		return "attachment"
+	}
+	for _, prefix := range []string{"image/", "audio/", "video/"} {
+		if strings.HasPrefix(mediaType, prefix) {
+			return "inline"
+		}
+	}
+	return "attachment"
+}
+
+// getReceipt reports whether a sent message was delivered and read
+// @Summary Get a sent message's receipt
+// @Tags messages
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param messageid path string true "Message ID"
+// @Success 200 {object} whatsapp.MessageReceipt
+// @Failure 404 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/receipts/{messageid} [get]
+func (h *ClientsHandler) getReceipt(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	receipt, err := client.GetReceipt(c.Param("messageid"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, receipt)
+}
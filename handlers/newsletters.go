@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NewsletterMessageRequest represents a request to post to a channel
+type NewsletterMessageRequest struct {
+	Message string `json:"message" binding:"required"`
+}
+
+// listNewsletters lists the channels a client follows
+// @Summary List followed channels
+// @Tags messages
+// @Produce json
+// @Param id path string true "Client ID"
+// @Success 200 {object} object{newsletters=[]whatsapp.Newsletter}
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/newsletters [get]
+func (h *ClientsHandler) listNewsletters(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	newsletters, err := client.ListNewsletters()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"newsletters": newsletters})
+}
+
+// sendNewsletter posts a text message to a channel the client runs
+// @Summary Post a message to a channel
+// @Description The client's account must be an owner or admin of the channel.
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param nid path string true "Channel JID, or its ID without @newsletter"
+// @Param request body NewsletterMessageRequest true "Message to post"
+// @Param dry_run query bool false "Validate the message and return it without sending"
+// @Success 200 {object} SendResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/newsletters/{nid}/send [post]
+func (h *ClientsHandler) sendNewsletter(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req NewsletterMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondStatus(c, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	// The server part is optional in the path
+	newsletterJID := c.Param("nid")
+	if !strings.Contains(newsletterJID, "@") {
+		newsletterJID += "@newsletter"
+	}
+
+	if isDryRun(c, h.cfg) {
+		preview, err := client.PreviewNewsletter(newsletterJID, req.Message)
+		respondDryRun(c, client.ID, newsletterJID, preview, err)
+		return
+	}
+
+	messageID, err := client.SendNewsletter(newsletterJID, req.Message)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newSendResult(client.ID, messageID, newsletterJID))
+}
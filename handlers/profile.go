@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProfileRequest represents a request to update the client's profile. Fields
+// left out are not changed.
+type ProfileRequest struct {
+	PushName *string `json:"push_name"`
+	Status   *string `json:"status"`
+}
+
+// getProfile returns the client's push name and status
+// @Summary Get a client's profile
+// @Tags clients
+// @Produce json
+// @Param id path string true "Client ID"
+// @Success 200 {object} whatsapp.Profile
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/profile [get]
+func (h *ClientsHandler) getProfile(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	profile, err := client.GetProfile()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// updateProfile changes the client's push name and/or status
+// @Summary Update a client's profile
+// @Description Changes the push name, the status, or both; fields left out aren't changed.
+// @Tags clients
+// @Accept json
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param request body ProfileRequest true "Profile fields to change"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/profile [put]
+func (h *ClientsHandler) updateProfile(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req ProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondStatus(c, http.StatusBadRequest, "Invalid request")
+		return
+	}
+	if req.PushName == nil && req.Status == nil {
+		respondStatus(c, http.StatusBadRequest, "push_name or status is required")
+		return
+	}
+
+	if req.PushName != nil {
+		if err := client.SetPushName(*req.PushName); err != nil {
+			respondError(c, err)
+			return
+		}
+	}
+	if req.Status != nil {
+		if err := client.SetStatus(*req.Status); err != nil {
+			respondError(c, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
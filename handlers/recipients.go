@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"go-simple-whatsapp-gateway2/config"
+	"go-simple-whatsapp-gateway2/whatsapp"
+)
+
+// maxRecipients caps the recipients of a single send. Larger fan-outs belong
+// on the bulk endpoint, which paces them.
+const maxRecipients = 100
+
+// SendStatusQueued is the status of a message that was only queued
+const SendStatusQueued = "queued"
+
+// RecipientResult is the outcome of a send for one of several recipients
+type RecipientResult struct {
+	Recipient string `json:"recipient"`
+	Success   bool   `json:"success"`
+	Status    string `json:"status,omitempty"`
+	MessageID string `json:"message_id,omitempty"`
+	// JobID is set for queued messages
+	JobID string `json:"job_id,omitempty"`
+	// JID is the resolved recipient in dry-run mode
+	JID   string `json:"jid,omitempty"`
+	Error string `json:"error,omitempty"`
+	Code  string `json:"code,omitempty"`
+}
+
+// MultiSendResult is the response to a send with several recipients. Success
+// is only true when every recipient succeeded.
+type MultiSendResult struct {
+	Success  bool              `json:"success"`
+	ClientID string            `json:"client_id"`
+	Sent     int               `json:"sent"`
+	Failed   int               `json:"failed"`
+	Results  []RecipientResult `json:"results"`
+}
+
+// checkRecipients makes sure a request names either one recipient or a list
+// of them, and applies jidType to every one
+func (r *MessageRequest) checkRecipients(jidType string) error {
+	switch {
+	case r.Recipient == "" && len(r.Recipients) == 0:
+		return errors.New("either recipient or recipients is required")
+	case r.Recipient != "" && len(r.Recipients) > 0:
+		return errors.New("recipient and recipients can't be used together")
+	case len(r.Recipients) > maxRecipients:
+		return fmt.Errorf("at most %d recipients are allowed, use the bulk endpoint for more", maxRecipients)
+	}
+
+	var err error
+	if r.Recipient != "" {
+		r.Recipient, err = whatsapp.ApplyJIDType(r.Recipient, jidType)
+		return err
+	}
+	for i, recipient := range r.Recipients {
+		if strings.TrimSpace(recipient) == "" {
+			return fmt.Errorf("recipient %d is empty", i+1)
+		}
+		if r.Recipients[i], err = whatsapp.ApplyJIDType(recipient, jidType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendToRecipients sends a message to each of the request's recipients in
+// turn and reports the outcome for every one. Dry-run and async sends work
+// like they do for a single recipient.
+func sendToRecipients(c *gin.Context, client *whatsapp.Client, cfg *config.Config, req MessageRequest) {
+	dryRun := isDryRun(c, cfg)
+	async := c.Query("async") == "true"
+
+	response := MultiSendResult{
+		ClientID: client.ID,
+		Results:  make([]RecipientResult, 0, len(req.Recipients)),
+	}
+	for _, recipient := range req.Recipients {
+		result := RecipientResult{Recipient: recipient}
+		var err error
+		switch {
+		case dryRun:
+			var preview whatsapp.MessagePreview
+			if preview, err = client.PreviewMessage(recipient, req.Message, req.sendOptions()); err == nil {
+				result.Status, result.JID = SendStatusDryRun, preview.JID
+			}
+		case async:
+			if result.JobID, err = client.EnqueueMessage(recipient, req.Message, req.sendOptions()); err == nil {
+				result.Status = SendStatusQueued
+			}
+		default:
+			if result.MessageID, err = client.SendMessageContext(c.Request.Context(), recipient, req.Message, req.sendOptions()); err == nil {
+				result.Status = SendStatusSent
+			}
+		}
+
+		if err != nil {
+			_, result.Code, result.Error = errorStatus(err)
+			response.Failed++
+		} else {
+			result.Success = true
+			response.Sent++
+		}
+		response.Results = append(response.Results, result)
+	}
+	response.Success = response.Failed == 0
+
+	status := http.StatusOK
+	if async && !dryRun {
+		status = http.StatusAccepted
+	}
+	c.JSON(status, response)
+}
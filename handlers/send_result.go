@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go-simple-whatsapp-gateway2/config"
+	"go-simple-whatsapp-gateway2/whatsapp"
+)
+
+// Statuses reported by send endpoints
+const (
+	// SendStatusSent is the status of a message handed to WhatsApp
+	SendStatusSent = "sent"
+	// SendStatusDryRun is the status of a message that was validated in
+	// dry-run mode and not sent
+	SendStatusDryRun = "dry_run"
+)
+
+// SendResult is the response to a successful send, shared by every send
+// endpoint so they all return the same shape
+type SendResult struct {
+	Success   bool      `json:"success"`
+	ClientID  string    `json:"client_id"`
+	MessageID string    `json:"message_id"`
+	Recipient string    `json:"recipient"`
+	Timestamp time.Time `json:"sent_at"`
+	Status    string    `json:"status"`
+}
+
+// newSendResult describes a message that was just sent
+func newSendResult(clientID string, messageID string, recipient string) SendResult {
+	return SendResult{
+		Success:   true,
+		ClientID:  clientID,
+		MessageID: messageID,
+		Recipient: recipient,
+		Timestamp: time.Now(),
+		Status:    SendStatusSent,
+	}
+}
+
+// DryRunResult is the response to a send in dry-run mode. It shows the
+// resolved recipient and the message exactly as it would have been sent.
+type DryRunResult struct {
+	Success   bool            `json:"success"`
+	DryRun    bool            `json:"dry_run"`
+	ClientID  string          `json:"client_id"`
+	Recipient string          `json:"recipient"`
+	JID       string          `json:"jid"`
+	Message   json.RawMessage `json:"message" swaggertype:"object"`
+	Status    string          `json:"status"`
+}
+
+// isDryRun reports whether a send should only be validated, either because
+// the request asks for it with ?dry_run=true or because DRY_RUN is set
+func isDryRun(c *gin.Context, cfg *config.Config) bool {
+	return cfg.DryRun || c.Query("dry_run") == "true"
+}
+
+// respondDryRun answers a send in dry-run mode. Any error is a validation
+// error since nothing was sent.
+func respondDryRun(c *gin.Context, clientID string, recipient string, preview whatsapp.MessagePreview, err error) {
+	if err != nil {
+		respondInvalid(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, DryRunResult{
+		Success:   true,
+		DryRun:    true,
+		ClientID:  clientID,
+		Recipient: recipient,
+		JID:       preview.JID,
+		Message:   preview.Message,
+		Status:    SendStatusDryRun,
+	})
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// sessionCookie is the name of the cookie holding the UI session token
+const sessionCookie = "session"
+
+// SessionStore keeps UI login sessions in memory. Sessions don't survive a
+// restart, which just means users have to log in again.
+type SessionStore struct {
+	mutex    sync.Mutex
+	sessions map[string]time.Time
+}
+
+// NewSessionStore creates an empty session store
+func NewSessionStore() *SessionStore {
+	return &SessionStore{
+		sessions: make(map[string]time.Time),
+	}
+}
+
+// Create starts a session lasting ttl and returns its token
+func (s *SessionStore) Create(ttl time.Duration) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate session token: %w", err)
+	}
+	token := hex.EncodeToString(b)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// Drop expired sessions so the map doesn't grow forever
+	now := time.Now()
+	for t, expires := range s.sessions {
+		if now.After(expires) {
+			delete(s.sessions, t)
+		}
+	}
+	s.sessions[token] = now.Add(ttl)
+
+	return token, nil
+}
+
+// Valid reports whether token belongs to a live session
+func (s *SessionStore) Valid(token string) bool {
+	if token == "" {
+		return false
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	expires, ok := s.sessions[token]
+	if !ok {
+		return false
+	}
+	if time.Now().After(expires) {
+		delete(s.sessions, token)
+		return false
+	}
+	return true
+}
+
+// Delete ends a session
+func (s *SessionStore) Delete(token string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.sessions, token)
+}
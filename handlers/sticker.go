@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxStickerUpload caps the size of an uploaded sticker request. The sticker
+// itself is held to WhatsApp's smaller limits when it's sent.
+const maxStickerUpload = 1 << 20
+
+// sendSticker sends a WebP sticker from a multipart upload in the "sticker"
+// field
+// @Summary Send a sticker
+// @Tags messages
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param recipient formData string true "Phone number or JID to send to"
+// @Param sticker formData file true "WebP image, up to 100KB or 500KB when animated"
+// @Param dry_run query bool false "Validate the sticker and return the message without sending"
+// @Success 200 {object} SendResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/send/sticker [post]
+func (h *ClientsHandler) sendSticker(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxStickerUpload)
+	recipient := c.PostForm("recipient")
+	if recipient == "" {
+		respondStatus(c, http.StatusBadRequest, "recipient is required")
+		return
+	}
+	file, _, err := c.Request.FormFile("sticker")
+	if err != nil {
+		respondStatus(c, http.StatusBadRequest, "sticker file is required")
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		respondStatus(c, http.StatusBadRequest, "Failed to read sticker")
+		return
+	}
+
+	if isDryRun(c, h.cfg) {
+		preview, err := client.PreviewSticker(recipient, data)
+		respondDryRun(c, client.ID, recipient, preview, err)
+		return
+	}
+
+	messageID, err := client.SendSticker(recipient, data)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newSendResult(client.ID, messageID, recipient))
+}
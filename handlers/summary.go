@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"go-simple-whatsapp-gateway2/whatsapp"
+)
+
+// summaryHandler returns a handler reporting client counts by status and the
+// gateway's totals. It isn't under /clients/:id, so only the admin key can
+// reach it.
+// @Summary Get a summary of all clients
+// @Tags clients
+// @Produce json
+// @Success 200 {object} whatsapp.Summary
+// @Failure 401 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /summary [get]
+func summaryHandler(clientManager *whatsapp.ClientManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, clientManager.Summary())
+	}
+}
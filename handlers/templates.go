@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"go-simple-whatsapp-gateway2/whatsapp"
+)
+
+// TemplateRequest represents a request to save a message template
+type TemplateRequest struct {
+	Name string `json:"name" binding:"required"`
+	Body string `json:"body" binding:"required"`
+}
+
+// saveTemplate saves a named message template, replacing any existing one
+// @Summary Save a message template
+// @Description Replaces any template with the same name. The body uses text/template syntax, e.g. "Hi {{.name}}", filled from a send request's variables.
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param request body TemplateRequest true "Template to save"
+// @Success 201 {object} whatsapp.MessageTemplate
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/templates [post]
+func (h *ClientsHandler) saveTemplate(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var req TemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondStatus(c, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	tmpl, err := client.SaveTemplate(req.Name, req.Body)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, tmpl)
+}
+
+// listTemplates lists a client's message templates
+// @Summary List message templates
+// @Tags messages
+// @Produce json
+// @Param id path string true "Client ID"
+// @Success 200 {object} object{templates=[]whatsapp.MessageTemplate}
+// @Failure 404 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/templates [get]
+func (h *ClientsHandler) listTemplates(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"templates": client.ListTemplates()})
+}
+
+// deleteTemplate removes a message template
+// @Summary Delete a message template
+// @Tags messages
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param name path string true "Template name"
+// @Success 200 {object} SuccessResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /clients/{id}/templates/{name} [delete]
+func (h *ClientsHandler) deleteTemplate(c *gin.Context) {
+	id := c.Param("id")
+	client, err := h.clientManager.GetClient(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if err := client.DeleteTemplate(c.Param("name")); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// resolveMessage fills in req.Message from the template the request names.
+// A request has to carry either a message or a template, not both.
+func resolveMessage(client *whatsapp.Client, req *MessageRequest) error {
+	switch {
+	case req.Template == "" && req.Message == "":
+		return errors.New("either message or template is required")
+	case req.Template != "" && req.Message != "":
+		return errors.New("message and template can't be used together")
+	case req.Template == "":
+		return nil
+	}
+
+	message, err := client.RenderTemplate(req.Template, req.Variables)
+	if err != nil {
+		return err
+	}
+	req.Message = message
+	return nil
+}
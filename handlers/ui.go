@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
 	"net/http"
-	"os"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -13,12 +15,20 @@ import (
 // UIHandler handles UI endpoints
 type UIHandler struct {
 	clientManager *whatsapp.ClientManager
+	apiKey        string
+	sessions      *SessionStore
+	loginLimiter  *LoginLimiter
+	tokens        *TokenIssuer
 }
 
 // NewUIHandler creates a new UI handler
-func NewUIHandler(clientManager *whatsapp.ClientManager) *UIHandler {
+func NewUIHandler(clientManager *whatsapp.ClientManager, apiKey string, sessions *SessionStore, loginLimiter *LoginLimiter, tokens *TokenIssuer) *UIHandler {
 	return &UIHandler{
 		clientManager: clientManager,
+		apiKey:        apiKey,
+		sessions:      sessions,
+		loginLimiter:  loginLimiter,
+		tokens:        tokens,
 	}
 }
 
@@ -35,6 +45,7 @@ func (h *UIHandler) RegisterRoutes(router *gin.RouterGroup) {
 	router.GET("/login", h.loginPage)
 	router.POST("/login", h.login)
 	router.GET("/logout", h.logout)
+	router.GET("/api-token", h.apiToken)
 }
 
 // redirectToDashboard redirects to the dashboard
@@ -66,12 +77,35 @@ func (h *UIHandler) clients(c *gin.Context) {
 	})
 }
 
+// getClient looks up the client of a UI page. Clients that don't exist send
+// the user back to the client list; any other failure renders an error page,
+// so a transient problem isn't mistaken for a missing client.
+func (h *UIHandler) getClient(c *gin.Context, id string) (*whatsapp.Client, bool) {
+	client, err := h.clientManager.GetClient(id)
+	if err == nil {
+		return client, true
+	}
+
+	if errors.Is(err, whatsapp.ErrClientNotFound) {
+		c.Redirect(http.StatusFound, "/ui/clients")
+		return nil, false
+	}
+
+	status, _, message := errorStatus(err)
+	c.HTML(status, "error.html", gin.H{
+		"Title":    "Error",
+		"Status":   http.StatusText(status),
+		"Message":  message,
+		"RetryURL": c.Request.URL.Path,
+	})
+	return nil, false
+}
+
 // clientDetail renders the client detail page
 func (h *UIHandler) clientDetail(c *gin.Context) {
 	id := c.Param("id")
-	client, err := h.clientManager.GetClient(id)
-	if err != nil {
-		c.Redirect(http.StatusFound, "/ui/clients")
+	client, ok := h.getClient(c, id)
+	if !ok {
 		return
 	}
 
@@ -85,9 +119,8 @@ func (h *UIHandler) clientDetail(c *gin.Context) {
 // qrCode renders the QR code page
 func (h *UIHandler) qrCode(c *gin.Context) {
 	id := c.Param("id")
-	client, err := h.clientManager.GetClient(id)
-	if err != nil {
-		c.Redirect(http.StatusFound, "/ui/clients")
+	client, ok := h.getClient(c, id)
+	if !ok {
 		return
 	}
 
@@ -100,9 +133,8 @@ func (h *UIHandler) qrCode(c *gin.Context) {
 // phonePairing renders the phone pairing page
 func (h *UIHandler) phonePairing(c *gin.Context) {
 	id := c.Param("id")
-	client, err := h.clientManager.GetClient(id)
-	if err != nil {
-		c.Redirect(http.StatusFound, "/ui/clients")
+	client, ok := h.getClient(c, id)
+	if !ok {
 		return
 	}
 
@@ -115,9 +147,8 @@ func (h *UIHandler) phonePairing(c *gin.Context) {
 // sendMessage renders the send message page
 func (h *UIHandler) sendMessage(c *gin.Context) {
 	id := c.Param("id")
-	client, err := h.clientManager.GetClient(id)
-	if err != nil {
-		c.Redirect(http.StatusFound, "/ui/clients")
+	client, ok := h.getClient(c, id)
+	if !ok {
 		return
 	}
 
@@ -145,44 +176,66 @@ func (h *UIHandler) loginPage(c *gin.Context) {
 
 // login processes login requests
 func (h *UIHandler) login(c *gin.Context) {
+	// Refuse IPs that failed too often
+	ip := c.ClientIP()
+	if locked, remaining := h.loginLimiter.Locked(ip); locked {
+		c.HTML(http.StatusTooManyRequests, "login_alt.html", gin.H{
+			"Title": "Login",
+			"Error": fmt.Sprintf("Too many login attempts. Try again in %s.", remaining.Round(time.Second)),
+		})
+		return
+	}
+
 	// Get API key from form
 	apiKey := c.PostForm("api_key")
-	
+
 	// Get remember me
 	remember := c.PostForm("remember") == "1"
-	
-	// Get API key from config for comparison
-	expectedAPIKey := os.Getenv("API_KEY")
-	if expectedAPIKey == "" {
-		expectedAPIKey = "changeme" // Default from .env
-	}
-	
+
 	// Verify API key
-	if apiKey != expectedAPIKey {
+	if subtle.ConstantTimeCompare([]byte(apiKey), []byte(h.apiKey)) != 1 {
+		h.loginLimiter.Fail(ip)
 		c.HTML(http.StatusOK, "login_alt.html", gin.H{
 			"Title": "Login",
 			"Error": "Invalid API Key",
 		})
 		return
 	}
-	
-	// Set cookie
+
+	h.loginLimiter.Succeed(ip)
+
+	// Start a session
 	expiration := 3600 // 1 hour by default
 	if remember {
 		expiration = 3600 * 24 // 24 hours if remember me is checked
 	}
-	
-	c.SetCookie("api_key", apiKey, expiration, "/", "", false, true)
-	
+
+	token, err := h.sessions.Create(time.Duration(expiration) * time.Second)
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "login_alt.html", gin.H{
+			"Title": "Login",
+			"Error": "Failed to start session",
+		})
+		return
+	}
+
+	// The cookie only holds the session token, never the API key
+	c.SetCookie(sessionCookie, token, expiration, "/", "", false, true)
+
 	// Redirect to dashboard
 	c.Redirect(http.StatusFound, "/ui/dashboard")
 }
 
-// logout handles logout by clearing cookies
+// logout ends the session and clears the cookie
 func (h *UIHandler) logout(c *gin.Context) {
+	// Invalidate the session server-side
+	if token, err := c.Cookie(sessionCookie); err == nil {
+		h.sessions.Delete(token)
+	}
+
 	// Clear cookie
-	c.SetCookie("api_key", "", -1, "/", "", false, true)
-	
+	c.SetCookie(sessionCookie, "", -1, "/", "", false, true)
+
 	// Redirect to login page
 	c.Redirect(http.StatusFound, "/ui/login")
-}
\ No newline at end of file
+}
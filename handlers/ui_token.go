@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// uiTokenTTL is the lifetime of the dashboard's API tokens. The dashboard
+// fetches a new one before it runs out, so logging out leaves at most this
+// long for a token taken from the page.
+const uiTokenTTL = 15 * time.Minute
+
+// apiToken issues a short-lived admin token for the dashboard's calls to the
+// API, so the API key never has to reach the browser
+func (h *UIHandler) apiToken(c *gin.Context) {
+	token, claims, err := h.tokens.IssueAdmin("ui", uiTokenTTL)
+	if err != nil {
+		respondStatus(c, http.StatusInternalServerError, "Failed to issue token")
+		return
+	}
+
+	// Tokens are credentials, so don't let anything cache them
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, TokenResponse{
+		Token:     token,
+		Clients:   claims.Clients,
+		ExpiresAt: time.Unix(claims.ExpiresAt, 0),
+	})
+}
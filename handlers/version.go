@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+)
+
+// whatsmeowModule is the module path of the WhatsApp library
+const whatsmeowModule = "go.mau.fi/whatsmeow"
+
+// BuildInfo identifies the running build. It is filled in from variables
+// set with -ldflags -X at build time.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+}
+
+// VersionResponse is returned by GET /api/version
+type VersionResponse struct {
+	BuildInfo
+	WhatsmeowVersion string `json:"whatsmeow_version"`
+	GoVersion        string `json:"go_version"`
+}
+
+// versionHandler returns a handler reporting the build and library versions
+// @Summary Get the gateway's version
+// @Tags clients
+// @Produce json
+// @Success 200 {object} VersionResponse
+// @Failure 401 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /version [get]
+func versionHandler(build BuildInfo) gin.HandlerFunc {
+	response := VersionResponse{
+		BuildInfo:        build,
+		WhatsmeowVersion: whatsmeowVersion(),
+		GoVersion:        runtime.Version(),
+	}
+
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// whatsmeowVersion returns the version of the WhatsApp library the binary
+// was built with
+func whatsmeowVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path == whatsmeowModule {
+				return dep.Version
+			}
+		}
+	}
+	return "unknown"
+}
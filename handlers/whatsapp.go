@@ -1,23 +1,26 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"go-simple-whatsapp-gateway2/config"
 	"go-simple-whatsapp-gateway2/whatsapp"
 )
 
 // WhatsAppHandler handles legacy single-client API endpoints
 type WhatsAppHandler struct {
 	clientManager *whatsapp.ClientManager
+	cfg           *config.Config
 }
 
 // NewWhatsAppHandler creates a new WhatsApp handler
-func NewWhatsAppHandler(clientManager *whatsapp.ClientManager) *WhatsAppHandler {
+func NewWhatsAppHandler(clientManager *whatsapp.ClientManager, cfg *config.Config) *WhatsAppHandler {
 	return &WhatsAppHandler{
 		clientManager: clientManager,
+		cfg:           cfg,
 	}
 }
 
@@ -33,28 +36,101 @@ func (h *WhatsAppHandler) RegisterRoutes(router *gin.RouterGroup) {
 	router.POST("/logout", h.logout)
 }
 
-// getStatus gets the status of the default client
+// noDefaultClientMessage tells callers of legacy routes how to get going on a
+// gateway without a default client
+const noDefaultClientMessage = "No default client set, create a client and set it as default with POST /api/clients/default"
+
+// NoDefaultClientResponse is returned by the legacy routes when no default
+// client is set
+type NoDefaultClientResponse struct {
+	Error string `json:"error" example:"No default client set, create a client and set it as default with POST /api/clients/default"`
+	Code  string `json:"code" example:"no_default_client"`
+	// AvailableClients are the IDs of the existing clients, any of which can
+	// be made the default
+	AvailableClients []string `json:"available_clients"`
+}
+
+// defaultClient returns the default client. If there is none, it answers
+// the request with a NoDefaultClientResponse and returns false.
+func (h *WhatsAppHandler) defaultClient(c *gin.Context) (*whatsapp.Client, bool) {
+	client, err := h.clientManager.GetClient("")
+	if errors.Is(err, whatsapp.ErrNoDefaultClient) {
+		c.JSON(http.StatusConflict, NoDefaultClientResponse{
+			Error:            noDefaultClientMessage,
+			Code:             CodeNoDefaultClient,
+			AvailableClients: h.clientManager.ClientIDs(),
+		})
+		return nil, false
+	}
+	if err != nil {
+		respondError(c, err)
+		return nil, false
+	}
+	return client, true
+}
+
+// StatusResponse is the state of the default client along with the state
+// of the gateway as a whole
+type StatusResponse struct {
+	whatsapp.ClientState
+	Gateway whatsapp.GatewayStatus `json:"gateway"`
+}
+
+// getStatus gets the status of the default client and the gateway. The
+// gateway status is included even without a default client, so a rejected
+// WhatsApp version is always visible here.
+// @Summary Get the default client and gateway status
+// @Description gateway.client_outdated is set once WhatsApp rejects the built-in WhatsApp Web version; the gateway needs a whatsmeow update.
+// @Tags legacy
+// @Produce json
+// @Success 200 {object} StatusResponse
+// @Failure 404 {object} object{error=string,code=string,gateway=whatsapp.GatewayStatus}
+// @Failure 409 {object} object{error=string,code=string,available_clients=[]string,gateway=whatsapp.GatewayStatus}
+// @Security ApiKeyAuth
+// @Router /status [get]
 func (h *WhatsAppHandler) getStatus(c *gin.Context) {
+	gateway := h.clientManager.GatewayStatus()
 	client, err := h.clientManager.GetClient("")
+	if errors.Is(err, whatsapp.ErrNoDefaultClient) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":             noDefaultClientMessage,
+			"code":              CodeNoDefaultClient,
+			"available_clients": h.clientManager.ClientIDs(),
+			"gateway":           gateway,
+		})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		status, code, message := errorStatus(err)
+		c.JSON(status, gin.H{"error": message, "code": code, "gateway": gateway})
 		return
 	}
 
-	c.JSON(http.StatusOK, client.GetState())
+	c.JSON(http.StatusOK, StatusResponse{
+		ClientState: client.GetState(),
+		Gateway:     gateway,
+	})
 }
 
 // generateQR generates a QR code for the default client
+// @Summary Get a login QR code for the default client
+// @Tags legacy
+// @Produce json
+// @Success 200 {object} object{qr_code=string}
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} NoDefaultClientResponse "No default client set, or a client error"
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /qr [get]
 func (h *WhatsAppHandler) generateQR(c *gin.Context) {
-	client, err := h.clientManager.GetClient("")
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	client, ok := h.defaultClient(c)
+	if !ok {
 		return
 	}
 
-	qrCode, err := client.GenerateQR()
+	qrCode, err := client.GenerateQR(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -62,21 +138,32 @@ func (h *WhatsAppHandler) generateQR(c *gin.Context) {
 }
 
 // pairPhone pairs the default client with a phone number (currently not supported)
+// @Summary Pair the default client with a phone number
+// @Description Not supported by the current WhatsApp library; always fails. Use the QR code instead.
+// @Tags legacy
+// @Accept json
+// @Produce json
+// @Param request body PairingRequest true "Phone number to pair with"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} NoDefaultClientResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /pair [post]
 func (h *WhatsAppHandler) pairPhone(c *gin.Context) {
-	client, err := h.clientManager.GetClient("")
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	client, ok := h.defaultClient(c)
+	if !ok {
 		return
 	}
 
 	var req PairingRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		respondStatus(c, http.StatusBadRequest, "Invalid request")
 		return
 	}
 
 	if err := client.PairPhone(req.PhoneNumber); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -84,16 +171,24 @@ func (h *WhatsAppHandler) pairPhone(c *gin.Context) {
 }
 
 // getPairingCode gets the pairing code for the default client (currently not supported)
+// @Summary Get the default client's pairing code
+// @Description Not supported by the current WhatsApp library; always fails. Use the QR code instead.
+// @Tags legacy
+// @Produce json
+// @Success 200 {object} object{code=string}
+// @Failure 409 {object} NoDefaultClientResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /paircode [get]
 func (h *WhatsAppHandler) getPairingCode(c *gin.Context) {
-	client, err := h.clientManager.GetClient("")
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	client, ok := h.defaultClient(c)
+	if !ok {
 		return
 	}
 
 	code, err := client.GetPairingCode()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -101,40 +196,103 @@ func (h *WhatsAppHandler) getPairingCode(c *gin.Context) {
 }
 
 // sendMessage sends a message from the default client
+// @Summary Send a text message from the default client
+// @Description With recipients instead of recipient, the message goes to each of them in turn and the response is a MultiSendResult with one result per recipient.
+// @Tags legacy
+// @Accept json
+// @Produce json
+// @Param request body MessageRequest true "Message to send"
+// @Param async query bool false "Queue the message and return a job ID"
+// @Param dry_run query bool false "Validate the message and return it without sending"
+// @Param jid_type query string false "Treat a bare recipient as a phone number or lid" Enums(auto, phone, lid)
+// @Success 200 {object} SendResult "Sent, or DryRunResult in dry-run mode"
+// @Success 202 {object} object{success=bool,queued=bool,job_id=string}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} NoDefaultClientResponse "No default client set, or a client error"
+// @Failure 408 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /send [post]
 func (h *WhatsAppHandler) sendMessage(c *gin.Context) {
-	client, err := h.clientManager.GetClient("")
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	client, ok := h.defaultClient(c)
+	if !ok {
 		return
 	}
 
 	var req MessageRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		respondStatus(c, http.StatusBadRequest, "Invalid request")
 		return
 	}
 
-	if err := client.SendMessage(req.Recipient, req.Message); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	// ?jid_type=lid marks a bare recipient as a lid rather than a phone number
+	if err := req.checkRecipients(c.Query("jid_type")); err != nil {
+		respondInvalid(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"sent_at": time.Now(),
-	})
+	// Render the message from a template when one is named
+	if err := resolveMessage(client, &req); err != nil {
+		respondInvalid(c, err)
+		return
+	}
+
+	// A list of recipients gets a result for each of them
+	if len(req.Recipients) > 0 {
+		sendToRecipients(c, client, h.cfg, req)
+		return
+	}
+
+	// With ?dry_run=true the message is only validated and shown
+	if isDryRun(c, h.cfg) {
+		preview, err := client.PreviewMessage(req.Recipient, req.Message, req.sendOptions())
+		respondDryRun(c, client.ID, req.Recipient, preview, err)
+		return
+	}
+
+	// With ?async=true the message is only queued and the job ID returned
+	if c.Query("async") == "true" {
+		jobID, err := client.EnqueueMessage(req.Recipient, req.Message, req.sendOptions())
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{
+			"success": true,
+			"queued":  true,
+			"job_id":  jobID,
+		})
+		return
+	}
+
+	messageID, err := client.SendMessageContext(c.Request.Context(), req.Recipient, req.Message, req.sendOptions())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newSendResult(client.ID, messageID, req.Recipient))
 }
 
 // connect connects the default client
+// @Summary Connect the default client
+// @Tags legacy
+// @Produce json
+// @Success 200 {object} SuccessResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} NoDefaultClientResponse "No default client set, or a client error"
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /connect [post]
 func (h *WhatsAppHandler) connect(c *gin.Context) {
-	client, err := h.clientManager.GetClient("")
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	client, ok := h.defaultClient(c)
+	if !ok {
 		return
 	}
 
 	if err := client.Connect(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -142,15 +300,23 @@ func (h *WhatsAppHandler) connect(c *gin.Context) {
 }
 
 // disconnect disconnects the default client
+// @Summary Disconnect the default client
+// @Tags legacy
+// @Produce json
+// @Success 200 {object} SuccessResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} NoDefaultClientResponse "No default client set, or a client error"
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /disconnect [post]
 func (h *WhatsAppHandler) disconnect(c *gin.Context) {
-	client, err := h.clientManager.GetClient("")
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	client, ok := h.defaultClient(c)
+	if !ok {
 		return
 	}
 
 	if err := client.Disconnect(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -158,15 +324,23 @@ func (h *WhatsAppHandler) disconnect(c *gin.Context) {
 }
 
 // logout logs out the default client
+// @Summary Log out the default client
+// @Tags legacy
+// @Produce json
+// @Success 200 {object} SuccessResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} NoDefaultClientResponse "No default client set, or a client error"
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /logout [post]
 func (h *WhatsAppHandler) logout(c *gin.Context) {
-	client, err := h.clientManager.GetClient("")
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	client, ok := h.defaultClient(c)
+	if !ok {
 		return
 	}
 
 	if err := client.Logout(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"go-simple-whatsapp-gateway2/whatsapp"
+)
+
+// wsPingInterval is how often idle WebSocket connections are pinged
+const wsPingInterval = 30 * time.Second
+
+// wsWriteTimeout bounds a single WebSocket write
+const wsWriteTimeout = 10 * time.Second
+
+// wsSnapshot is the first message on a status WebSocket
+type wsSnapshot struct {
+	Type          string                 `json:"type"`
+	Clients       []whatsapp.ClientState `json:"clients"`
+	DefaultClient string                 `json:"default_client"`
+}
+
+// ClientStatusSocket streams client status over a WebSocket: a "snapshot"
+// with every client on connect, then a "status" or "removed" update for each
+// change. Browsers on the CORS allowed origins may connect too.
+func ClientStatusSocket(clientManager *whatsapp.ClientManager, allowedOrigins []string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		allowed[origin] = true
+	}
+	upgrader := websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			if origin == "" || allowed["*"] || allowed[origin] {
+				return true
+			}
+			u, err := url.Parse(origin)
+			return err == nil && u.Host == r.Host
+		},
+	}
+
+	return func(c *gin.Context) {
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			// The upgrader has already responded
+			return
+		}
+		defer conn.Close()
+
+		// Subscribe before taking the snapshot so no change falls in between
+		updates, unsubscribe := clientManager.SubscribeStatus()
+		defer unsubscribe()
+
+		conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		if err := conn.WriteJSON(wsSnapshot{
+			Type:          "snapshot",
+			Clients:       clientManager.ListClients(),
+			DefaultClient: clientManager.GetDefaultClient(),
+		}); err != nil {
+			return
+		}
+
+		// Nothing is expected from the peer, but reading is needed to
+		// notice when it goes away
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		ping := time.NewTicker(wsPingInterval)
+		defer ping.Stop()
+
+		for {
+			select {
+			case <-closed:
+				return
+			case update, ok := <-updates:
+				if !ok {
+					slog.Debug("Status WebSocket fell behind, closing it", "remote", c.ClientIP())
+					return
+				}
+				conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+				if err := conn.WriteJSON(update); err != nil {
+					return
+				}
+			case <-ping.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// logLevel is the minimum level logged, changeable at runtime with SetLevel
+var logLevel slog.LevelVar
+
+// Setup installs the default slog logger with the given level (debug, info,
+// warn, error) and format (text, json). Output goes to stderr. The standard
+// log package is routed through the same handler.
+func Setup(level string, format string) error {
+	if err := SetLevel(level); err != nil {
+		return err
+	}
+
+	opts := &slog.HandlerOptions{Level: &logLevel}
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "", "text":
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	default:
+		return fmt.Errorf("unknown log format: %q", format)
+	}
+
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// SetLevel changes the minimum level logged by the logger installed by Setup
+func SetLevel(name string) error {
+	lvl, err := ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	logLevel.Set(lvl)
+	return nil
+}
+
+// ParseLevel converts a level name into a slog level
+func ParseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("unknown log level: %q", level)
+}
@@ -1,65 +1,171 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"log"
+	"log/slog"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 
 	"go-simple-whatsapp-gateway2/config"
 	"go-simple-whatsapp-gateway2/handlers"
+	"go-simple-whatsapp-gateway2/logger"
 	"go-simple-whatsapp-gateway2/whatsapp"
 )
 
-func main() {
-	// Load .env file if exists
-	_ = godotenv.Load()
+// Build information, set at build time with
+// -ldflags "-X main.version=... -X main.commit=... -X main.buildDate=..."
+var (
+	version   = "dev"
+	commit    = "unknown"
+	buildDate = "unknown"
+)
+
+// shutdownTimeout bounds how long in-flight requests may take to finish on exit
+const shutdownTimeout = 30 * time.Second
 
+// API description for the generated spec. Run `swag init` after changing
+// any handler annotations to regenerate docs/.
+//
+// @title WhatsApp Gateway API
+// @version 1.0
+// @description Multi-client WhatsApp gateway.
+// @BasePath /api
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description The global API key, or a client's own key for that client's endpoints.
+func main() {
 	// Parse command line flags
 	configFile := flag.String("config", "", "Path to config file")
 	flag.Parse()
 
+	// Remember the real environment before .env adds to it
+	externalEnv := environKeys()
+
+	// Load .env file if exists
+	_ = godotenv.Load()
+
 	// Initialize configuration
 	cfg, err := config.Load(*configFile)
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// Setup logging
+	if err := logger.Setup(cfg.LogLevel, cfg.LogFormat); err != nil {
+		log.Fatalf("Failed to setup logging: %v", err)
+	}
+
+	// Catch bad settings now rather than on the first request
+	warnings, err := cfg.Validate()
+	for _, warning := range warnings {
+		slog.Warn("Insecure configuration", "warning", warning)
+	}
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	if cfg.ProxyURL != "" {
+		slog.Info("Routing WhatsApp traffic through proxy", "proxy", cfg.RedactedProxyURL())
+	}
+
 	// Setup client manager
-	clientManager := whatsapp.NewClientManager(cfg.WhatsappDataDir)
-	defer clientManager.Close()
+	clientManager := whatsapp.NewClientManager(cfg.WhatsappDataDir, whatsapp.Options{
+		MessagesPerMinute:   cfg.MessagesPerMinute,
+		SendConcurrency:     cfg.SendConcurrency,
+		MaxClients:          cfg.MaxClients,
+		MessageHistoryLimit: cfg.MessageHistoryLimit,
+		EventLogSize:        cfg.EventLogSize,
+		SendRetries:         cfg.SendRetries,
+		SendRetryBackoff:    time.Duration(cfg.SendRetryBackoffMs) * time.Millisecond,
+		DownloadMedia:       cfg.DownloadMedia,
+		DebugEvents:         cfg.DebugEvents,
+		MediaRetention:      time.Duration(cfg.MediaRetentionHours) * time.Hour,
+		InlineMediaLimit:    cfg.WebhookInlineMediaKB << 10,
+		AutoReconnect:       cfg.AutoReconnect,
+		PingInterval:        time.Duration(cfg.PingIntervalSeconds) * time.Second,
+		StateSaveInterval:   time.Duration(cfg.StateSaveIntervalSeconds) * time.Second,
+		DBDriver:            cfg.DBDriver,
+		DBDSN:               cfg.DBDSN,
+		EncryptionKey:       cfg.EncryptionKey,
+		DeviceName:          cfg.DeviceName,
+		ProxyURL:            cfg.ProxyURL,
+		WebhookURL:          cfg.WebhookURL,
+		WebhookSecret:       cfg.WebhookSecret,
+		DefaultCountryCode:  cfg.DefaultCountryCode,
+	})
 
 	// Load saved clients
 	if err := clientManager.LoadClients(); err != nil {
-		log.Printf("Warning: Failed to load saved clients: %v", err)
+		slog.Warn("Failed to load saved clients", "error", err)
+	}
+
+	// WhatsApp rejects this version once it's too old; the warning comes
+	// from the client manager as soon as a client is rejected
+	gateway := clientManager.GatewayStatus()
+	slog.Info("Using WhatsApp Web version", "version", gateway.WAVersion)
+	if gateway.ClientOutdated {
+		slog.Warn("WhatsApp Web version is outdated, update whatsmeow", "client", gateway.OutdatedClient)
 	}
 
 	// Setup router
 	router := gin.Default()
-	
+
 	// Load templates with relative path
 	router.LoadHTMLGlob("./templates/*")
-	
+
 	// Static files with relative path
 	router.Static("/static", "./static")
 
 	// Setup handlers
-	handlers.RegisterHandlers(router, clientManager, cfg.APIKey)
+	handlers.RegisterHandlers(router, clientManager, cfg, handlers.BuildInfo{
+		Version:   version,
+		Commit:    commit,
+		BuildDate: buildDate,
+	})
 
 	// Add debug logging
-	log.Printf("Config: ListenAddr=%s, API Key=%s, WhatsappDataDir=%s", cfg.ListenAddr, cfg.APIKey, cfg.WhatsappDataDir)
-	log.Printf("Template path: %s", "D:/Dev/go-simple-whatsapp-gateway2/templates/*")
-	log.Printf("Static file path: %s", "D:/Dev/go-simple-whatsapp-gateway2/static")
+	slog.Debug("Config loaded", "listen_addr", cfg.ListenAddr, "data_dir", cfg.WhatsappDataDir)
 
 	// Start server in a goroutine
+	listener, err := listen(cfg)
+	if err != nil {
+		log.Fatalf("Failed to listen: %v", err)
+	}
+	srv := &http.Server{Handler: router}
+	go func() {
+		tls := cfg.TLSCert != ""
+		slog.Info("Starting server", "addr", listener.Addr().String(), "tls", tls)
+
+		var err error
+		if tls {
+			err = srv.ServeTLS(listener, cfg.TLSCert, cfg.TLSKey)
+		} else {
+			err = srv.Serve(listener)
+		}
+		if err != nil && err != http.ErrServerClosed {
+			slog.Error("Failed to start server", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	// Reload settings that can change at runtime on SIGHUP
+	reloader := newConfigReloader(*configFile, cfg, clientManager, externalEnv)
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
 	go func() {
-		log.Printf("Starting server on %s", cfg.ListenAddr)
-		if err := router.Run(cfg.ListenAddr); err != nil {
-			log.Fatalf("Failed to start server: %v", err)
+		for range hup {
+			reloader.reload()
 		}
 	}()
 
@@ -68,12 +174,40 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("Shutting down server...")
-	
+	slog.Info("Shutting down server")
+
+	// Stop accepting requests and let in-flight ones finish first, so they
+	// don't run against clients that are being closed. Closing the server
+	// also removes the unix socket.
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Warn("Timed out waiting for requests to finish", "timeout", shutdownTimeout, "error", err)
+		srv.Close()
+	}
+
 	// Save client states before exit
 	if err := clientManager.SaveClients(); err != nil {
-		log.Printf("Warning: Failed to save clients: %v", err)
+		slog.Warn("Failed to save clients", "error", err)
+	}
+
+	// Close clients and their databases
+	if err := clientManager.Close(); err != nil {
+		slog.Error("Failed to close clients", "error", err)
 	}
 
-	log.Println("Server exited")
+	slog.Info("Server exited")
+}
+
+// listen opens the unix socket when LISTEN_SOCKET is set, or the TCP address
+func listen(cfg *config.Config) (net.Listener, error) {
+	if cfg.ListenSocket == "" {
+		return net.Listen("tcp", cfg.ListenAddr)
+	}
+
+	// A socket left over from an unclean exit would make listening fail
+	if err := os.Remove(cfg.ListenSocket); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+	return net.Listen("unix", cfg.ListenSocket)
 }
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/joho/godotenv"
+
+	"go-simple-whatsapp-gateway2/config"
+	"go-simple-whatsapp-gateway2/logger"
+	"go-simple-whatsapp-gateway2/whatsapp"
+)
+
+// configReloader re-reads the configuration on SIGHUP and applies the
+// settings that can change without a restart: the webhook, the send rate
+// limit, the client limit and the log level
+type configReloader struct {
+	mutex         sync.Mutex
+	configFile    string
+	running       *config.Config
+	clientManager *whatsapp.ClientManager
+	// externalEnv holds the variables set before .env was loaded, which
+	// take precedence over .env like they do on startup
+	externalEnv map[string]bool
+}
+
+// newConfigReloader creates a reloader for the configuration the server
+// started with
+func newConfigReloader(configFile string, running *config.Config, clientManager *whatsapp.ClientManager, externalEnv map[string]bool) *configReloader {
+	return &configReloader{
+		configFile:    configFile,
+		running:       running,
+		clientManager: clientManager,
+		externalEnv:   externalEnv,
+	}
+}
+
+// environKeys returns the names of the variables currently in the environment
+func environKeys() map[string]bool {
+	keys := make(map[string]bool)
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		keys[key] = true
+	}
+	return keys
+}
+
+// reload loads the configuration again and applies it. Nothing is applied
+// if the new configuration is invalid.
+func (r *configReloader) reload() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	slog.Info("Reloading configuration")
+
+	// Pick up edits to .env without overriding the real environment
+	if vars, err := godotenv.Read(); err == nil {
+		for key, value := range vars {
+			if !r.externalEnv[key] {
+				os.Setenv(key, value)
+			}
+		}
+	}
+
+	cfg, err := config.Load(r.configFile)
+	if err != nil {
+		slog.Error("Failed to reload configuration, keeping the current one", "error", err)
+		return
+	}
+	warnings, err := cfg.Validate()
+	if err != nil {
+		slog.Error("Reloaded configuration is invalid, keeping the current one", "error", err)
+		return
+	}
+	for _, warning := range warnings {
+		slog.Warn("Insecure configuration", "warning", warning)
+	}
+	if _, err := logger.ParseLevel(cfg.LogLevel); err != nil {
+		slog.Error("Reloaded configuration is invalid, keeping the current one", "error", err)
+		return
+	}
+
+	// Settings the server was started with can't change while it runs
+	for _, setting := range []struct {
+		name     string
+		old, new string
+	}{
+		{"LISTEN_ADDR", r.running.ListenAddr, cfg.ListenAddr},
+		{"LISTEN_SOCKET", r.running.ListenSocket, cfg.ListenSocket},
+		{"TLS_CERT", r.running.TLSCert, cfg.TLSCert},
+		{"TLS_KEY", r.running.TLSKey, cfg.TLSKey},
+		{"WHATSAPP_DATA_DIR", r.running.WhatsappDataDir, cfg.WhatsappDataDir},
+		{"DB_DRIVER", r.running.DBDriver, cfg.DBDriver},
+		{"DB_DSN", r.running.DBDSN, cfg.DBDSN},
+		{"ENCRYPTION_KEY", r.running.EncryptionKey, cfg.EncryptionKey},
+		{"LOG_FORMAT", r.running.LogFormat, cfg.LogFormat},
+		{"DEVICE_NAME", r.running.DeviceName, cfg.DeviceName},
+		{"PROXY_URL", r.running.ProxyURL, cfg.ProxyURL},
+		{"JWT_SECRET", r.running.JWTSecret, cfg.JWTSecret},
+		{"API_KEYS_FILE", r.running.APIKeysFile, cfg.APIKeysFile},
+	} {
+		if setting.old != setting.new {
+			slog.Warn("Setting changed but needs a restart to take effect", "setting", setting.name)
+		}
+	}
+
+	logger.SetLevel(cfg.LogLevel)
+	r.clientManager.Reconfigure(whatsapp.ReloadableOptions{
+		MessagesPerMinute: cfg.MessagesPerMinute,
+		MaxClients:        cfg.MaxClients,
+		WebhookURL:        cfg.WebhookURL,
+		WebhookSecret:     cfg.WebhookSecret,
+	})
+
+	slog.Info("Configuration reloaded",
+		"log_level", cfg.LogLevel,
+		"messages_per_minute", cfg.MessagesPerMinute,
+		"max_clients", cfg.MaxClients,
+		"webhook", cfg.WebhookURL != "",
+	)
+}
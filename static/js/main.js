@@ -1,32 +1,46 @@
 // Main JavaScript file for the WhatsApp Gateway UI
 
-// Store API key in localStorage after the user enters it once
-function storeApiKey(apiKey) {
-    if (apiKey) {
-        localStorage.setItem('api_key', apiKey);
-    }
-}
+// The dashboard calls the API with short-lived tokens issued for its
+// session, so the API key never has to be kept in the browser
+let apiTokenRequest = null;
+let apiTokenExpiry = 0;
 
-// Get API key from localStorage or prompt the user
-function getApiKey() {
-    let apiKey = localStorage.getItem('api_key');
-    
-    if (!apiKey) {
-        // Use default API key from .env file first
-        apiKey = 'changeme'; // default from .env
-        storeApiKey(apiKey);
-        console.log('Using default API key: changeme');
+// Get a token for API calls, fetching a new one a minute before the current
+// one expires
+function getApiToken() {
+    if (!apiTokenRequest || Date.now() > apiTokenExpiry - 60000) {
+        apiTokenRequest = $.ajax({
+            url: '/ui/api-token',
+            method: 'GET',
+            dataType: 'json'
+        }).then(function(response) {
+            apiTokenExpiry = new Date(response.expires_at).getTime();
+            return response.token;
+        }, function(xhr) {
+            apiTokenRequest = null;
+            if (xhr.status === 401) {
+                // The session is over
+                window.location.href = '/ui/login';
+            }
+            return $.Deferred().reject(xhr);
+        });
     }
-    
-    return apiKey;
+    return apiTokenRequest;
 }
 
-// Function to reset the API key (for troubleshooting)
-function resetApiKey() {
-    localStorage.removeItem('api_key');
-    const newKey = prompt('Please enter your API key:', 'changeme');
-    storeApiKey(newKey);
-    return newKey;
+// Call the API with the session's token. Takes the same options as $.ajax.
+function apiRequest(options) {
+    return getApiToken().then(function(token) {
+        options.headers = Object.assign({}, options.headers, {
+            'Authorization': 'Bearer ' + token
+        });
+        return $.ajax(options);
+    }, function(xhr) {
+        if (options.error) {
+            options.error(xhr);
+        }
+        return $.Deferred().reject(xhr);
+    });
 }
 
 // Helper function to format date/time
@@ -42,9 +56,9 @@ function handleAjaxError(xhr, element) {
     if (xhr.responseJSON && xhr.responseJSON.error) {
         errorMessage = xhr.responseJSON.error;
     } else if (xhr.status === 401) {
-        errorMessage = 'Unauthorized: Invalid API key';
-        // Clear stored API key if it's invalid
-        localStorage.removeItem('api_key');
+        errorMessage = 'Unauthorized: session expired';
+        // Fetch a new token next time
+        apiTokenRequest = null;
     } else if (xhr.status === 404) {
         errorMessage = 'Resource not found';
     } else if (xhr.status === 500) {
@@ -91,12 +105,9 @@ $(document).ready(function() {
     }
 
     // Add test API request to verify API connectivity
-    $.ajax({
+    apiRequest({
         url: '/api/clients',
         method: 'GET',
-        headers: {
-            'X-API-Key': getApiKey()
-        },
         success: function(response) {
             $('#js-debug').append(' | API OK');
             console.log('API test successful:', response);
@@ -0,0 +1,58 @@
+package whatsapp
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+)
+
+// GenerateAPIKey creates a random API key
+func GenerateAPIKey() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// APIKey returns the client's own API key
+func (c *Client) APIKey() string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.apiKey
+}
+
+// CheckAPIKey reports whether key is this client's API key
+func (c *Client) CheckAPIKey(key string) bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	if c.apiKey == "" || key == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(c.apiKey), []byte(key)) == 1
+}
+
+// RotateAPIKey replaces the client's API key with a new one and saves it
+func (c *Client) RotateAPIKey() (string, error) {
+	key, err := GenerateAPIKey()
+	if err != nil {
+		return "", err
+	}
+
+	c.mutex.Lock()
+	previous := c.apiKey
+	c.apiKey = key
+	c.mutex.Unlock()
+
+	if err := c.SaveState(); err != nil {
+		c.mutex.Lock()
+		c.apiKey = previous
+		c.mutex.Unlock()
+		return "", err
+	}
+
+	return key, nil
+}
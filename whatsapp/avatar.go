@@ -0,0 +1,206 @@
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// maxAvatarDownload caps the size of a proxied profile picture
+const maxAvatarDownload = 5 << 20
+
+// avatarHTTPClient downloads profile pictures from WhatsApp's CDN
+var avatarHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// ErrNoProfilePicture is returned when a contact has no profile picture or
+// hides it from the client
+var ErrNoProfilePicture = errors.New("no profile picture available")
+
+// ProfilePicture points at a contact's or group's current profile picture
+type ProfilePicture struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+// GetProfilePicture returns the URL and ID of a contact's or group's
+// profile picture
+func (c *Client) GetProfilePicture(jidStr string) (ProfilePicture, error) {
+	jid, err := c.parseRecipient(jidStr)
+	if err != nil {
+		return ProfilePicture{}, err
+	}
+
+	c.mutex.Lock()
+	c.lastActivity = time.Now()
+	c.mutex.Unlock()
+
+	if !c.client.IsLoggedIn() {
+		return ProfilePicture{}, ErrNotLoggedIn
+	}
+
+	info, err := c.client.GetProfilePictureInfo(jid, &whatsmeow.GetProfilePictureParams{})
+	switch {
+	case errors.Is(err, whatsmeow.ErrProfilePictureNotSet):
+		return ProfilePicture{}, fmt.Errorf("%w: %s has no profile picture", ErrNoProfilePicture, jid)
+	case errors.Is(err, whatsmeow.ErrProfilePictureUnauthorized):
+		return ProfilePicture{}, fmt.Errorf("%w: %s hides their profile picture", ErrNoProfilePicture, jid)
+	case err != nil:
+		return ProfilePicture{}, fmt.Errorf("failed to get profile picture: %w", err)
+	case info == nil:
+		return ProfilePicture{}, fmt.Errorf("%w: %s has no profile picture", ErrNoProfilePicture, jid)
+	}
+
+	return ProfilePicture{ID: info.ID, URL: info.URL}, nil
+}
+
+// DownloadProfilePicture fetches the image a ProfilePicture points at
+func DownloadProfilePicture(ctx context.Context, picture ProfilePicture) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, picture.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create profile picture request: %w", err)
+	}
+
+	resp, err := avatarHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download profile picture: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download profile picture: status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAvatarDownload+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile picture: %w", err)
+	}
+	if len(data) > maxAvatarDownload {
+		return nil, errors.New("profile picture is too large")
+	}
+
+	return data, nil
+}
+
+const (
+	// minAvatarSize is the smallest profile picture side accepted
+	minAvatarSize = 96
+	// maxAvatarSize is the side profile pictures are scaled down to
+	maxAvatarSize = 640
+	// avatarJPEGQuality is used when a picture has to be re-encoded
+	avatarJPEGQuality = 90
+)
+
+// SetProfilePicture changes the client's own profile picture and returns the
+// new picture ID. JPEG and PNG are accepted; pictures are cropped to a square
+// and scaled down to at most 640x640, re-encoding as JPEG when needed.
+func (c *Client) SetProfilePicture(data []byte) (string, error) {
+	avatar, err := prepareAvatar(data)
+	if err != nil {
+		return "", err
+	}
+
+	c.mutex.Lock()
+	c.lastActivity = time.Now()
+	c.mutex.Unlock()
+
+	if !c.client.IsLoggedIn() {
+		return "", ErrNotLoggedIn
+	}
+
+	// An empty target sets the picture of the client's own account
+	pictureID, err := c.client.SetGroupPhoto(types.EmptyJID, avatar)
+	if err != nil {
+		return "", fmt.Errorf("failed to set profile picture: %w", err)
+	}
+
+	return pictureID, nil
+}
+
+// prepareAvatar checks an uploaded picture and turns it into a square JPEG
+// WhatsApp accepts. Square JPEGs that are small enough are used as is.
+func prepareAvatar(data []byte) ([]byte, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: not a JPEG or PNG image", ErrInvalidMessage)
+	}
+	if format != "jpeg" && format != "png" {
+		return nil, fmt.Errorf("%w: %s images are not supported, use JPEG or PNG", ErrInvalidMessage, format)
+	}
+	if config.Width < minAvatarSize || config.Height < minAvatarSize {
+		return nil, fmt.Errorf("%w: picture must be at least %dx%d", ErrInvalidMessage, minAvatarSize, minAvatarSize)
+	}
+	if format == "jpeg" && config.Width == config.Height && config.Width <= maxAvatarSize {
+		return data, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to decode picture: %v", ErrInvalidMessage, err)
+	}
+
+	// Crop the centre square, then scale it down if it's too big
+	bounds := img.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	crop := image.Rect(0, 0, side, side).Add(image.Pt(
+		bounds.Min.X+(bounds.Dx()-side)/2,
+		bounds.Min.Y+(bounds.Dy()-side)/2,
+	))
+	out := scaleSquare(img, crop, min(side, maxAvatarSize))
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, out, &jpeg.Options{Quality: avatarJPEGQuality}); err != nil {
+		return nil, fmt.Errorf("failed to encode picture: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// scaleSquare scales the square src region of img to size x size, averaging
+// the source pixels that fall into each destination pixel. The result is
+// opaque since JPEG has no alpha channel.
+func scaleSquare(img image.Image, src image.Rectangle, size int) *image.RGBA {
+	out := image.NewRGBA(image.Rect(0, 0, size, size))
+	scale := float64(src.Dx()) / float64(size)
+
+	for y := 0; y < size; y++ {
+		y0 := src.Min.Y + int(float64(y)*scale)
+		y1 := max(src.Min.Y+int(float64(y+1)*scale), y0+1)
+		for x := 0; x < size; x++ {
+			x0 := src.Min.X + int(float64(x)*scale)
+			x1 := max(src.Min.X+int(float64(x+1)*scale), x0+1)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := img.At(sx, sy).RGBA()
+					r += uint64(pr)
+					g += uint64(pg)
+					b += uint64(pb)
+					a += uint64(pa)
+					n++
+				}
+			}
+			// Colours are premultiplied, so adding the missing alpha puts
+			// transparent areas on a white background
+			bg := 0xffff - a/n
+			out.Set(x, y, color.RGBA64{
+				R: uint16(r/n + bg),
+				G: uint16(g/n + bg),
+				B: uint16(b/n + bg),
+				A: 0xffff,
+			})
+		}
+	}
+
+	return out
+}
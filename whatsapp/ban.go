@@ -0,0 +1,118 @@
+package whatsapp
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// BanStatus reports whether WhatsApp has temporarily banned the account and
+// why its last connection attempt was refused. Both are cleared once the
+// client connects again.
+type BanStatus struct {
+	ClientID string `json:"client_id"`
+	// Banned is true while a temporary ban is in force
+	Banned bool `json:"banned"`
+	// Code is WhatsApp's reason code for the latest ban, e.g. 101 for
+	// messaging too many people who don't have the number saved
+	Code     int        `json:"code,omitempty"`
+	Reason   string     `json:"reason,omitempty"`
+	BannedAt *time.Time `json:"banned_at,omitempty"`
+	// ExpiresAt is unset when WhatsApp didn't say how long the ban lasts
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// The last connection attempt WhatsApp refused, for failures other than
+	// bans and logouts
+	LastConnectFailureCode int        `json:"last_connect_failure_code,omitempty"`
+	LastConnectFailure     string     `json:"last_connect_failure,omitempty"`
+	LastConnectFailureAt   *time.Time `json:"last_connect_failure_at,omitempty"`
+}
+
+// temporaryBan is a ban WhatsApp reported when the client connected
+type temporaryBan struct {
+	code    events.TempBanReason
+	at      time.Time
+	expires time.Time
+}
+
+// activeAt reports whether the ban is still in force. A ban without an
+// expiry holds until the client connects again.
+func (b *temporaryBan) activeAt(now time.Time) bool {
+	return b != nil && (b.expires.IsZero() || now.Before(b.expires))
+}
+
+// connectFailure is a refused connection attempt
+type connectFailure struct {
+	reason  events.ConnectFailureReason
+	message string
+	at      time.Time
+}
+
+// recordTemporaryBanLocked keeps a ban and holds off reconnecting until it
+// expires, since connecting during a ban only prolongs it. Must hold c.mutex.
+func (c *Client) recordTemporaryBanLocked(evt *events.TemporaryBan) {
+	now := time.Now()
+	ban := &temporaryBan{code: evt.Code, at: now}
+	if evt.Expire > 0 {
+		ban.expires = now.Add(evt.Expire)
+	}
+	c.tempBan = ban
+	c.status = StatusDisconnected
+	c.connectedSince = time.Time{}
+	c.connError = evt.String()
+	c.notifyConnection(StatusDisconnected)
+	slog.Warn("Client was temporarily banned by WhatsApp", "client", c.ID, "code", int(evt.Code), "expires_in", evt.Expire)
+
+	// Restart the supervisor so it waits for the ban to run out
+	c.stopReconnectLocked()
+	if c.client.Store.ID != nil {
+		c.startReconnectLocked()
+	}
+}
+
+// reconnectNotBeforeLocked returns when reconnecting may start: zero for
+// right away, or the end of a ban. ok is false while banned with no known
+// end. Must hold c.mutex.
+func (c *Client) reconnectNotBeforeLocked() (notBefore time.Time, ok bool) {
+	if !c.tempBan.activeAt(time.Now()) {
+		return time.Time{}, true
+	}
+	return c.tempBan.expires, !c.tempBan.expires.IsZero()
+}
+
+// BanStatus returns the client's temporary ban and last refused connection
+func (c *Client) BanStatus() BanStatus {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	status := BanStatus{ClientID: c.ID}
+	if ban := c.tempBan; ban != nil {
+		status.Banned = ban.activeAt(time.Now())
+		status.Code = int(ban.code)
+		status.Reason = withoutCode(ban.code.String(), status.Code)
+		at := ban.at
+		status.BannedAt = &at
+		if !ban.expires.IsZero() {
+			expires := ban.expires
+			status.ExpiresAt = &expires
+		}
+	}
+	if failure := c.connFailure; failure != nil {
+		status.LastConnectFailureCode = int(failure.reason)
+		status.LastConnectFailure = withoutCode(failure.reason.String(), status.LastConnectFailureCode)
+		if failure.message != "" {
+			status.LastConnectFailure += ": " + failure.message
+		}
+		at := failure.at
+		status.LastConnectFailureAt = &at
+	}
+	return status
+}
+
+// withoutCode drops the "code: " prefix whatsmeow puts on its descriptions,
+// since the code is reported on its own
+func withoutCode(description string, code int) string {
+	return strings.TrimPrefix(description, strconv.Itoa(code)+": ")
+}
@@ -0,0 +1,130 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+)
+
+// maxBatchMessages bounds the number of messages in one batch
+const maxBatchMessages = 50
+
+// Message types that can be part of a batch
+const (
+	BatchTypeText    = "text"
+	BatchTypeButtons = "buttons"
+	BatchTypeList    = "list"
+)
+
+// Outcomes of a batch item
+const (
+	BatchStatusSent    = "sent"
+	BatchStatusFailed  = "failed"
+	BatchStatusSkipped = "skipped"
+	BatchStatusDryRun  = "dry_run"
+)
+
+// BatchMessage is one message of a batch. Type selects which of the other
+// fields apply, matching the request of the single send endpoint.
+type BatchMessage struct {
+	Type string `json:"type"`
+
+	// text
+	Message string `json:"message,omitempty"`
+	SendOptions
+
+	// buttons
+	Body    string   `json:"body,omitempty"`
+	Buttons []Button `json:"buttons,omitempty"`
+
+	// list
+	Title       string        `json:"title,omitempty"`
+	Description string        `json:"description,omitempty"`
+	ButtonText  string        `json:"button_text,omitempty"`
+	Sections    []ListSection `json:"sections,omitempty"`
+}
+
+// BatchResult holds the outcome of one message of a batch
+type BatchResult struct {
+	Index     int    `json:"index"`
+	Type      string `json:"type"`
+	Status    string `json:"status"`
+	MessageID string `json:"message_id,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// BatchOptions controls how a batch is sent
+type BatchOptions struct {
+	// ContinueOnError keeps sending after a message fails instead of
+	// skipping the rest
+	ContinueOnError bool
+	// DryRun only validates the batch; nothing is sent
+	DryRun bool
+}
+
+// SendBatch sends several messages to one recipient, one after another in
+// the given order. Every message is validated before the first is sent, so
+// a malformed batch is rejected as a whole and any error returned is a
+// validation error. After that, the first failure skips the remaining
+// messages unless opts.ContinueOnError is set.
+func (c *Client) SendBatch(ctx context.Context, recipient string, messages []BatchMessage, opts BatchOptions) ([]BatchResult, error) {
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("%w: batch is empty", ErrInvalidMessage)
+	}
+	if len(messages) > maxBatchMessages {
+		return nil, fmt.Errorf("%w: a batch holds at most %d messages", ErrInvalidMessage, maxBatchMessages)
+	}
+	jid, err := c.parseRecipient(recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := make([]*waProto.Message, len(messages))
+	for i, msg := range messages {
+		var content *waProto.Message
+		switch msg.Type {
+		case BatchTypeText:
+			if strings.TrimSpace(msg.Message) == "" {
+				err = fmt.Errorf("%w: message cannot be empty", ErrInvalidMessage)
+			} else {
+				content, err = c.buildTextMessage(jid, msg.Message, msg.SendOptions)
+			}
+		case BatchTypeButtons:
+			content, err = buildButtonsMessage(msg.Body, msg.Buttons)
+		case BatchTypeList:
+			content, err = buildListMessage(msg.Title, msg.Description, msg.ButtonText, msg.Sections)
+		default:
+			err = fmt.Errorf("%w: unknown type %q", ErrInvalidMessage, msg.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("messages[%d]: %w", i, err)
+		}
+		contents[i] = content
+	}
+
+	results := make([]BatchResult, len(messages))
+	failed := false
+	for i, msg := range messages {
+		results[i] = BatchResult{Index: i, Type: msg.Type}
+		switch {
+		case opts.DryRun:
+			results[i].Status = BatchStatusDryRun
+		case failed && !opts.ContinueOnError:
+			results[i].Status = BatchStatusSkipped
+		default:
+			messageID, err := c.sendAndWait(ctx, &queuedMessage{recipient: recipient, content: contents[i]})
+			if err != nil {
+				failed = true
+				results[i].Status = BatchStatusFailed
+				results[i].Error = err.Error()
+			} else {
+				results[i].Status = BatchStatusSent
+				results[i].MessageID = messageID
+			}
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,168 @@
+package whatsapp
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BulkOptions controls how a bulk send is dispatched
+type BulkOptions struct {
+	// Concurrency is the number of sends allowed in flight at once
+	Concurrency int
+	// Delay is the minimum time between two sends from the same client
+	Delay time.Duration
+	// Ordered makes results be reported in input order instead of completion order
+	Ordered bool
+	// DryRun only validates each recipient; nothing is sent and no delay applies
+	DryRun bool
+}
+
+// BulkResult holds the outcome of a single recipient in a bulk send
+type BulkResult struct {
+	Index     int       `json:"index"`
+	Recipient string    `json:"recipient"`
+	Success   bool      `json:"success"`
+	MessageID string    `json:"message_id,omitempty"`
+	JID       string    `json:"jid,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	SentAt    time.Time `json:"sent_at"`
+}
+
+// BulkSummary summarizes a finished bulk send
+type BulkSummary struct {
+	Total     int  `json:"total"`
+	Sent      int  `json:"sent"`
+	Failed    int  `json:"failed"`
+	Cancelled bool `json:"cancelled"`
+}
+
+// SendBulk sends the same message to several recipients. onResult, if set, is
+// called once per recipient as results become available (in input order when
+// opts.Ordered is set). Sending stops early when ctx is cancelled, which also
+// cancels sends still queued or under way; recipients that were never
+// attempted are not reported. The returned slice is always
+// sorted by input index.
+func (c *Client) SendBulk(ctx context.Context, recipients []string, message string, opts BulkOptions, onResult func(BulkResult)) ([]BulkResult, BulkSummary) {
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	jobs := make(chan int)
+	results := make(chan BulkResult)
+
+	// Workers
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				result := BulkResult{Index: idx, Recipient: recipients[idx]}
+				if opts.DryRun {
+					if preview, err := c.PreviewMessage(recipients[idx], message, SendOptions{}); err != nil {
+						result.Error = err.Error()
+					} else {
+						result.Success = true
+						result.JID = preview.JID
+					}
+				} else if err := c.throttle(ctx, opts.Delay); err != nil {
+					result.Error = err.Error()
+				} else if messageID, err := c.SendMessageContext(ctx, recipients[idx], message, SendOptions{}); err != nil {
+					result.Error = err.Error()
+				} else {
+					result.Success = true
+					result.MessageID = messageID
+				}
+				result.SentAt = time.Now()
+				results <- result
+			}
+		}()
+	}
+
+	// Feed jobs until done or cancelled
+	go func() {
+		defer close(jobs)
+		for i := range recipients {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Collect results, buffering out-of-order ones when ordering is requested
+	collected := make([]*BulkResult, len(recipients))
+	next := 0
+	summary := BulkSummary{Total: len(recipients)}
+	for result := range results {
+		r := result
+		collected[r.Index] = &r
+		if r.Success {
+			summary.Sent++
+		} else {
+			summary.Failed++
+		}
+
+		if onResult == nil {
+			continue
+		}
+		if !opts.Ordered {
+			onResult(r)
+			continue
+		}
+		for next < len(collected) && collected[next] != nil {
+			onResult(*collected[next])
+			next++
+		}
+	}
+
+	ordered := make([]BulkResult, 0, len(recipients))
+	for _, r := range collected {
+		if r != nil {
+			ordered = append(ordered, *r)
+		}
+	}
+	summary.Cancelled = len(ordered) < len(recipients)
+
+	return ordered, summary
+}
+
+// throttle blocks until the client may send again, spacing sends by at least
+// delay. The slot is reserved before waiting so concurrent callers queue up
+// behind each other instead of all firing at once.
+func (c *Client) throttle(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	c.throttleMutex.Lock()
+	now := time.Now()
+	slot := c.nextSendAt
+	if slot.Before(now) {
+		slot = now
+	}
+	c.nextSendAt = slot.Add(delay)
+	c.throttleMutex.Unlock()
+
+	wait := time.Until(slot)
+	if wait <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
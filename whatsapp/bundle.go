@@ -0,0 +1,352 @@
+package whatsapp
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// bundleMagic starts every session bundle and versions its format
+const bundleMagic = "WAGWBUNDLE1"
+
+// Sizes of the key derivation salt and the AES-GCM nonce in a bundle
+const (
+	bundleSaltSize  = 16
+	bundleNonceSize = 12
+)
+
+// bundleKDFIterations is the PBKDF2-SHA256 work factor for bundle keys
+const bundleKDFIterations = 600000
+
+// maxBundleFileSize bounds any single file unpacked from a bundle
+const maxBundleFileSize = 512 << 20
+
+// Files inside a session bundle
+const (
+	bundleManifestFile = "manifest.json"
+	bundleStateFile    = "state.json"
+	bundleStoreFile    = "whatsapp.db"
+)
+
+// sqliteHeader starts every SQLite database file
+const sqliteHeader = "SQLite format 3\x00"
+
+// ErrInvalidBundle is returned for session bundles that can't be decrypted or
+// don't hold a valid session
+var ErrInvalidBundle = errors.New("invalid session bundle")
+
+// ErrClientExists is returned when importing over an existing client without force
+var ErrClientExists = fmt.Errorf("client %w", ErrAlreadyExists)
+
+// ErrExportUnsupported is returned when the store can't be exported
+var ErrExportUnsupported = errors.New("session export is only supported with the sqlite3 driver")
+
+// bundleManifest describes the contents of a session bundle
+type bundleManifest struct {
+	ClientID   string    `json:"client_id"`
+	ExportedAt time.Time `json:"exported_at"`
+}
+
+// ExportClient packs a client's session store and state into a bundle
+// encrypted with passphrase. The client keeps running; the store is copied
+// from a consistent snapshot.
+func (cm *ClientManager) ExportClient(id string, passphrase string) ([]byte, error) {
+	if driver := cm.options().DBDriver; driver != "" && driver != DriverSQLite {
+		return nil, ErrExportUnsupported
+	}
+
+	client, err := cm.GetClient(id)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := client.snapshotStore()
+	if err != nil {
+		return nil, err
+	}
+
+	client.mutex.RLock()
+	state, err := client.marshalStateLocked()
+	client.mutex.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := json.Marshal(bundleManifest{ClientID: client.ID, ExportedAt: time.Now()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	archive, err := packBundle(map[string][]byte{
+		bundleManifestFile: manifest,
+		bundleStateFile:    state,
+		bundleStoreFile:    store,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sealBundle(archive, passphrase)
+}
+
+// ImportClient restores a client from a bundle made by ExportClient. An
+// existing client with the same ID is only replaced when force is set. The
+// imported client connects right away if it was connected when exported.
+func (cm *ClientManager) ImportClient(bundle []byte, passphrase string, force bool) (*Client, error) {
+	if driver := cm.options().DBDriver; driver != "" && driver != DriverSQLite {
+		return nil, ErrExportUnsupported
+	}
+
+	archive, err := openBundle(bundle, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	files, err := unpackBundle(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate everything before touching the data directory
+	var manifest bundleManifest
+	if err := json.Unmarshal(files[bundleManifestFile], &manifest); err != nil {
+		return nil, fmt.Errorf("%w: bad manifest: %v", ErrInvalidBundle, err)
+	}
+	if err := ValidateClientID(manifest.ClientID); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+	var state ClientState
+	if err := json.Unmarshal(files[bundleStateFile], &state); err != nil {
+		return nil, fmt.Errorf("%w: bad state: %v", ErrInvalidBundle, err)
+	}
+	if !bytes.HasPrefix(files[bundleStoreFile], []byte(sqliteHeader)) {
+		return nil, fmt.Errorf("%w: store is not an sqlite database", ErrInvalidBundle)
+	}
+
+	id := manifest.ClientID
+	if cm.hasClient(id) {
+		if !force {
+			return nil, fmt.Errorf("%w: %s", ErrClientExists, id)
+		}
+		if err := cm.DeleteClient(id); err != nil {
+			return nil, fmt.Errorf("failed to replace existing client: %w", err)
+		}
+	} else if err := cm.checkClientLimit(); err != nil {
+		return nil, err
+	}
+
+	// Write the files to a fresh client directory
+	clientDir := filepath.Join(cm.dataDir, id)
+	if err := removeAllWithRetry(clientDir); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(clientDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create client directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(clientDir, bundleStoreFile), files[bundleStoreFile], 0600); err != nil {
+		return nil, fmt.Errorf("failed to write store: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(clientDir, bundleStateFile), files[bundleStateFile], 0600); err != nil {
+		return nil, fmt.Errorf("failed to write state file: %w", err)
+	}
+
+	client, state, err := cm.openSavedClient(id)
+	if err != nil {
+		return nil, err
+	}
+
+	cm.mutex.Lock()
+	if _, exists := cm.clients[id]; exists {
+		cm.mutex.Unlock()
+		client.Close()
+		return nil, fmt.Errorf("%w: %s", ErrClientExists, id)
+	}
+	// Another client may have taken the last place in the meantime
+	if err := cm.checkClientLimitLocked(cm.options().MaxClients); err != nil {
+		cm.mutex.Unlock()
+		client.Close()
+		removeAllWithRetry(clientDir)
+		return nil, err
+	}
+	cm.clients[id] = client
+	cm.mutex.Unlock()
+
+	cm.publishClientState(client.GetState())
+
+	if state.Status == StatusConnected || state.Connected {
+		go func() {
+			if err := client.Connect(); err != nil {
+				slog.Warn("Failed to connect imported client", "client", id, "error", err)
+			}
+		}()
+	}
+
+	return client, nil
+}
+
+// snapshotStore copies the client's sqlite database into a consistent
+// snapshot and returns its contents
+func (c *Client) snapshotStore() ([]byte, error) {
+	tmp, err := os.MkdirTemp("", "wagw-export-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	snapshot := filepath.Join(tmp, bundleStoreFile)
+	if c.encryptedStore {
+		// The bundle is encrypted itself; the store inside is plaintext so
+		// it can be imported with or without store encryption
+		if err := exportDB(context.Background(), c.db, snapshot, ""); err != nil {
+			return nil, fmt.Errorf("failed to snapshot store: %w", err)
+		}
+	} else if _, err := c.db.ExecContext(context.Background(), "VACUUM INTO ?", snapshot); err != nil {
+		return nil, fmt.Errorf("failed to snapshot store: %w", err)
+	}
+
+	data, err := os.ReadFile(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read store snapshot: %w", err)
+	}
+	return data, nil
+}
+
+// packBundle writes files into a gzipped tar archive
+func packBundle(files map[string][]byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	for _, name := range []string{bundleManifestFile, bundleStateFile, bundleStoreFile} {
+		data := files[name]
+		header := &tar.Header{Name: name, Mode: 0600, Size: int64(len(data)), ModTime: time.Now()}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, fmt.Errorf("failed to write bundle: %w", err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			return nil, fmt.Errorf("failed to write bundle: %w", err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// unpackBundle reads the known files from a gzipped tar archive and checks
+// they're all there
+func unpackBundle(archive []byte) (map[string][]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+	defer gz.Close()
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+		}
+		switch header.Name {
+		case bundleManifestFile, bundleStateFile, bundleStoreFile:
+		default:
+			return nil, fmt.Errorf("%w: unexpected file %q", ErrInvalidBundle, header.Name)
+		}
+		if header.Size > maxBundleFileSize {
+			return nil, fmt.Errorf("%w: %s is too large", ErrInvalidBundle, header.Name)
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, maxBundleFileSize))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+		}
+		files[header.Name] = data
+	}
+
+	for _, name := range []string{bundleManifestFile, bundleStateFile, bundleStoreFile} {
+		if _, ok := files[name]; !ok {
+			return nil, fmt.Errorf("%w: missing %s", ErrInvalidBundle, name)
+		}
+	}
+	return files, nil
+}
+
+// sealBundle encrypts data with AES-256-GCM under a key derived from
+// passphrase. The output is magic || salt || nonce || ciphertext.
+func sealBundle(data []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, bundleSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	gcm, err := bundleCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, bundleNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	header := make([]byte, 0, len(bundleMagic)+bundleSaltSize+bundleNonceSize)
+	header = append(header, bundleMagic...)
+	header = append(header, salt...)
+	header = append(header, nonce...)
+
+	// The header is authenticated along with the data
+	return gcm.Seal(header, nonce, data, header), nil
+}
+
+// openBundle decrypts a bundle made by sealBundle
+func openBundle(bundle []byte, passphrase string) ([]byte, error) {
+	headerSize := len(bundleMagic) + bundleSaltSize + bundleNonceSize
+	if len(bundle) < headerSize || string(bundle[:len(bundleMagic)]) != bundleMagic {
+		return nil, fmt.Errorf("%w: not a session bundle", ErrInvalidBundle)
+	}
+	header := bundle[:headerSize]
+	salt := header[len(bundleMagic) : len(bundleMagic)+bundleSaltSize]
+	nonce := header[len(bundleMagic)+bundleSaltSize:]
+
+	gcm, err := bundleCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	data, err := gcm.Open(nil, nonce, bundle[headerSize:], header)
+	if err != nil {
+		return nil, fmt.Errorf("%w: wrong key or corrupted data", ErrInvalidBundle)
+	}
+	return data, nil
+}
+
+// bundleCipher derives the AES-GCM cipher for a passphrase and salt
+func bundleCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, bundleKDFIterations, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive bundle key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
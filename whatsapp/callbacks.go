@@ -0,0 +1,216 @@
+package whatsapp
+
+import (
+	"container/list"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+const (
+	// deliveryCallbackTTL is how long a message's callback URL waits for
+	// receipts before it's dropped
+	deliveryCallbackTTL = 24 * time.Hour
+	// maxDeliveryCallbacks bounds the callbacks waiting for receipts. The
+	// oldest is dropped once it's reached.
+	maxDeliveryCallbacks = 10000
+)
+
+// callbackHTTPClient posts receipts to callback URLs. The URLs come from API
+// callers rather than the operator, so like link previews it only connects
+// to public addresses and never goes through a proxy.
+var callbackHTTPClient = &http.Client{
+	Timeout: webhookTimeout,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: webhookTimeout,
+			Control: publicAddressOnly,
+		}).DialContext,
+		TLSHandshakeTimeout:   webhookTimeout,
+		ResponseHeaderTimeout: webhookTimeout,
+	},
+}
+
+// WebhookEventReceipt is the event posted to a message's callback URL
+const WebhookEventReceipt = "receipt"
+
+// Receipt statuses posted to callback URLs
+const (
+	ReceiptStatusDelivered = "delivered"
+	ReceiptStatusRead      = "read"
+	ReceiptStatusPlayed    = "played"
+	ReceiptStatusFailed    = "failed"
+)
+
+// ReceiptCallbackData is the data of a receipt posted to a message's
+// callback URL
+type ReceiptCallbackData struct {
+	MessageID string `json:"message_id"`
+	ChatJID   string `json:"chat_jid"`
+	// Sender is who sent the receipt, which differs from the chat in groups
+	Sender string    `json:"sender,omitempty"`
+	Status string    `json:"status"`
+	At     time.Time `json:"at"`
+}
+
+// deliveryCallback is a message's callback URL waiting for receipts
+type deliveryCallback struct {
+	messageID string
+	url       string
+	expires   time.Time
+	// posted are the statuses already posted, since each receipt of a
+	// group message arrives once per participant
+	posted map[string]bool
+}
+
+// callbackTracker keeps the callback URLs of sent messages until their
+// final receipt arrives or they expire. Callbacks are added in send order
+// with the same TTL, so the oldest is always the first to expire.
+type callbackTracker struct {
+	mutex     sync.Mutex
+	callbacks map[string]*list.Element
+	order     *list.List
+}
+
+// newCallbackTracker creates an empty callback tracker
+func newCallbackTracker() *callbackTracker {
+	return &callbackTracker{
+		callbacks: make(map[string]*list.Element),
+		order:     list.New(),
+	}
+}
+
+// validateCallbackURL checks a callback URL is an absolute http(s) URL that
+// doesn't point at the gateway's own network. Host names are only checked
+// when receipts are posted, since they can resolve differently by then.
+func validateCallbackURL(callbackURL string) error {
+	u, err := url.Parse(callbackURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("%w: callback_url must be an absolute http or https URL", ErrInvalidMessage)
+	}
+
+	host := u.Hostname()
+	if ip, err := netip.ParseAddr(host); err == nil && !isPublicAddr(ip) {
+		return fmt.Errorf("%w: callback_url must not point at a non-public address", ErrInvalidMessage)
+	}
+	if host = strings.ToLower(strings.TrimSuffix(host, ".")); host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: callback_url must not point at a non-public address", ErrInvalidMessage)
+	}
+	return nil
+}
+
+// add registers a callback URL for a sent message
+func (t *callbackTracker) add(messageID string, callbackURL string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := time.Now()
+	t.dropExpiredLocked(now)
+	if elem, ok := t.callbacks[messageID]; ok {
+		t.removeLocked(elem)
+	}
+	if t.order.Len() >= maxDeliveryCallbacks {
+		t.removeLocked(t.order.Front())
+	}
+
+	t.callbacks[messageID] = t.order.PushBack(&deliveryCallback{
+		messageID: messageID,
+		url:       callbackURL,
+		expires:   now.Add(deliveryCallbackTTL),
+		posted:    make(map[string]bool),
+	})
+}
+
+// take returns the callback URL of a message if it hasn't posted status yet.
+// Final statuses remove the callback.
+func (t *callbackTracker) take(messageID string, status string) (string, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.dropExpiredLocked(time.Now())
+	elem, ok := t.callbacks[messageID]
+	if !ok {
+		return "", false
+	}
+	callback := elem.Value.(*deliveryCallback)
+	if callback.posted[status] {
+		return "", false
+	}
+	callback.posted[status] = true
+	if status != ReceiptStatusDelivered {
+		// Nothing follows a read, played or failed receipt
+		t.removeLocked(elem)
+	}
+	return callback.url, true
+}
+
+// dropExpiredLocked removes the callbacks that have expired. Must hold mutex.
+func (t *callbackTracker) dropExpiredLocked(now time.Time) {
+	for elem := t.order.Front(); elem != nil && now.After(elem.Value.(*deliveryCallback).expires); elem = t.order.Front() {
+		t.removeLocked(elem)
+	}
+}
+
+// removeLocked removes a callback. Must hold mutex.
+func (t *callbackTracker) removeLocked(elem *list.Element) {
+	t.order.Remove(elem)
+	delete(t.callbacks, elem.Value.(*deliveryCallback).messageID)
+}
+
+// receiptStatus returns the callback status of a receipt, or "" for
+// receipts that don't say anything about delivery
+func receiptStatus(receiptType types.ReceiptType) string {
+	switch receiptType {
+	case types.ReceiptTypeDelivered:
+		return ReceiptStatusDelivered
+	case types.ReceiptTypeRead:
+		return ReceiptStatusRead
+	case types.ReceiptTypePlayed:
+		return ReceiptStatusPlayed
+	case types.ReceiptTypeServerError:
+		return ReceiptStatusFailed
+	}
+	return ""
+}
+
+// notifyReceiptCallbacks posts a receipt to the callback URLs of the
+// messages it covers. Callbacks are signed with the webhook secret, if one
+// is set.
+func (c *Client) notifyReceiptCallbacks(evt *events.Receipt) {
+	status := receiptStatus(evt.Type)
+	if status == "" {
+		return
+	}
+
+	var secret string
+	if hook := c.hook.Load(); hook != nil {
+		secret = hook.secret
+	}
+	for _, id := range evt.MessageIDs {
+		callbackURL, ok := c.callbacks.take(id, status)
+		if !ok {
+			continue
+		}
+		hook := &webhook{url: callbackURL, secret: secret, client: callbackHTTPClient}
+		hook.send(WebhookPayload{
+			Event:     WebhookEventReceipt,
+			ClientID:  c.ID,
+			Timestamp: time.Now(),
+			Data: ReceiptCallbackData{
+				MessageID: id,
+				ChatJID:   evt.Chat.String(),
+				Sender:    evt.Sender.String(),
+				Status:    status,
+				At:        evt.Timestamp,
+			},
+		})
+	}
+}
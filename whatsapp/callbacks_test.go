@@ -0,0 +1,56 @@
+package whatsapp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestValidateCallbackURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{url: "https://example.com/receipts"},
+		{url: "http://93.184.215.14:8080/receipts"},
+		{url: "ftp://example.com/receipts", wantErr: true},
+		{url: "/receipts", wantErr: true},
+		{url: "http://127.0.0.1/receipts", wantErr: true},
+		{url: "http://[::1]/receipts", wantErr: true},
+		{url: "http://10.0.0.5/receipts", wantErr: true},
+		{url: "http://169.254.169.254/latest/meta-data", wantErr: true},
+		{url: "http://[::ffff:192.168.1.1]/receipts", wantErr: true},
+		{url: "http://localhost:8080/receipts", wantErr: true},
+		{url: "http://api.localhost./receipts", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			err := validateCallbackURL(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateCallbackURL(%q) error = %v, want error %v", tt.url, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCallbackRefusesNonPublicAddress(t *testing.T) {
+	hit := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit <- struct{}{}
+	}))
+	defer server.Close()
+
+	// A host name can pass validation and still resolve to the gateway's own
+	// network, so delivery checks the address it connects to
+	hook := &webhook{url: server.URL, client: callbackHTTPClient}
+	if err := hook.deliver(WebhookPayload{Event: WebhookEventReceipt, Timestamp: time.Now()}); err == nil {
+		t.Fatal("callback to a loopback address was delivered")
+	}
+	select {
+	case <-hit:
+		t.Fatal("callback reached the loopback server")
+	default:
+	}
+}
@@ -0,0 +1,107 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow/appstate"
+	"go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// MuteForever mutes a chat until it's unmuted
+const MuteForever time.Duration = -1
+
+// ChatSettings are a chat's mute, archive and pin state as synced from the
+// phone
+type ChatSettings struct {
+	ChatJID string `json:"chat_jid"`
+	Muted   bool   `json:"muted"`
+	// MutedUntil is unset when the chat is muted forever
+	MutedUntil *time.Time `json:"muted_until,omitempty"`
+	Archived   bool       `json:"archived"`
+	Pinned     bool       `json:"pinned"`
+}
+
+// GetChatSettings returns a chat's mute, archive and pin state
+func (c *Client) GetChatSettings(chatJID string) (ChatSettings, error) {
+	jid, err := c.parseRecipient(chatJID)
+	if err != nil {
+		return ChatSettings{}, err
+	}
+
+	local, err := c.client.Store.ChatSettings.GetChatSettings(context.Background(), jid)
+	if err != nil {
+		return ChatSettings{}, fmt.Errorf("failed to get chat settings: %w", err)
+	}
+
+	settings := ChatSettings{
+		ChatJID:  jid.String(),
+		Archived: local.Archived,
+		Pinned:   local.Pinned,
+	}
+	switch {
+	case local.MutedUntil.Equal(store.MutedForever):
+		settings.Muted = true
+	case local.MutedUntil.After(time.Now()):
+		settings.Muted = true
+		settings.MutedUntil = &local.MutedUntil
+	}
+	return settings, nil
+}
+
+// MuteChat mutes a chat for duration, or until it's unmuted with
+// MuteForever. A duration of zero unmutes it.
+func (c *Client) MuteChat(chatJID string, duration time.Duration) error {
+	if duration < 0 && duration != MuteForever {
+		return fmt.Errorf("%w: mute duration cannot be negative", ErrInvalidMessage)
+	}
+	return c.sendChatPatch(chatJID, "mute", func(jid types.JID) appstate.PatchInfo {
+		patch := appstate.BuildMute(jid, duration != 0, max(duration, 0))
+		if duration == MuteForever {
+			// Phones mark a mute without end with -1; leaving it out
+			// reads back as muted until 1970
+			patch.Mutations[0].Value.MuteAction.MuteEndTimestamp = proto.Int64(-1)
+		}
+		return patch
+	})
+}
+
+// ArchiveChat archives or unarchives a chat. Archiving also unpins it.
+func (c *Client) ArchiveChat(chatJID string, archive bool) error {
+	return c.sendChatPatch(chatJID, "archive", func(jid types.JID) appstate.PatchInfo {
+		return appstate.BuildArchive(jid, archive, time.Time{}, nil)
+	})
+}
+
+// PinChat pins or unpins a chat
+func (c *Client) PinChat(chatJID string, pin bool) error {
+	return c.sendChatPatch(chatJID, "pin", func(jid types.JID) appstate.PatchInfo {
+		return appstate.BuildPin(jid, pin)
+	})
+}
+
+// sendChatPatch syncs a change to a chat's settings to the other linked
+// devices. whatsmeow fetches the patch back, which updates the local copy
+// GetChatSettings reads.
+func (c *Client) sendChatPatch(chatJID string, action string, build func(types.JID) appstate.PatchInfo) error {
+	jid, err := c.parseRecipient(chatJID)
+	if err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	c.lastActivity = time.Now()
+	c.mutex.Unlock()
+
+	if !c.client.IsLoggedIn() {
+		return ErrNotLoggedIn
+	}
+
+	if err := c.client.SendAppState(context.Background(), build(jid)); err != nil {
+		return fmt.Errorf("failed to %s chat: %w", action, err)
+	}
+	return nil
+}
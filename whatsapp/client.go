@@ -2,13 +2,19 @@ package whatsapp
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.mau.fi/whatsmeow"
@@ -27,22 +33,168 @@ import (
 type ClientStatus string
 
 const (
-	StatusLoggedOut ClientStatus = "logged_out"
-	StatusConnected ClientStatus = "connected"
+	StatusLoggedOut    ClientStatus = "logged_out"
+	StatusConnected    ClientStatus = "connected"
 	StatusDisconnected ClientStatus = "disconnected"
-	StatusError ClientStatus = "error"
+	StatusError        ClientStatus = "error"
 )
 
+// ErrNotLoggedIn is returned by operations that need a logged in session
+var ErrNotLoggedIn = errors.New("not logged in")
+
+// ErrNotConnected is returned when sending while the client is disconnected
+var ErrNotConnected = errors.New("not connected")
+
+// ErrAlreadyLoggedIn is returned when linking a client that is already logged in
+var ErrAlreadyLoggedIn = errors.New("already logged in")
+
+// ErrAlreadyExists is wrapped by errors for things that can't be created
+// because they already exist
+var ErrAlreadyExists = errors.New("already exists")
+
+// ErrInvalidRecipient is returned when a recipient can't be turned into a JID
+var ErrInvalidRecipient = errors.New("invalid recipient")
+
+// ErrInvalidGroupJID is returned when a group JID is malformed or not a group
+var ErrInvalidGroupJID = errors.New("invalid group JID")
+
+// ErrInvalidMessage is returned when the content of a message is malformed
+var ErrInvalidMessage = errors.New("invalid message")
+
+// ErrSelfSend is returned when sending to the client's own number without
+// allowing it
+var ErrSelfSend = fmt.Errorf("%w: recipient is the client's own number, set allow_self to send anyway", ErrInvalidRecipient)
+
+// Options holds settings applied to every client
+type Options struct {
+	// MessagesPerMinute limits how fast a client sends. 0 disables the limit.
+	MessagesPerMinute int
+	// SendConcurrency is how many of a client's queued messages may be sent
+	// at once. Above 1, messages can arrive in a different order than they
+	// were queued in.
+	SendConcurrency int
+	// MaxClients caps the clients a manager holds. 0 allows any number.
+	MaxClients int
+	// MessageHistoryLimit caps the received messages stored per client.
+	// 0 disables message history.
+	MessageHistoryLimit int
+	// EventLogSize caps the connection events kept in memory per client.
+	// 0 disables the event log.
+	EventLogSize int
+	// DownloadMedia saves attachments of received messages to disk
+	DownloadMedia bool
+	// DebugEvents logs every WhatsApp event the client doesn't handle
+	DebugEvents bool
+	// MediaRetention is how long downloaded media is kept. 0 keeps it forever.
+	MediaRetention time.Duration
+	// InlineMediaLimit is the largest downloaded media, in bytes, that is
+	// included in message webhooks as well as linked. 0 never includes it.
+	InlineMediaLimit int
+	// AutoReconnect retries the connection with backoff after an unexpected
+	// disconnect
+	AutoReconnect bool
+	// PingInterval is how often connected clients are pinged to catch dead
+	// sockets. 0 disables the check.
+	PingInterval time.Duration
+	// DBDriver selects the session store: DriverSQLite (default), with a
+	// database file per client, or DriverPostgres, with a schema per client
+	DBDriver string
+	// DBDSN is the connection string for DriverPostgres
+	DBDSN string
+	// EncryptionKey encrypts DriverSQLite stores with SQLCipher. Existing
+	// plaintext stores are encrypted when they're opened.
+	EncryptionKey string
+	// DefaultCountryCode is put in front of national phone numbers (ones
+	// starting with a single 0). Without it such numbers are rejected.
+	DefaultCountryCode string
+	// SendRetries is how many times a send that failed for a transient
+	// reason, such as a dropped connection, is retried
+	SendRetries int
+	// SendRetryBackoff is the wait before the first retry. It doubles with
+	// each further retry.
+	SendRetryBackoff time.Duration
+	// StateSaveInterval is how often every client's state is saved. Clients
+	// are also saved soon after their connection state changes. 0 disables
+	// the periodic save.
+	StateSaveInterval time.Duration
+	// ProxyURL routes the WhatsApp connection and media transfers through
+	// an http, https or socks5 proxy when set
+	ProxyURL string
+	// DeviceName is shown in the phone's linked devices list. Defaults to
+	// DefaultDeviceName.
+	DeviceName string
+	// ExpectedPhone is the number a saved client was linked to. It picks the
+	// client's session when its store holds more than one.
+	ExpectedPhone string
+	// WebhookURL receives event notifications when set
+	WebhookURL string
+	// WebhookSecret signs webhook bodies with HMAC-SHA256 when set
+	WebhookSecret string
+
+	// onStatusChange is called with the new state whenever a client's
+	// connection state changes. Set by the client manager.
+	onStatusChange func(ClientState)
+	// onOutdated is called when WhatsApp rejects the client version. Set by
+	// the client manager.
+	onOutdated func(clientID string)
+}
+
+// maxClientIDLength bounds client IDs, which double as directory names
+const maxClientIDLength = 64
+
+// clientIDPattern is the allowlist for client IDs
+var clientIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ErrInvalidClientID is returned for client IDs that aren't safe to use
+var ErrInvalidClientID = errors.New("invalid client ID")
+
+// ValidateClientID checks that a client ID only uses letters, digits, dashes
+// and underscores. IDs are used as directory names under the data directory,
+// so anything else could escape it.
+func ValidateClientID(id string) error {
+	if id == "" {
+		return fmt.Errorf("%w: cannot be empty", ErrInvalidClientID)
+	}
+	if len(id) > maxClientIDLength {
+		return fmt.Errorf("%w: longer than %d characters", ErrInvalidClientID, maxClientIDLength)
+	}
+	if !clientIDPattern.MatchString(id) {
+		return fmt.Errorf("%w: only letters, digits, '-' and '_' are allowed", ErrInvalidClientID)
+	}
+	return nil
+}
+
 // ClientState represents the persistent state of a client
 type ClientState struct {
-	ID               string       `json:"id"`
-	Status           ClientStatus `json:"status"`
-	LastActivity     time.Time    `json:"last_activity"`
-	Connected        bool         `json:"connected"`
-	LoggedIn         bool         `json:"logged_in"`
-	PushName         string       `json:"push_name"`
-	PhoneNumber      string       `json:"phone_number,omitempty"`
-	ConnectionError  string       `json:"connection_error,omitempty"`
+	ID                string            `json:"id"`
+	Status            ClientStatus      `json:"status"`
+	LastActivity      time.Time         `json:"last_activity"`
+	Connected         bool              `json:"connected"`
+	LoggedIn          bool              `json:"logged_in"`
+	PushName          string            `json:"push_name"`
+	PhoneNumber       string            `json:"phone_number,omitempty"`
+	ConnectionError   string            `json:"connection_error,omitempty"`
+	ReconnectAttempts int               `json:"reconnect_attempts,omitempty"`
+	LogoutReason      string            `json:"logout_reason,omitempty"`
+	QRWebhook         bool              `json:"qr_webhook"`
+	Labels            []string          `json:"labels,omitempty"`
+	Metadata          map[string]string `json:"metadata,omitempty"`
+	// LastPing is when the connection last answered a connection check
+	LastPing *time.Time `json:"last_ping,omitempty"`
+	// ConnectedSince is when the current connection came up; UptimeSeconds
+	// is how long ago that was. Both are only set while connected.
+	ConnectedSince *time.Time `json:"connected_since,omitempty"`
+	UptimeSeconds  int64      `json:"uptime_seconds,omitempty"`
+	// LastConnectedAt is when the client last connected, kept across
+	// restarts and cleared on logout
+	LastConnectedAt *time.Time `json:"last_connected_at,omitempty"`
+	// DeviceName is only set when the client overrides the configured name
+	DeviceName string `json:"device_name,omitempty"`
+	// Filters decide which received messages reach the webhook
+	Filters *InboundFilters `json:"filters,omitempty"`
+
+	// APIKey is only filled in when the state is saved to disk
+	APIKey string `json:"api_key,omitempty"`
 }
 
 // Client represents a WhatsApp client instance
@@ -50,33 +202,132 @@ type Client struct {
 	ID           string
 	client       *whatsmeow.Client
 	container    *sqlstore.Container
+	db           *sql.DB
 	eventHandler func(event interface{})
 	deviceStore  *store.Device
-	
+	// encryptedStore is set when db is an SQLCipher database
+	encryptedStore bool
+
 	// Client state
-	status      ClientStatus
+	apiKey       string
+	status       ClientStatus
 	lastActivity time.Time
-	connError   string
-	
+	connError    string
+	logoutReason string
+
 	// For safe concurrent access
-	mutex       sync.RWMutex
-	
+	mutex sync.RWMutex
+
+	// Serializes writes of the state file
+	saveMutex sync.Mutex
+
+	// Set once Close has released the database
+	closed bool
+
 	// For QR channel
-	qrChan      chan string
-	qrTimeout   *time.Timer
-	
+	qrChan chan string
+	// The QR login in progress, if any
+	qrAttempt *qrAttempt
+	qrTimeout *time.Timer
+
 	// For phone pairing channel
 	pairChan    chan string
 	pairTimeout *time.Timer
-	
+
+	// Outgoing message queue
+	queue *sendQueue
+
+	// Delivery receipts of sent messages
+	receipts *receiptTracker
+
+	// Callback URLs of sent messages waiting for receipts
+	callbacks *callbackTracker
+
+	// Callers waiting for a contact's presence
+	presences *presenceWaiters
+
+	// Recent connection events for debugging
+	events *eventLog
+
+	// Maximum number of received messages kept in history
+	historyLimit int
+
+	// Whether attachments of received messages are saved to disk
+	downloadMedia bool
+	// Largest downloaded media sent inline in message webhooks
+	inlineMediaLimit int
+
+	// Whether unhandled events are logged
+	debugEvents bool
+
+	// Country code used for national phone numbers
+	defaultCountryCode string
+
+	// Reconnect supervisor, running while reconnectStop is set
+	autoReconnect     bool
+	reconnectStop     chan struct{}
+	reconnectAttempts int
+
+	// The latest temporary ban and refused connection, until the next
+	// successful connection
+	tempBan     *temporaryBan
+	connFailure *connectFailure
+
+	// Connection checks; lastPing is the last answered one
+	lastPing time.Time
+
+	// When the current connection came up, zero while disconnected, and
+	// when the client last connected at all
+	connectedSince time.Time
+	lastConnected  time.Time
+	pinging        atomic.Bool
+
+	// Event notifications
+	// hook is swapped when the webhook is reconfigured at runtime
+	hook      atomic.Pointer[webhook]
+	qrWebhook bool
+
+	// Labels and metadata for organizing clients, saved with the state
+	labels   []string
+	metadata map[string]string
+	// filters are replaced as a whole, never changed in place
+	filters        *InboundFilters
+	onStatusChange func(ClientState)
+	onOutdated     func(clientID string)
+	outdated       atomic.Bool
+	connNotifier   connectionNotifier
+
+	// Messages scheduled for later delivery
+	scheduled     []ScheduledMessage
+	scheduleMutex sync.Mutex
+
+	// Retries of failed sends, and the messages that failed for good
+	sendRetries      int
+	sendRetryBackoff time.Duration
+	deadLetters      []DeadLetter
+	deadLetterMutex  sync.Mutex
+
+	// Named message templates
+	templates     map[string]MessageTemplate
+	templateMutex sync.Mutex
+
+	// For throttling outgoing sends
+	throttleMutex sync.Mutex
+	nextSendAt    time.Time
+
+	// Name shown in the phone's linked devices list, and the per-client
+	// override it came from, if any
+	deviceName       string
+	customDeviceName string
+
 	// Data directory
-	dataDir     string
+	dataDir string
 }
 
 // NewClient creates a new WhatsApp client
-func NewClient(id string, dataDir string) (*Client, error) {
-	if id == "" {
-		return nil, errors.New("client ID cannot be empty")
+func NewClient(id string, dataDir string, opts Options) (*Client, error) {
+	if err := ValidateClientID(id); err != nil {
+		return nil, err
 	}
 
 	// Create client directory
@@ -85,38 +336,116 @@ func NewClient(id string, dataDir string) (*Client, error) {
 		return nil, fmt.Errorf("failed to create client directory: %w", err)
 	}
 
-	// Create database file
-	dbPath := filepath.Join(clientDir, "whatsapp.db")
-	container, err := sqlstore.New("sqlite3", "file:"+dbPath+"?_foreign_keys=on", waLog.Stdout("sqlstore", "DEBUG", true))
+	// Open the client's database
+	db, dialect, err := openStore(id, clientDir, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
+		return nil, err
+	}
+
+	// Share the connection with the whatsmeow store so the gateway's own
+	// tables live in the same database
+	container := sqlstore.NewWithDB(db, dialect, waLog.Stdout("sqlstore", "DEBUG", true))
+	if err := container.Upgrade(context.Background()); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to upgrade database: %w", err)
 	}
 
 	// Get device store
-	deviceStore, err := container.GetFirstDevice()
+	deviceStore, err := selectDevice(context.Background(), id, container, opts.ExpectedPhone)
 	if err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to get device: %w", err)
 	}
 
 	// Create the client
 	wac := whatsmeow.NewClient(deviceStore, waLog.Stdout("whatsapp", "INFO", true))
 
+	// Reconnects are handled by our own supervisor
+	wac.EnableAutoReconnect = false
+
+	if opts.ProxyURL != "" {
+		if err := wac.SetProxyAddress(opts.ProxyURL); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to set proxy: %w", err)
+		}
+	}
+
+	deviceName := opts.DeviceName
+	if deviceName == "" {
+		deviceName = DefaultDeviceName
+	}
+
 	// Create the client wrapper
 	c := &Client{
-		ID:          id,
-		client:      wac,
-		container:   container,
-		deviceStore: deviceStore,
-		status:      StatusLoggedOut,
-		lastActivity: time.Now(),
-		dataDir:     clientDir,
-		qrChan:      make(chan string),
-		pairChan:    make(chan string),
+		ID:                 id,
+		client:             wac,
+		container:          container,
+		deviceStore:        deviceStore,
+		status:             StatusLoggedOut,
+		lastActivity:       time.Now(),
+		dataDir:            clientDir,
+		qrChan:             make(chan string),
+		pairChan:           make(chan string),
+		queue:              newSendQueue(opts.MessagesPerMinute, opts.SendConcurrency),
+		receipts:           newReceiptTracker(),
+		callbacks:          newCallbackTracker(),
+		presences:          newPresenceWaiters(),
+		events:             newEventLog(opts.EventLogSize),
+		db:                 db,
+		historyLimit:       opts.MessageHistoryLimit,
+		downloadMedia:      opts.DownloadMedia,
+		inlineMediaLimit:   opts.InlineMediaLimit,
+		debugEvents:        opts.DebugEvents,
+		autoReconnect:      opts.AutoReconnect,
+		onStatusChange:     opts.onStatusChange,
+		onOutdated:         opts.onOutdated,
+		defaultCountryCode: opts.DefaultCountryCode,
+		sendRetries:        opts.SendRetries,
+		sendRetryBackoff:   opts.SendRetryBackoff,
+		templates:          make(map[string]MessageTemplate),
+		deviceName:         deviceName,
+		encryptedStore:     opts.storeEncrypted(),
+	}
+	wac.GetClientPayload = c.clientPayload
+
+	c.hook.Store(newWebhook(opts.WebhookURL, opts.WebhookSecret))
+
+	// Connection webhooks report changes relative to the state on startup
+	if deviceStore.ID != nil {
+		c.connNotifier.reported = StatusDisconnected
+	} else {
+		c.connNotifier.reported = StatusLoggedOut
+	}
+
+	// Set up message history storage
+	if err := c.initHistory(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// Restore messages scheduled before a restart
+	if err := c.loadSchedule(); err != nil {
+		slog.Warn("Failed to load schedule", "client", id, "error", err)
+	}
+
+	// Restore saved message templates
+	if err := c.loadTemplates(); err != nil {
+		slog.Warn("Failed to load templates", "client", id, "error", err)
+	}
+
+	// Keep messages that failed before a restart for replay
+	if err := c.loadDeadLetters(); err != nil {
+		slog.Warn("Failed to load dead letters", "client", id, "error", err)
 	}
 
 	// Set up event handler
 	wac.AddEventHandler(c.handleEvent)
 
+	// Start sending queued messages
+	for i := 0; i < c.queue.workers; i++ {
+		go c.queue.run(c)
+	}
+
 	return c, nil
 }
 
@@ -159,6 +488,9 @@ func (c *Client) Disconnect() error {
 	// Update activity timestamp
 	c.lastActivity = time.Now()
 
+	// A manual disconnect shouldn't be undone by the supervisor
+	c.stopReconnectLocked()
+
 	// Check if connected
 	if !c.client.IsConnected() {
 		return nil
@@ -167,10 +499,17 @@ func (c *Client) Disconnect() error {
 	// Disconnect
 	c.client.Disconnect()
 	c.status = StatusDisconnected
+	c.connectedSince = time.Time{}
+
+	// Fail anything still waiting to be sent
+	c.queue.drain(c)
 
 	return nil
 }
 
+// logoutTimeout bounds how long logging out waits for WhatsApp
+const logoutTimeout = 10 * time.Second
+
 // Logout logs out the client and removes device store
 func (c *Client) Logout() error {
 	c.mutex.Lock()
@@ -179,13 +518,17 @@ func (c *Client) Logout() error {
 	// Update activity timestamp
 	c.lastActivity = time.Now()
 
+	c.stopReconnectLocked()
+
 	// Check if logged in
 	if !c.client.IsLoggedIn() {
 		return nil
 	}
 
 	// Logout
-	err := c.client.Logout()
+	ctx, cancel := context.WithTimeout(context.Background(), logoutTimeout)
+	defer cancel()
+	err := c.client.Logout(ctx)
 	if err != nil {
 		c.status = StatusError
 		c.connError = err.Error()
@@ -193,62 +536,136 @@ func (c *Client) Logout() error {
 	}
 
 	c.status = StatusLoggedOut
+	c.connectedSince = time.Time{}
+	c.lastConnected = time.Time{}
+
+	// Fail anything still waiting to be sent
+	c.queue.drain(c)
+
 	return nil
 }
 
-// GenerateQR generates a QR code for authentication
-func (c *Client) GenerateQR() (string, error) {
+// GenerateQR generates a QR code for authentication. If ctx is cancelled
+// before a code arrives, the connection attempt is torn down. A QR login
+// already in progress is cancelled and replaced.
+func (c *Client) GenerateQR(ctx context.Context) (string, error) {
+	return c.generateQR(ctx, c.connectForQRLocked)
+}
+
+// generateQR replaces any QR login in progress with a new one and waits for
+// its first code. connect starts the login and returns its QR channel; it's
+// called with c.mutex held and must not outlive pairCtx.
+func (c *Client) generateQR(ctx context.Context, connect func(pairCtx context.Context) (<-chan whatsmeow.QRChannelItem, error)) (string, error) {
 	c.mutex.Lock()
 
 	// Update activity timestamp
 	c.lastActivity = time.Now()
 
+	// Stop a previous request first; it may still link the client
+	c.replaceQRAttemptLocked()
+
+	// The QR channel has to outlive ctx: once a code is returned the user
+	// still needs time to scan it, long after the HTTP request has finished.
+	// It gets its own context that is only cancelled if the attempt is
+	// abandoned before a code is handed out, or replaced by a newer one.
+	pairCtx, cancelPair := context.WithCancel(context.Background())
+
+	qrChan, err := connect(pairCtx)
+	if err != nil {
+		cancelPair()
+		c.mutex.Unlock()
+		return "", err
+	}
+
+	attempt := &qrAttempt{ctx: pairCtx, cancel: cancelPair, done: make(chan struct{})}
+	c.qrAttempt = attempt
+
+	// Release the mutex while waiting for the QR code
+	c.mutex.Unlock()
+
+	return c.awaitQRCode(ctx, attempt, qrChan)
+}
+
+// connectForQRLocked connects the client for a QR login and returns its QR
+// channel. Must hold c.mutex.
+func (c *Client) connectForQRLocked(pairCtx context.Context) (<-chan whatsmeow.QRChannelItem, error) {
 	// Check if already logged in
 	if c.client.IsLoggedIn() {
-		c.mutex.Unlock()
-		return "", errors.New("already logged in")
+		return nil, ErrAlreadyLoggedIn
 	}
-	
+
 	// Disconnect first if already connected
 	if c.client.IsConnected() {
 		c.client.Disconnect()
 	}
+	c.dropQRHandlers()
 
 	// Get QR channel BEFORE connecting
-	qrChan, err := c.client.GetQRChannel(context.Background())
+	qrChan, err := c.client.GetQRChannel(pairCtx)
 	if err != nil {
 		c.status = StatusError
 		c.connError = err.Error()
-		c.mutex.Unlock()
-		return "", fmt.Errorf("failed to request QR: %w", err)
+		return nil, fmt.Errorf("failed to request QR: %w", err)
 	}
 
 	// Now connect after getting QR channel
-	err = c.client.Connect()
-	if err != nil {
+	if err := c.client.Connect(); err != nil {
 		c.status = StatusError
 		c.connError = err.Error()
-		c.mutex.Unlock()
-		return "", fmt.Errorf("failed to connect: %w", err)
+		return nil, fmt.Errorf("failed to connect: %w", err)
 	}
 
-	// Release the mutex while waiting for the QR code
-	c.mutex.Unlock()
-	
+	return qrChan, nil
+}
+
+// awaitQRCode waits for the first code of a QR login. The attempt is torn
+// down if ctx is done, the wait times out or no code arrives; once a code is
+// returned, later codes keep being forwarded in the background.
+func (c *Client) awaitQRCode(ctx context.Context, attempt *qrAttempt, qrChan <-chan whatsmeow.QRChannelItem) (string, error) {
+	// abandon stops the QR channel and drops the connection attempt
+	abandon := func() {
+		attempt.cancel()
+		c.client.Disconnect()
+		c.finishQRAttempt(attempt)
+	}
+
+	timeout := time.NewTimer(qrCodeTimeout)
+	defer timeout.Stop()
+
 	// Wait for QR code with timeout handling
 	select {
-	case evt := <-qrChan:
+	case evt, ok := <-qrChan:
+		if !ok {
+			abandon()
+			return "", errors.New("QR channel closed before a code was received")
+		}
 		// Check the event type
 		if evt.Event == "code" {
+			qrGenerations.WithLabelValues(c.ID).Inc()
+			go func() {
+				c.forwardQRCodes(evt, qrChan)
+				c.finishQRAttempt(attempt)
+			}()
 			return evt.Code, nil
-		} else if evt.Event == "err-client-outdated" {
-			// This error means the WhatsApp Web version is outdated
-			// Real solution would be to update whatsmeow library
-			return "", errors.New("WhatsApp Web client outdated. Please update the whatsmeow library or try again later.")
+		}
+		abandon()
+		if evt == whatsmeow.QRChannelClientOutdated {
+			c.reportOutdated()
+			return "", ErrClientOutdated
 		}
 		return "", fmt.Errorf("unexpected QR event: %s", evt.Event)
-		
-	case <-time.After(30 * time.Second):
+
+	case <-ctx.Done():
+		abandon()
+		return "", fmt.Errorf("QR code request cancelled: %w", ctx.Err())
+
+	case <-attempt.ctx.Done():
+		// A newer request took over the connection and tears it down
+		c.finishQRAttempt(attempt)
+		return "", ErrQRSuperseded
+
+	case <-timeout.C:
+		abandon()
 		return "", errors.New("timeout waiting for QR code")
 	}
 }
@@ -266,57 +683,317 @@ func (c *Client) GetPairingCode() (string, error) {
 	return "", errors.New("phone pairing is not available in the current library version")
 }
 
-// SendMessage sends a WhatsApp message
-func (c *Client) SendMessage(recipient string, message string) error {
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
+// SendOptions holds optional settings for an outgoing text message
+type SendOptions struct {
+	// QuotedMessageID makes the message a reply to the message with this ID
+	QuotedMessageID string `json:"quoted_message_id,omitempty"`
+	// QuotedSender is the author of the quoted message. Defaults to the
+	// recipient, which is correct for replies in one-to-one chats.
+	QuotedSender string `json:"quoted_sender,omitempty"`
+	// QuotedText is shown as the quoted content. Message bodies aren't
+	// stored, so the caller has to supply it.
+	QuotedText string `json:"quoted_text,omitempty"`
+	// EphemeralSeconds makes the message disappear after this many seconds.
+	// It must be one of WhatsApp's disappearing message timers.
+	EphemeralSeconds int `json:"ephemeral_seconds,omitempty"`
+	// AllowSelf allows sending to the client's own number, which is
+	// otherwise rejected as a likely mistake
+	AllowSelf bool `json:"allow_self,omitempty"`
+	// LinkPreview attaches a preview of the first link in the message. If
+	// the page can't be fetched, the message is sent without one.
+	LinkPreview bool `json:"link_preview,omitempty"`
+	// CallbackURL receives the message's delivery and read receipts
+	CallbackURL string `json:"callback_url,omitempty"`
+	// Mentions are the phone numbers or user JIDs to tag in the message.
+	// Their @number tokens are added to the text if it lacks them.
+	Mentions []string `json:"mentions,omitempty"`
+}
 
-	// Update activity timestamp
+// SendMessage sends a WhatsApp message and returns its message ID
+func (c *Client) SendMessage(recipient string, message string) (string, error) {
+	return c.SendMessageWithOptions(recipient, message, SendOptions{})
+}
+
+// SendMessageWithOptions sends a WhatsApp message with optional reply context
+// and returns its message ID. The message goes through the client's rate
+// limited send queue, so this blocks until it's the message's turn.
+func (c *Client) SendMessageWithOptions(recipient string, message string, opts SendOptions) (string, error) {
+	return c.SendMessageContext(context.Background(), recipient, message, opts)
+}
+
+// SendMessageContext is SendMessageWithOptions with a context. When ctx is
+// done before the message's turn, it is dropped from the queue; a send that
+// is already under way is cancelled too.
+func (c *Client) SendMessageContext(ctx context.Context, recipient string, message string, opts SendOptions) (string, error) {
+	// Reject bad messages now rather than after they've waited in the queue
+	jid, err := c.parseRecipient(recipient)
+	if err != nil {
+		return "", err
+	}
+	if err := c.checkTextMessage(jid, message, opts); err != nil {
+		return "", err
+	}
+
+	return c.sendAndWait(ctx, &queuedMessage{
+		recipient: recipient,
+		message:   message,
+		opts:      opts,
+		preview:   c.linkPreviewFor(ctx, message, opts),
+	})
+}
+
+// sendContent sends a prebuilt message through the send queue and returns
+// its message ID
+func (c *Client) sendContent(recipient string, content *waProto.Message) (string, error) {
+	if _, err := c.parseRecipient(recipient); err != nil {
+		return "", err
+	}
+
+	return c.sendAndWait(context.Background(), &queuedMessage{
+		recipient: recipient,
+		content:   content,
+	})
+}
+
+// sendAndWait queues a message and waits until it has been sent or ctx is done
+func (c *Client) sendAndWait(ctx context.Context, job *queuedMessage) (string, error) {
+	job.ctx = ctx
+	job.done = make(chan sendOutcome, 1)
+	if err := c.queue.enqueue(job); err != nil {
+		return "", err
+	}
+
+	select {
+	case outcome := <-job.done:
+		return outcome.messageID, outcome.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// sendNow sends a queued message immediately. Must not hold c.mutex.
+func (c *Client) sendNow(job *queuedMessage) (string, error) {
+	// Only the activity timestamp needs the lock. The whatsmeow client is
+	// safe for concurrent use, and holding the lock over the network round
+	// trip would block GetState and everything else on the client.
+	c.mutex.Lock()
 	c.lastActivity = time.Now()
+	c.mutex.Unlock()
 
 	// Check if connected and logged in
 	if !c.client.IsConnected() {
-		return errors.New("not connected")
+		// Say why the connection is down when it won't come back by itself
+		if c.outdated.Load() {
+			return "", ErrClientOutdated
+		}
+		return "", ErrNotConnected
 	}
 	if !c.client.IsLoggedIn() {
-		return errors.New("not logged in")
+		return "", ErrNotLoggedIn
+	}
+
+	// Parse recipient JID
+	jid, err := c.parseSendTarget(job.recipient)
+	if err != nil {
+		return "", err
+	}
+
+	// Create message
+	msg := job.content
+	if msg == nil {
+		msg, err = c.buildTextMessage(jid, job.message, job.opts)
+		if err != nil {
+			return "", err
+		}
+		msg = applyLinkPreview(msg, job.preview)
+	}
+
+	// Send message
+	resp, err := c.client.SendMessage(job.context(), jid, msg)
+	if err != nil {
+		return "", fmt.Errorf("failed to send message: %w", err)
+	}
+	c.receipts.sent(resp.ID, jid, resp.Timestamp)
+	if job.opts.CallbackURL != "" {
+		c.callbacks.add(resp.ID, job.opts.CallbackURL)
+	}
+
+	return resp.ID, nil
+}
+
+// buildTextMessage builds a text message for the recipient. Plain messages use
+// the simple conversation field; replies and mentions need an extended text
+// message to carry the context info.
+func (c *Client) buildTextMessage(recipient types.JID, message string, opts SendOptions) (*waProto.Message, error) {
+	if err := c.checkTextMessage(recipient, message, opts); err != nil {
+		return nil, err
+	}
+	mentioned, err := c.parseMentions(opts.Mentions)
+	if err != nil {
+		return nil, err
+	}
+	message = withMentionTokens(message, mentioned)
+
+	if opts.QuotedMessageID == "" {
+		if opts.QuotedSender != "" || opts.QuotedText != "" {
+			return nil, errors.New("quoted_sender and quoted_text require quoted_message_id")
+		}
+		if opts.EphemeralSeconds == 0 && len(mentioned) == 0 {
+			return &waProto.Message{
+				Conversation: proto.String(message),
+			}, nil
+		}
+		var contextInfo *waProto.ContextInfo
+		if opts.EphemeralSeconds > 0 {
+			contextInfo = ephemeralContext(nil, opts.EphemeralSeconds)
+		}
+		return &waProto.Message{
+			ExtendedTextMessage: &waProto.ExtendedTextMessage{
+				Text:        proto.String(message),
+				ContextInfo: mentionContext(contextInfo, mentioned),
+			},
+		}, nil
+	}
+
+	// Work out who wrote the quoted message
+	sender := recipient
+	if opts.QuotedSender != "" {
+		sender, err = c.parseRecipient(opts.QuotedSender)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quoted sender: %w", err)
+		}
+	} else if recipient.Server == types.GroupServer {
+		return nil, fmt.Errorf("%w: quoted_sender is required when replying in a group", ErrInvalidRecipient)
+	}
+
+	contextInfo := &waProto.ContextInfo{
+		StanzaID:    proto.String(opts.QuotedMessageID),
+		Participant: proto.String(sender.ToNonAD().String()),
+	}
+	if opts.QuotedText != "" {
+		contextInfo.QuotedMessage = &waProto.Message{
+			Conversation: proto.String(opts.QuotedText),
+		}
+	}
+	if opts.EphemeralSeconds > 0 {
+		contextInfo = ephemeralContext(contextInfo, opts.EphemeralSeconds)
 	}
+	contextInfo = mentionContext(contextInfo, mentioned)
+
+	return &waProto.Message{
+		ExtendedTextMessage: &waProto.ExtendedTextMessage{
+			Text:        proto.String(message),
+			ContextInfo: contextInfo,
+		},
+	}, nil
+}
 
-	// Format the recipient properly for JID
-	// Remove any potential + at the beginning
-	if len(recipient) > 0 && recipient[0] == '+' {
-		recipient = recipient[1:]
+// checkTextMessage rejects empty messages, bad options and, unless allowed,
+// messages to the client's own number
+func (c *Client) checkTextMessage(recipient types.JID, message string, opts SendOptions) error {
+	if strings.TrimSpace(message) == "" {
+		return fmt.Errorf("%w: message cannot be empty", ErrInvalidMessage)
 	}
-	
-	// Ensure the recipient has the right format: number@s.whatsapp.net
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	if _, err := c.parseMentions(opts.Mentions); err != nil {
+		return err
+	}
+	if !opts.AllowSelf && c.isOwnJID(recipient) {
+		return ErrSelfSend
+	}
+	return nil
+}
+
+// isOwnJID reports whether jid is the logged in account, by phone number or
+// lid
+func (c *Client) isOwnJID(jid types.JID) bool {
+	if c.client.Store.ID == nil {
+		return false
+	}
+	switch jid.Server {
+	case types.DefaultUserServer:
+		return jid.User == c.client.Store.ID.User
+	case types.HiddenUserServer:
+		return jid.User == c.client.Store.GetLID().User
+	}
+	return false
+}
+
+// parseRecipient turns a phone number, user JID or group JID into a JID
+// that messages can be sent to
+func (c *Client) parseRecipient(recipient string) (types.JID, error) {
+	// Bare phone numbers are normalized and get the user server
 	if !strings.Contains(recipient, "@") {
-		recipient = recipient + "@s.whatsapp.net"
+		number, err := NormalizePhoneNumber(recipient, c.defaultCountryCode)
+		if err != nil {
+			return types.EmptyJID, err
+		}
+		recipient = number + "@" + types.DefaultUserServer
 	}
 
 	// Parse recipient JID
 	jid, err := types.ParseJID(recipient)
 	if err != nil {
-		return fmt.Errorf("invalid recipient: %w", err)
+		return types.EmptyJID, fmt.Errorf("%w: %v", ErrInvalidRecipient, err)
 	}
-	if jid.Server != types.DefaultUserServer {
-		return fmt.Errorf("invalid recipient: not a user JID")
+	switch jid.Server {
+	case types.DefaultUserServer, types.HiddenUserServer, types.GroupServer:
+	default:
+		return types.EmptyJID, fmt.Errorf("%w: not a user, lid or group JID", ErrInvalidRecipient)
 	}
 	if jid.User == "" {
-		return fmt.Errorf("invalid recipient: empty user")
+		return types.EmptyJID, fmt.Errorf("%w: empty user", ErrInvalidRecipient)
 	}
 
-	// Create message
-	msg := &waProto.Message{
-		Conversation: proto.String(message),
+	return jid, nil
+}
+
+// Recipient JID types that can be passed as a hint with bare recipients
+const (
+	// JIDTypeAuto leaves the recipient as is: bare numbers are phone numbers
+	// and full JIDs keep their server
+	JIDTypeAuto = "auto"
+	// JIDTypePhone treats a bare recipient as a phone number (@s.whatsapp.net)
+	JIDTypePhone = "phone"
+	// JIDTypeLID treats a bare recipient as a hidden user ID (@lid)
+	JIDTypeLID = "lid"
+)
+
+// ApplyJIDType qualifies a bare recipient with the server for jidType.
+// Recipients that already carry a server are returned unchanged.
+func ApplyJIDType(recipient string, jidType string) (string, error) {
+	switch jidType {
+	case "", JIDTypeAuto, JIDTypePhone:
+		return recipient, nil
+	case JIDTypeLID:
+		if strings.Contains(recipient, "@") {
+			return recipient, nil
+		}
+		return strings.TrimPrefix(recipient, "+") + "@" + types.HiddenUserServer, nil
 	}
+	return "", fmt.Errorf("%w: unknown jid_type %q", ErrInvalidRecipient, jidType)
+}
 
-	// Send message
-	_, err = c.client.SendMessage(context.Background(), jid, msg)
+// parseGroupJID parses a group JID, rejecting anything not on the group server
+func parseGroupJID(groupJID string) (types.JID, error) {
+	jid, err := types.ParseJID(groupJID)
 	if err != nil {
-		return fmt.Errorf("failed to send message: %w", err)
+		return types.EmptyJID, fmt.Errorf("%w: %v", ErrInvalidGroupJID, err)
+	}
+	if jid.Server != types.GroupServer || jid.User == "" {
+		return types.EmptyJID, fmt.Errorf("%w: %s is not a group", ErrInvalidGroupJID, groupJID)
 	}
+	return jid, nil
+}
 
-	return nil
+// SendGroupMessage sends a WhatsApp message to a group and returns its message ID
+func (c *Client) SendGroupMessage(groupJID string, message string, opts SendOptions) (string, error) {
+	if _, err := parseGroupJID(groupJID); err != nil {
+		return "", err
+	}
+	return c.SendMessageWithOptions(groupJID, message, opts)
 }
 
 // GetState returns the current client state
@@ -324,9 +1001,16 @@ func (c *Client) GetState() ClientState {
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
 
+	return c.getStateLocked()
+}
+
+// getStateLocked builds the current client state. The caller must hold
+// c.mutex (read or write); taking a second read lock instead could deadlock
+// if a writer is waiting.
+func (c *Client) getStateLocked() ClientState {
 	connected := c.client.IsConnected()
 	loggedIn := c.client.IsLoggedIn()
-	
+
 	var status ClientStatus
 	if loggedIn {
 		status = StatusConnected
@@ -335,7 +1019,7 @@ func (c *Client) GetState() ClientState {
 	} else {
 		status = c.status
 	}
-	
+
 	// Get device info
 	var pushName, phoneNumber string
 	if c.deviceStore.PushName != "" {
@@ -345,15 +1029,44 @@ func (c *Client) GetState() ClientState {
 		phoneNumber = c.client.Store.ID.User
 	}
 
+	var lastPing *time.Time
+	if !c.lastPing.IsZero() {
+		at := c.lastPing
+		lastPing = &at
+	}
+
+	var connectedSince, lastConnected *time.Time
+	var uptime int64
+	if !c.connectedSince.IsZero() {
+		at := c.connectedSince
+		connectedSince = &at
+		uptime = int64(time.Since(at).Seconds())
+	}
+	if !c.lastConnected.IsZero() {
+		at := c.lastConnected
+		lastConnected = &at
+	}
+
 	return ClientState{
-		ID:              c.ID,
-		Status:          status,
-		LastActivity:    c.lastActivity,
-		Connected:       connected,
-		LoggedIn:        loggedIn,
-		PushName:        pushName,
-		PhoneNumber:     phoneNumber,
-		ConnectionError: c.connError,
+		ID:                c.ID,
+		Status:            status,
+		LastActivity:      c.lastActivity,
+		Connected:         connected,
+		LoggedIn:          loggedIn,
+		PushName:          pushName,
+		PhoneNumber:       phoneNumber,
+		ConnectionError:   c.connError,
+		ReconnectAttempts: c.reconnectAttempts,
+		LogoutReason:      c.logoutReason,
+		QRWebhook:         c.qrWebhook,
+		Labels:            slices.Clone(c.labels),
+		Metadata:          maps.Clone(c.metadata),
+		LastPing:          lastPing,
+		ConnectedSince:    connectedSince,
+		UptimeSeconds:     uptime,
+		LastConnectedAt:   lastConnected,
+		DeviceName:        c.customDeviceName,
+		Filters:           c.filters,
 	}
 }
 
@@ -362,40 +1075,144 @@ func (c *Client) Close() error {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
+	// Stop reconnecting and reporting
+	c.stopReconnectLocked()
+	c.stopConnectionNotifier()
+
+	// Stop handing out QR codes
+	if c.qrAttempt != nil {
+		c.qrAttempt.cancel()
+		c.qrAttempt = nil
+	}
+
 	// Disconnect if connected
 	if c.client.IsConnected() {
 		c.client.Disconnect()
 	}
 
-	// No need to close the container in newer versions
+	// Stop the send queue
+	c.queue.close(c)
+
+	// Release the database connection, only once
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	return c.closeStore()
+}
+
+// closeStore closes the client's database
+func (c *Client) closeStore() error {
+	if err := c.container.Close(); err != nil {
+		return fmt.Errorf("failed to close database: %w", err)
+	}
 	return nil
 }
 
 // SaveState saves the client state to a file
 func (c *Client) SaveState() error {
-	c.mutex.RLock()
-	defer c.mutex.RUnlock()
-
-	// Get current state
-	state := c.GetState()
+	// Saves of the same client run one at a time so an older state can't
+	// overwrite a newer one halfway through
+	c.saveMutex.Lock()
+	defer c.saveMutex.Unlock()
 
-	// Marshal to JSON
-	data, err := json.MarshalIndent(state, "", "  ")
+	c.mutex.RLock()
+	data, err := c.marshalStateLocked()
+	c.mutex.RUnlock()
 	if err != nil {
-		return fmt.Errorf("failed to marshal state: %w", err)
+		return err
 	}
 
-	// Write to file
+	// Write to a temporary file and rename it over the old one, so a crash
+	// mid-write leaves the previous state intact.
+	// The state holds the client's API key, so keep it private
 	stateFile := filepath.Join(c.dataDir, "state.json")
-	if err := os.WriteFile(stateFile, data, 0644); err != nil {
+	tmpFile := stateFile + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0600); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := os.Rename(tmpFile, stateFile); err != nil {
+		os.Remove(tmpFile)
 		return fmt.Errorf("failed to write state file: %w", err)
 	}
 
 	return nil
 }
 
+// marshalStateLocked encodes the state as it is saved to disk, including the
+// client's API key. Must hold c.mutex.
+func (c *Client) marshalStateLocked() ([]byte, error) {
+	state := c.getStateLocked()
+	state.APIKey = c.apiKey
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal state: %w", err)
+	}
+	return data, nil
+}
+
+// restoreState applies the persisted parts of a saved state to the client
+func (c *Client) restoreState(state ClientState) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.apiKey = state.APIKey
+	c.qrWebhook = state.QRWebhook
+	c.labels = state.Labels
+	c.metadata = state.Metadata
+	c.customDeviceName = state.DeviceName
+	if state.LastConnectedAt != nil {
+		c.lastConnected = *state.LastConnectedAt
+	}
+	c.filters = state.Filters
+}
+
 // handleEvent handles WhatsApp events
 func (c *Client) handleEvent(evt interface{}) {
+	// Store received messages before taking the lock so database writes
+	// don't hold up other operations on the client
+	if msg, ok := evt.(*events.Message); ok {
+		c.storeMessage(msg)
+		// Messages with media are forwarded once it's saved, so the webhook
+		// can point at it
+		if media, _, _ := downloadableMedia(msg.Message); media != nil && c.downloadMedia {
+			go func() {
+				info, data := c.saveMedia(msg)
+				c.notifyMessage(msg, c.webhookMedia(info, data))
+			}()
+		} else {
+			c.notifyMessage(msg, nil)
+		}
+	}
+	outdated := isOutdatedEvent(evt)
+	if outdated {
+		c.reportOutdated()
+	}
+	c.events.record(evt)
+	switch e := evt.(type) {
+	case *events.Receipt:
+		c.receipts.record(e)
+		c.notifyReceiptCallbacks(e)
+	case *events.Presence:
+		c.presences.record(e)
+		c.notifyPresence(e)
+	case *events.ChatPresence:
+		c.notifyChatPresence(e)
+	case *events.Message, *events.QR, *events.Connected, *events.Disconnected, *events.LoggedOut, *events.StreamReplaced, *events.TemporaryBan, *events.ConnectFailure:
+		// Handled above or below
+	default:
+		if c.debugEvents && !outdated {
+			c.logUnhandledEvent(evt)
+		}
+	}
+
+	// Report connection state changes once the lock below is released
+	switch evt.(type) {
+	case *events.Connected, *events.Disconnected, *events.LoggedOut, *events.StreamReplaced, *events.TemporaryBan, *events.ConnectFailure:
+		defer c.publishStatus()
+	}
+
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
@@ -403,7 +1220,7 @@ func (c *Client) handleEvent(evt interface{}) {
 	c.lastActivity = time.Now()
 
 	// Handle specific events
-	switch evt.(type) {
+	switch e := evt.(type) {
 	case *events.QR:
 		// For the QR event, we'll just send a notification
 		// The actual QR code data is handled by the GetQRChannel method
@@ -418,12 +1235,51 @@ func (c *Client) handleEvent(evt interface{}) {
 	case *events.Connected:
 		c.status = StatusConnected
 		c.connError = ""
+		c.reconnectAttempts = 0
+		c.logoutReason = ""
+		c.tempBan = nil
+		c.connFailure = nil
+		c.outdated.Store(false)
+		c.connectedSince = time.Now()
+		c.lastConnected = c.connectedSince
+		c.notifyConnection(StatusConnected)
 	case *events.Disconnected:
-		if c.client.IsLoggedIn() {
+		c.connectedSince = time.Time{}
+		if c.client.IsLoggedIn() || c.client.Store.ID != nil {
 			c.status = StatusDisconnected
+			c.startReconnectLocked()
 		} else {
 			c.status = StatusLoggedOut
 		}
+		c.notifyConnection(c.status)
+	case *events.LoggedOut:
+		// The session is gone for good, e.g. the device was removed from
+		// the phone, so there is nothing to reconnect to
+		c.stopReconnectLocked()
+		c.status = StatusLoggedOut
+		c.connectedSince = time.Time{}
+		c.lastConnected = time.Time{}
+		c.logoutReason = logoutReason(e)
+		c.connError = c.logoutReason
+		c.notifyConnection(StatusLoggedOut)
+		slog.Warn("Client was logged out by WhatsApp", "client", c.ID, "reason", c.logoutReason)
+	case *events.StreamReplaced:
+		// Another connection is using the same session. Reconnecting would
+		// just kick that one off, so leave it to the user.
+		c.stopReconnectLocked()
+		c.status = StatusError
+		c.connectedSince = time.Time{}
+		c.connError = "session was replaced by another connection"
+		slog.Warn("Client session replaced by another connection", "client", c.ID)
+	case *events.TemporaryBan:
+		c.recordTemporaryBanLocked(e)
+	case *events.ConnectFailure:
+		// whatsmeow doesn't reconnect after these, and neither do we
+		c.connFailure = &connectFailure{reason: e.Reason, message: e.Message, at: time.Now()}
+		c.status = StatusDisconnected
+		c.connectedSince = time.Time{}
+		c.connError = fmt.Sprintf("connection refused by WhatsApp: %s", e.Reason)
+		slog.Warn("WhatsApp refused the connection", "client", c.ID, "reason", e.Reason, "message", e.Message)
 	}
 
 	// Call the custom event handler if set
@@ -432,6 +1288,14 @@ func (c *Client) handleEvent(evt interface{}) {
 	}
 }
 
+// logoutReason describes why WhatsApp logged the client out
+func logoutReason(evt *events.LoggedOut) string {
+	if evt.OnConnect {
+		return fmt.Sprintf("logged out by WhatsApp: %s", evt.Reason)
+	}
+	return "logged out by WhatsApp: device was removed"
+}
+
 // SetEventHandler sets a custom event handler
 func (c *Client) SetEventHandler(handler func(interface{})) {
 	c.mutex.Lock()
@@ -0,0 +1,43 @@
+package whatsapp
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrClientLimit is returned when creating a client would go over
+// MaxClients
+var ErrClientLimit = errors.New("client limit reached")
+
+// ClientLimitError is the ErrClientLimit a create or import failed with,
+// with the numbers behind it
+type ClientLimitError struct {
+	Count int
+	Limit int
+}
+
+// Error implements error
+func (e *ClientLimitError) Error() string {
+	return fmt.Sprintf("%s: %d of %d clients in use", ErrClientLimit, e.Count, e.Limit)
+}
+
+// Unwrap makes errors.Is match ErrClientLimit
+func (e *ClientLimitError) Unwrap() error {
+	return ErrClientLimit
+}
+
+// checkClientLimit checks there's room for another client
+func (cm *ClientManager) checkClientLimit() error {
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+	return cm.checkClientLimitLocked(cm.options().MaxClients)
+}
+
+// checkClientLimitLocked checks there's room for another client under limit.
+// Only loaded clients count. Must hold cm.mutex.
+func (cm *ClientManager) checkClientLimitLocked(limit int) error {
+	if limit > 0 && len(cm.clients) >= limit {
+		return &ClientLimitError{Count: len(cm.clients), Limit: limit}
+	}
+	return nil
+}
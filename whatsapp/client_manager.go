@@ -1,132 +1,348 @@
 package whatsapp
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
 
+// ErrClientNotFound is returned when no client has the requested ID
+var ErrClientNotFound = errors.New("client not found")
+
+// ErrNoDefaultClient is returned when the default client is asked for but
+// none is set
+var ErrNoDefaultClient = errors.New("no default client set")
+
+// ErrClientBusy is returned when a client is already being deleted or reset
+var ErrClientBusy = errors.New("client is being deleted or reset")
+
 // ClientManager manages multiple WhatsApp clients
 type ClientManager struct {
-	clients       map[string]*Client
+	clients map[string]*Client
+	// busy holds the IDs of clients being deleted or reset. That work runs
+	// outside mutex, so the IDs are claimed to keep it from overlapping.
+	busy      map[string]struct{}
+	dataDir   string
+	opts      Options
+	optsMutex sync.RWMutex
+	mutex     sync.RWMutex
+	// defaultClient is guarded by its own lock so reading and persisting it
+	// doesn't contend with client map operations. When both locks are
+	// needed, defaultMutex is taken first.
 	defaultClient string
-	dataDir       string
-	mutex         sync.RWMutex
+	defaultMutex  sync.Mutex
 	saveTimer     *time.Timer
+	saveInterval  time.Duration
+	saver         *stateSaver
+	stop          chan struct{}
+	statusHub     *statusHub
+	outdated      outdatedFlag
+	startedAt     time.Time
 }
 
 // NewClientManager creates a new client manager
-func NewClientManager(dataDir string) *ClientManager {
+func NewClientManager(dataDir string, opts Options) *ClientManager {
 	// Create data directory if it doesn't exist
 	if err := os.MkdirAll(dataDir, 0755); err != nil {
 		panic(fmt.Sprintf("Failed to create data directory: %v", err))
 	}
 
 	cm := &ClientManager{
-		clients: make(map[string]*Client),
-		dataDir: dataDir,
+		clients:      make(map[string]*Client),
+		busy:         make(map[string]struct{}),
+		dataDir:      dataDir,
+		opts:         opts,
+		stop:         make(chan struct{}),
+		statusHub:    newStatusHub(),
+		startedAt:    time.Now(),
+		saveInterval: opts.StateSaveInterval,
+		saver:        newStateSaver(),
 	}
+	cm.opts.onStatusChange = cm.clientStatusChanged
+	cm.opts.onOutdated = cm.markOutdated
+
+	// Export client gauges
+	cm.registerMetrics()
 
 	// Set up periodic state saving
-	cm.saveTimer = time.AfterFunc(5*time.Minute, cm.periodicSave)
+	if cm.saveInterval > 0 {
+		cm.saveTimer = time.AfterFunc(cm.saveInterval, cm.periodicSave)
+	}
+
+	// Dispatch scheduled messages
+	go cm.runScheduler()
+
+	// Remove expired media
+	if opts.DownloadMedia && opts.MediaRetention > 0 {
+		go cm.runMediaCleanup()
+	}
+
+	// Catch connections that died without WhatsApp noticing
+	if opts.PingInterval > 0 {
+		go cm.runConnectionChecks(opts.PingInterval)
+	}
 
 	return cm
 }
 
-// periodicSave saves all client states periodically
+// periodicSave saves all client states periodically. The timer is only
+// rearmed once saving is done, so periodic saves never overlap.
 func (cm *ClientManager) periodicSave() {
-	defer cm.saveTimer.Reset(5 * time.Minute)
+	defer func() {
+		select {
+		case <-cm.stop:
+		default:
+			cm.saveTimer.Reset(cm.saveInterval)
+		}
+	}()
 
 	if err := cm.SaveClients(); err != nil {
-		fmt.Printf("Warning: Failed to save clients: %v\n", err)
+		slog.Warn("Failed to save clients", "error", err)
 	}
 }
 
-// LoadClients loads saved clients from disk
-func (cm *ClientManager) LoadClients() error {
-	cm.mutex.Lock()
-	defer cm.mutex.Unlock()
+// ReloadableOptions are the options that can change while clients are running
+type ReloadableOptions struct {
+	MessagesPerMinute int
+	MaxClients        int
+	WebhookURL        string
+	WebhookSecret     string
+}
+
+// Reconfigure applies new reloadable options to every running client and to
+// clients created from now on
+func (cm *ClientManager) Reconfigure(update ReloadableOptions) {
+	cm.optsMutex.Lock()
+	cm.opts.MessagesPerMinute = update.MessagesPerMinute
+	cm.opts.MaxClients = update.MaxClients
+	cm.opts.WebhookURL = update.WebhookURL
+	cm.opts.WebhookSecret = update.WebhookSecret
+	cm.optsMutex.Unlock()
+
+	for _, client := range cm.snapshotClients() {
+		client.SetRateLimit(update.MessagesPerMinute)
+		client.hook.Store(newWebhook(update.WebhookURL, update.WebhookSecret))
+	}
+}
+
+// options returns the options new clients are created with
+func (cm *ClientManager) options() Options {
+	cm.optsMutex.RLock()
+	defer cm.optsMutex.RUnlock()
+	return cm.opts
+}
+
+// loadConcurrency bounds how many saved clients are opened at once
+const loadConcurrency = 8
+
+// loadTimeout bounds how long LoadClients waits for saved clients to open
+const loadTimeout = 2 * time.Minute
 
+// LoadClients loads saved clients from disk. Clients are opened in parallel
+// and it returns once every client was attempted or loadTimeout passed;
+// connecting them continues in the background.
+func (cm *ClientManager) LoadClients() error {
 	// Read clients directory
 	entries, err := os.ReadDir(cm.dataDir)
 	if err != nil {
 		return fmt.Errorf("failed to read data directory: %w", err)
 	}
 
-	// Load each client
+	var ids []string
 	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue
+		if entry.IsDir() {
+			ids = append(ids, entry.Name())
 		}
+	}
 
-		clientID := entry.Name()
-		stateFile := filepath.Join(cm.dataDir, clientID, "state.json")
+	ctx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+	defer cancel()
+
+	// Track which clients finished so the slow ones can be reported
+	var finishedMutex sync.Mutex
+	finished := make(map[string]bool, len(ids))
+
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < min(loadConcurrency, len(ids)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range jobs {
+				cm.loadClient(ctx, id)
+				finishedMutex.Lock()
+				finished[id] = true
+				finishedMutex.Unlock()
+			}
+		}()
+	}
 
-		// Check if state file exists
-		if _, err := os.Stat(stateFile); os.IsNotExist(err) {
-			continue
+	go func() {
+		defer close(jobs)
+		for _, id := range ids {
+			select {
+			case jobs <- id:
+			case <-ctx.Done():
+				return
+			}
 		}
-
-		// Read state file
-		data, err := os.ReadFile(stateFile)
-		if err != nil {
-			fmt.Printf("Warning: Failed to read state file for %s: %v\n", clientID, err)
-			continue
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		finishedMutex.Lock()
+		var slow []string
+		for _, id := range ids {
+			if !finished[id] {
+				slow = append(slow, id)
+			}
 		}
+		finishedMutex.Unlock()
+		slog.Warn("Timed out loading clients", "timeout", loadTimeout, "clients", slow)
+	}
 
-		// Parse state
-		var state ClientState
-		if err := json.Unmarshal(data, &state); err != nil {
-			fmt.Printf("Warning: Failed to parse state for %s: %v\n", clientID, err)
-			continue
-		}
+	// Restore the default client once every client is loaded
+	cm.loadDefaultClient()
+
+	return nil
+}
+
+// loadClient opens one saved client and adds it to the manager. Clients that
+// only finish opening after ctx is done are closed again.
+func (cm *ClientManager) loadClient(ctx context.Context, clientID string) {
+	stateFile := filepath.Join(cm.dataDir, clientID, "state.json")
+
+	// Check if state file exists
+	if _, err := os.Stat(stateFile); os.IsNotExist(err) {
+		return
+	}
 
-		// Create client
-		client, err := NewClient(clientID, cm.dataDir)
-		if err != nil {
-			fmt.Printf("Warning: Failed to create client %s: %v\n", clientID, err)
-			continue
+	client, state, err := cm.openSavedClient(clientID)
+	if err != nil {
+		slog.Warn("Failed to load client", "client", clientID, "error", err)
+		return
+	}
+
+	// Add to map, unless loading already gave up on it
+	cm.mutex.Lock()
+	if ctx.Err() != nil {
+		cm.mutex.Unlock()
+		slog.Warn("Client finished loading after the timeout, closing it", "client", clientID)
+		if err := client.Close(); err != nil {
+			slog.Warn("Failed to close client", "client", clientID, "error", err)
 		}
+		return
+	}
+	cm.clients[clientID] = client
+	cm.mutex.Unlock()
+
+	// Connect if previously connected
+	if state.Status == StatusConnected || state.Connected {
+		go func() {
+			if err := client.Connect(); err != nil {
+				slog.Warn("Failed to connect client", "client", client.ID, "error", err)
+			}
+		}()
+	}
+}
 
-		// Add to map
-		cm.clients[clientID] = client
+// openSavedClient opens a client from its data directory and restores its
+// saved state
+func (cm *ClientManager) openSavedClient(clientID string) (*Client, ClientState, error) {
+	var state ClientState
 
-		// Check for default client flag
-		defaultFile := filepath.Join(cm.dataDir, "default_client")
-		if data, err := os.ReadFile(defaultFile); err == nil {
-			cm.defaultClient = string(data)
+	// Read state file
+	data, err := os.ReadFile(filepath.Join(cm.dataDir, clientID, "state.json"))
+	if err != nil {
+		return nil, state, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	// Parse state
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, state, fmt.Errorf("failed to parse state: %w", err)
+	}
+
+	// Create client, keeping its own device name if it has one
+	opts := cm.options()
+	if state.DeviceName != "" {
+		opts.DeviceName = state.DeviceName
+	}
+	opts.ExpectedPhone = state.PhoneNumber
+	client, err := NewClient(clientID, cm.dataDir, opts)
+	if err != nil {
+		return nil, state, fmt.Errorf("failed to create client: %w", err)
+	}
+
+	// Restore persisted settings
+	client.restoreState(state)
+
+	return client, state, nil
+}
+
+// loadDefaultClient reads the saved default client, dropping it if that
+// client wasn't loaded
+func (cm *ClientManager) loadDefaultClient() {
+	cm.defaultMutex.Lock()
+	defer cm.defaultMutex.Unlock()
+
+	defaultFile := filepath.Join(cm.dataDir, "default_client")
+	data, err := os.ReadFile(defaultFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Warn("Failed to read default client", "error", err)
 		}
+		return
+	}
 
-		// Connect if previously connected
-		if state.Status == StatusConnected || state.Connected {
-			go func(c *Client) {
-				if err := c.Connect(); err != nil {
-					fmt.Printf("Warning: Failed to connect client %s: %v\n", c.ID, err)
-				}
-			}(client)
+	id := strings.TrimSpace(string(data))
+	if !cm.hasClient(id) {
+		slog.Warn("Saved default client was not loaded, clearing it", "client", id)
+		cm.defaultClient = ""
+		if err := os.Remove(defaultFile); err != nil {
+			slog.Warn("Failed to remove default client file", "error", err)
 		}
+		return
 	}
 
-	return nil
+	cm.defaultClient = id
 }
 
-// SaveClients saves all client states to disk
-func (cm *ClientManager) SaveClients() error {
+// hasClient reports whether a client with the given ID is loaded
+func (cm *ClientManager) hasClient(id string) bool {
 	cm.mutex.RLock()
 	defer cm.mutex.RUnlock()
 
+	_, exists := cm.clients[id]
+	return exists
+}
+
+// SaveClients saves all client states to disk
+func (cm *ClientManager) SaveClients() error {
 	// Save each client
-	for _, client := range cm.clients {
+	for _, client := range cm.snapshotClients() {
 		if err := client.SaveState(); err != nil {
-			fmt.Printf("Warning: Failed to save state for %s: %v\n", client.ID, err)
+			slog.Warn("Failed to save state", "client", client.ID, "error", err)
 		}
 	}
 
 	// Save default client
+	cm.defaultMutex.Lock()
+	defer cm.defaultMutex.Unlock()
 	if cm.defaultClient != "" {
 		defaultFile := filepath.Join(cm.dataDir, "default_client")
 		if err := os.WriteFile(defaultFile, []byte(cm.defaultClient), 0644); err != nil {
@@ -137,60 +353,98 @@ func (cm *ClientManager) SaveClients() error {
 	return nil
 }
 
-// CreateClient creates a new WhatsApp client
-func (cm *ClientManager) CreateClient(id string) (*Client, error) {
+// CreateClient creates a new WhatsApp client. deviceName overrides the
+// configured device name for this client when set.
+func (cm *ClientManager) CreateClient(id string, deviceName string) (*Client, error) {
+	client, first, err := cm.addClient(id, deviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set as default if first client
+	if first {
+		cm.defaultMutex.Lock()
+		if cm.defaultClient == "" {
+			cm.defaultClient = id
+		}
+		cm.defaultMutex.Unlock()
+	}
+
+	// Save state
+	if err := client.SaveState(); err != nil {
+		slog.Warn("Failed to save initial state", "client", id, "error", err)
+	}
+
+	cm.publishClientState(client.GetState())
+
+	return client, nil
+}
+
+// addClient creates a client and adds it to the map. It also reports whether
+// it is the only client.
+func (cm *ClientManager) addClient(id string, deviceName string) (*Client, bool, error) {
 	cm.mutex.Lock()
 	defer cm.mutex.Unlock()
 
-	// Check if ID is empty
-	if id == "" {
-		return nil, errors.New("client ID cannot be empty")
+	// Check the ID is safe to use as a directory name
+	if err := ValidateClientID(id); err != nil {
+		return nil, false, err
 	}
 
 	// Check if ID already exists
 	if _, exists := cm.clients[id]; exists {
-		return nil, fmt.Errorf("client with ID %s already exists", id)
+		return nil, false, fmt.Errorf("%w: %s", ErrClientExists, id)
+	}
+	if _, busy := cm.busy[id]; busy {
+		return nil, false, fmt.Errorf("%w: %s", ErrClientBusy, id)
+	}
+
+	opts := cm.options()
+	if err := cm.checkClientLimitLocked(opts.MaxClients); err != nil {
+		return nil, false, err
 	}
 
 	// Create client
-	client, err := NewClient(id, cm.dataDir)
+	if deviceName != "" {
+		opts.DeviceName = deviceName
+	}
+	client, err := NewClient(id, cm.dataDir, opts)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
+	client.customDeviceName = deviceName
 
-	// Add to map
-	cm.clients[id] = client
-
-	// Set as default if first client
-	if len(cm.clients) == 1 && cm.defaultClient == "" {
-		cm.defaultClient = id
+	// Give the client its own API key
+	apiKey, err := GenerateAPIKey()
+	if err != nil {
+		client.Close()
+		return nil, false, err
 	}
+	client.apiKey = apiKey
 
-	// Save state
-	if err := client.SaveState(); err != nil {
-		fmt.Printf("Warning: Failed to save initial state for %s: %v\n", id, err)
-	}
+	// Add to map
+	cm.clients[id] = client
 
-	return client, nil
+	return client, len(cm.clients) == 1, nil
 }
 
 // GetClient gets a client by ID
 func (cm *ClientManager) GetClient(id string) (*Client, error) {
-	cm.mutex.RLock()
-	defer cm.mutex.RUnlock()
-
 	// If ID is empty, use default client
 	if id == "" {
-		if cm.defaultClient == "" {
-			return nil, errors.New("no default client set")
+		id = cm.GetDefaultClient()
+		if id == "" {
+			return nil, ErrNoDefaultClient
 		}
-		id = cm.defaultClient
 	}
 
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+
 	// Check if client exists
 	client, exists := cm.clients[id]
 	if !exists {
-		return nil, fmt.Errorf("client %s not found", id)
+		return nil, fmt.Errorf("%w: %s", ErrClientNotFound, id)
 	}
 
 	return client, nil
@@ -198,70 +452,141 @@ func (cm *ClientManager) GetClient(id string) (*Client, error) {
 
 // DeleteClient deletes a client
 func (cm *ClientManager) DeleteClient(id string) error {
-	cm.mutex.Lock()
-	defer cm.mutex.Unlock()
-
-	// Check if client exists
-	client, exists := cm.clients[id]
-	if !exists {
-		return fmt.Errorf("client %s not found", id)
+	err := cm.removeClient(id)
+	if errors.Is(err, ErrClientNotFound) || errors.Is(err, ErrClientBusy) {
+		return err
 	}
 
-	// First, manually disconnect and logout to ensure proper cleanup
-	if client.client != nil {
-		// Disconnect if connected
-		if client.client.IsConnected() {
-			client.client.Disconnect()
-		}
-		
-		// Try to logout if needed
-		if client.client.IsLoggedIn() {
-			_ = client.client.Logout()
-		}
-	}
+	// If it was the default client, move the default to another client. This
+	// is needed even when removing its data failed, since it's already gone
+	// from the map.
+	cm.replaceDefaultClient(id)
+	cm.publishClientRemoved(id)
 
-	// Close client
-	if err := client.Close(); err != nil {
+	return err
+}
+
+// removeClient logs a client out, removes it from the map and deletes its
+// data. Only the map update holds cm.mutex; logging out and removing files
+// can be slow and would otherwise block every other client lookup.
+func (cm *ClientManager) removeClient(id string) error {
+	client, err := cm.claimClient(id)
+	if err != nil {
 		return err
 	}
+	defer cm.releaseClient(id)
+
+	// Unlink the device from the phone while the connection is still up.
+	// A client that can't reach WhatsApp is removed anyway.
+	if err := client.Logout(); err != nil {
+		slog.Warn("Failed to log out client before deleting it", "client", id, "error", err)
+	}
 
 	// Remove from map
+	cm.mutex.Lock()
 	delete(cm.clients, id)
+	cm.mutex.Unlock()
+	forgetClientMetrics(id)
 
-	// If it was the default client, unset default
-	if cm.defaultClient == id {
-		cm.defaultClient = ""
-		
-		// If there are other clients, set the first one as default
-		if len(cm.clients) > 0 {
-			for newDefault := range cm.clients {
-				cm.defaultClient = newDefault
-				break
-			}
-		}
+	// Close client, releasing the database so no handles keep the files open
+	if err := client.Close(); err != nil {
+		return err
 	}
 
-	// Wait a moment to ensure all handles are closed
-	time.Sleep(1 * time.Second)
-
 	// Remove client directory
 	clientDir := filepath.Join(cm.dataDir, id)
-	if err := os.RemoveAll(clientDir); err != nil {
-		// Log but don't return error - we've already removed from memory
-		fmt.Printf("Warning: Failed to remove client directory for %s: %v\n", id, err)
+	if err := removeAllWithRetry(clientDir); err != nil {
+		return fmt.Errorf("client %s was closed but its data could not be removed: %w", id, err)
+	}
+
+	// With Postgres the session lives in the shared database rather than
+	// the client directory. Left behind, it would be picked up again by a
+	// new client with the same ID.
+	if opts := cm.options(); opts.DBDriver == DriverPostgres {
+		if err := dropPostgresSchema(id, opts.DBDSN); err != nil {
+			return fmt.Errorf("client %s was closed but its session could not be removed: %w", id, err)
+		}
 	}
 
 	return nil
 }
 
-// SetDefaultClient sets the default client
-func (cm *ClientManager) SetDefaultClient(id string) error {
+// claimClient looks up a client and marks it busy, so no other delete or
+// reset starts on it and its ID can't be reused until releaseClient
+func (cm *ClientManager) claimClient(id string) (*Client, error) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	client, exists := cm.clients[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrClientNotFound, id)
+	}
+	if _, busy := cm.busy[id]; busy {
+		return nil, fmt.Errorf("%w: %s", ErrClientBusy, id)
+	}
+	cm.busy[id] = struct{}{}
+
+	return client, nil
+}
+
+// releaseClient ends a claim taken with claimClient
+func (cm *ClientManager) releaseClient(id string) {
 	cm.mutex.Lock()
 	defer cm.mutex.Unlock()
 
+	delete(cm.busy, id)
+}
+
+// removeRetries is how many times removing a client directory is attempted
+const removeRetries = 5
+
+// removeRetryDelay is the wait before the first retry, doubled each time
+const removeRetryDelay = 50 * time.Millisecond
+
+// removeAllWithRetry removes a directory, retrying with backoff because some
+// platforms (Windows) keep files locked for a moment after they're closed
+func removeAllWithRetry(dir string) error {
+	delay := removeRetryDelay
+	var err error
+	for attempt := 1; attempt <= removeRetries; attempt++ {
+		if err = os.RemoveAll(dir); err == nil {
+			return nil
+		}
+		if attempt < removeRetries {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+	return fmt.Errorf("failed to remove %s: %w", dir, err)
+}
+
+// replaceDefaultClient moves the default to any remaining client, or unsets
+// it, if the deleted client was the default
+func (cm *ClientManager) replaceDefaultClient(deleted string) {
+	cm.defaultMutex.Lock()
+	defer cm.defaultMutex.Unlock()
+
+	if cm.defaultClient != deleted {
+		return
+	}
+	cm.defaultClient = ""
+
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+	for id := range cm.clients {
+		cm.defaultClient = id
+		break
+	}
+}
+
+// SetDefaultClient sets the default client
+func (cm *ClientManager) SetDefaultClient(id string) error {
+	cm.defaultMutex.Lock()
+	defer cm.defaultMutex.Unlock()
+
 	// Check if client exists
-	if _, exists := cm.clients[id]; !exists {
-		return fmt.Errorf("client %s not found", id)
+	if !cm.hasClient(id) {
+		return fmt.Errorf("%w: %s", ErrClientNotFound, id)
 	}
 
 	// Set as default
@@ -278,10 +603,24 @@ func (cm *ClientManager) SetDefaultClient(id string) error {
 
 // GetDefaultClient gets the default client ID
 func (cm *ClientManager) GetDefaultClient() string {
+	cm.defaultMutex.Lock()
+	defer cm.defaultMutex.Unlock()
+
+	return cm.defaultClient
+}
+
+// ClientIDs returns the IDs of all clients, sorted
+func (cm *ClientManager) ClientIDs() []string {
 	cm.mutex.RLock()
 	defer cm.mutex.RUnlock()
 
-	return cm.defaultClient
+	ids := make([]string, 0, len(cm.clients))
+	for id := range cm.clients {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return ids
 }
 
 // ListClients lists all clients
@@ -295,30 +634,55 @@ func (cm *ClientManager) ListClients() []ClientState {
 		states = append(states, client.GetState())
 	}
 
+	// Map order is random; keep the list stable between calls
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].ID < states[j].ID
+	})
+
 	return states
 }
 
-// Close closes all clients
-func (cm *ClientManager) Close() {
+// snapshotClients returns the current clients so they can be worked on
+// without holding the manager lock
+func (cm *ClientManager) snapshotClients() []*Client {
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+
+	clients := make([]*Client, 0, len(cm.clients))
+	for _, client := range cm.clients {
+		clients = append(clients, client)
+	}
+	return clients
+}
+
+// Close closes all clients and returns any errors closing them
+func (cm *ClientManager) Close() error {
 	cm.mutex.Lock()
 	defer cm.mutex.Unlock()
 
-	// Stop save timer
+	// Stop save timers
 	if cm.saveTimer != nil {
 		cm.saveTimer.Stop()
 	}
+	cm.saver.close()
+
+	// Stop background jobs
+	close(cm.stop)
 
 	// Save all clients before closing
+	var errs []error
 	for _, client := range cm.clients {
 		if err := client.SaveState(); err != nil {
-			fmt.Printf("Warning: Failed to save state for %s: %v\n", client.ID, err)
+			slog.Warn("Failed to save state", "client", client.ID, "error", err)
 		}
-		
+
 		if err := client.Close(); err != nil {
-			fmt.Printf("Warning: Failed to close client %s: %v\n", client.ID, err)
+			errs = append(errs, fmt.Errorf("failed to close client %s: %w", client.ID, err))
 		}
 	}
 
 	// Clear map
 	cm.clients = make(map[string]*Client)
+
+	return errors.Join(errs...)
 }
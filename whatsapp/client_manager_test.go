@@ -0,0 +1,178 @@
+package whatsapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// newTestManager creates a client manager over dataDir that is closed when
+// the test ends
+func newTestManager(t *testing.T, dataDir string) *ClientManager {
+	t.Helper()
+
+	cm := NewClientManager(dataDir, Options{})
+	t.Cleanup(func() {
+		cm.Close()
+	})
+	return cm
+}
+
+// writeSavedClients creates the data directories of clients saved before a
+// restart
+func writeSavedClients(t *testing.T, dataDir string, ids ...string) {
+	t.Helper()
+
+	for _, id := range ids {
+		state, err := json.Marshal(ClientState{ID: id, Status: StatusLoggedOut})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Join(dataDir, id), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dataDir, id, "state.json"), state, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadClientsRestoresDefault(t *testing.T) {
+	tests := []struct {
+		name        string
+		clients     []string
+		saved       string
+		wantDefault string
+		wantFile    bool
+	}{
+		{name: "first client", clients: []string{"alpha", "bravo", "charlie"}, saved: "alpha", wantDefault: "alpha", wantFile: true},
+		{name: "middle client", clients: []string{"alpha", "bravo", "charlie"}, saved: "bravo", wantDefault: "bravo", wantFile: true},
+		{name: "last client", clients: []string{"alpha", "bravo", "charlie"}, saved: "charlie", wantDefault: "charlie", wantFile: true},
+		{name: "created out of order", clients: []string{"zulu", "alpha", "mike"}, saved: "mike", wantDefault: "mike", wantFile: true},
+		{name: "saved with a newline", clients: []string{"alpha", "bravo"}, saved: "bravo\n", wantDefault: "bravo", wantFile: true},
+		{name: "client no longer exists", clients: []string{"alpha", "bravo"}, saved: "deleted", wantDefault: "", wantFile: false},
+		{name: "no saved default", clients: []string{"alpha", "bravo"}, wantDefault: "", wantFile: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dataDir := t.TempDir()
+			writeSavedClients(t, dataDir, tt.clients...)
+			defaultFile := filepath.Join(dataDir, "default_client")
+			if tt.saved != "" {
+				if err := os.WriteFile(defaultFile, []byte(tt.saved), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cm := newTestManager(t, dataDir)
+			if err := cm.LoadClients(); err != nil {
+				t.Fatalf("LoadClients: %v", err)
+			}
+
+			if got := len(cm.ClientIDs()); got != len(tt.clients) {
+				t.Fatalf("loaded %d clients, want %d", got, len(tt.clients))
+			}
+			if got := cm.GetDefaultClient(); got != tt.wantDefault {
+				t.Errorf("default client = %q, want %q", got, tt.wantDefault)
+			}
+			if _, err := os.Stat(defaultFile); (err == nil) != tt.wantFile {
+				t.Errorf("default_client file exists = %v, want %v", err == nil, tt.wantFile)
+			}
+		})
+	}
+}
+
+// Run with -race: default client reads and writes go through their own lock,
+// alongside client map operations
+func TestDefaultClientConcurrentSwitching(t *testing.T) {
+	dataDir := t.TempDir()
+	cm := newTestManager(t, dataDir)
+
+	ids := []string{"alpha", "bravo", "charlie", "delta"}
+	for _, id := range ids {
+		if _, err := cm.CreateClient(id, ""); err != nil {
+			t.Fatalf("CreateClient(%q): %v", id, err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(4)
+		go func(i int) {
+			defer wg.Done()
+			if err := cm.SetDefaultClient(ids[i%len(ids)]); err != nil {
+				t.Errorf("SetDefaultClient: %v", err)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			if id := cm.GetDefaultClient(); id == "" {
+				t.Error("default client is unset")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := cm.GetClient(""); err != nil {
+				t.Errorf("GetClient(\"\"): %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			cm.ListClients()
+		}()
+	}
+
+	// Clients come and go while the default changes
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 5; i++ {
+			id := fmt.Sprintf("temp%d", i)
+			if _, err := cm.CreateClient(id, ""); err != nil {
+				t.Errorf("CreateClient(%q): %v", id, err)
+				continue
+			}
+			if err := cm.DeleteClient(id); err != nil {
+				t.Errorf("DeleteClient(%q): %v", id, err)
+			}
+		}
+	}()
+	wg.Wait()
+
+	// The saved default matches the one in memory
+	saved, err := os.ReadFile(filepath.Join(dataDir, "default_client"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cm.GetDefaultClient(); string(saved) != got {
+		t.Errorf("saved default = %q, in memory %q", saved, got)
+	}
+}
+
+func TestListClientsSortedByID(t *testing.T) {
+	cm := newTestManager(t, t.TempDir())
+
+	for _, id := range []string{"mike", "alpha", "zulu", "Bravo", "charlie-2", "charlie_1"} {
+		if _, err := cm.CreateClient(id, ""); err != nil {
+			t.Fatalf("CreateClient(%q): %v", id, err)
+		}
+	}
+	want := []string{"Bravo", "alpha", "charlie-2", "charlie_1", "mike", "zulu"}
+
+	// Map iteration order changes between calls, the list must not
+	for i := 0; i < 20; i++ {
+		states := cm.ListClients()
+		got := make([]string, len(states))
+		for j, state := range states {
+			got[j] = state.ID
+		}
+		if !slices.Equal(got, want) {
+			t.Fatalf("call %d: ListClients order = %v, want %v", i+1, got, want)
+		}
+	}
+}
@@ -0,0 +1,422 @@
+package whatsapp
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestParseRecipientAcceptsGroups(t *testing.T) {
+	c := &Client{}
+
+	tests := []struct {
+		name      string
+		recipient string
+		want      types.JID
+		wantErr   bool
+	}{
+		{
+			name:      "group JID",
+			recipient: "120363025246125486@g.us",
+			want:      types.NewJID("120363025246125486", types.GroupServer),
+		},
+		{
+			name:      "legacy group JID",
+			recipient: "6281234567890-1600000000@g.us",
+			want:      types.NewJID("6281234567890-1600000000", types.GroupServer),
+		},
+		{
+			name:      "user JID",
+			recipient: "6281234567890@s.whatsapp.net",
+			want:      types.NewJID("6281234567890", types.DefaultUserServer),
+		},
+		{
+			name:      "phone number",
+			recipient: "+62 812-3456-7890",
+			want:      types.NewJID("6281234567890", types.DefaultUserServer),
+		},
+		{name: "group without ID", recipient: "@g.us", wantErr: true},
+		{name: "broadcast", recipient: "status@broadcast", wantErr: true},
+		{name: "newsletter", recipient: "120363025246125486@newsletter", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jid, err := c.parseRecipient(tt.recipient)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidRecipient) {
+					t.Fatalf("parseRecipient(%q) error = %v, want ErrInvalidRecipient", tt.recipient, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseRecipient(%q) error = %v", tt.recipient, err)
+			}
+			if jid != tt.want {
+				t.Errorf("parseRecipient(%q) = %s, want %s", tt.recipient, jid, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGroupJID(t *testing.T) {
+	tests := []struct {
+		name     string
+		groupJID string
+		wantErr  bool
+	}{
+		{name: "group", groupJID: "120363025246125486@g.us"},
+		{name: "user JID", groupJID: "6281234567890@s.whatsapp.net", wantErr: true},
+		{name: "phone number", groupJID: "6281234567890", wantErr: true},
+		{name: "group without ID", groupJID: "@g.us", wantErr: true},
+		{name: "empty", groupJID: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jid, err := parseGroupJID(tt.groupJID)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidGroupJID) {
+					t.Fatalf("parseGroupJID(%q) error = %v, want ErrInvalidGroupJID", tt.groupJID, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseGroupJID(%q) error = %v", tt.groupJID, err)
+			}
+			if jid.Server != types.GroupServer {
+				t.Errorf("parseGroupJID(%q) server = %q, want %q", tt.groupJID, jid.Server, types.GroupServer)
+			}
+		})
+	}
+}
+
+func TestSendGroupMessageRejectsUsers(t *testing.T) {
+	c := &Client{}
+
+	_, err := c.SendGroupMessage("6281234567890@s.whatsapp.net", "hello", SendOptions{})
+	if !errors.Is(err, ErrInvalidGroupJID) {
+		t.Fatalf("SendGroupMessage to a user error = %v, want ErrInvalidGroupJID", err)
+	}
+}
+
+// newTestClient creates a client whose store lives in a temporary directory.
+// It isn't connected to WhatsApp.
+func newTestClient(t *testing.T, opts Options) *Client {
+	t.Helper()
+
+	c, err := NewClient("test", t.TempDir(), opts)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() {
+		c.Close()
+	})
+	return c
+}
+
+func TestBuildTextMessageQuotes(t *testing.T) {
+	c := newTestClient(t, Options{})
+	user := types.NewJID("6281234567890", types.DefaultUserServer)
+	group := types.NewJID("120363025246125486", types.GroupServer)
+
+	tests := []struct {
+		name            string
+		recipient       types.JID
+		opts            SendOptions
+		wantPlain       bool
+		wantParticipant string
+		wantQuotedText  string
+		wantErr         bool
+	}{
+		{
+			name:      "plain",
+			recipient: user,
+			wantPlain: true,
+		},
+		{
+			name:            "reply with quoted text",
+			recipient:       user,
+			opts:            SendOptions{QuotedMessageID: "3EB0C767D26A8B4A", QuotedText: "original"},
+			wantParticipant: "6281234567890@s.whatsapp.net",
+			wantQuotedText:  "original",
+		},
+		{
+			name:            "reply without quoted text",
+			recipient:       user,
+			opts:            SendOptions{QuotedMessageID: "3EB0C767D26A8B4A"},
+			wantParticipant: "6281234567890@s.whatsapp.net",
+		},
+		{
+			name:            "reply in a group",
+			recipient:       group,
+			opts:            SendOptions{QuotedMessageID: "3EB0C767D26A8B4A", QuotedSender: "6289876543210"},
+			wantParticipant: "6289876543210@s.whatsapp.net",
+		},
+		{
+			name:      "reply in a group without sender",
+			recipient: group,
+			opts:      SendOptions{QuotedMessageID: "3EB0C767D26A8B4A"},
+			wantErr:   true,
+		},
+		{
+			name:      "quoted text without message ID",
+			recipient: user,
+			opts:      SendOptions{QuotedText: "original"},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := c.buildTextMessage(tt.recipient, "hello", tt.opts)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("buildTextMessage succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildTextMessage: %v", err)
+			}
+
+			if tt.wantPlain {
+				if msg.GetConversation() != "hello" || msg.GetExtendedTextMessage() != nil {
+					t.Fatalf("plain message = %v, want a conversation", msg)
+				}
+				return
+			}
+
+			ext := msg.GetExtendedTextMessage()
+			if ext.GetText() != "hello" {
+				t.Errorf("text = %q, want %q", ext.GetText(), "hello")
+			}
+			info := ext.GetContextInfo()
+			if info.GetStanzaID() != tt.opts.QuotedMessageID {
+				t.Errorf("StanzaID = %q, want %q", info.GetStanzaID(), tt.opts.QuotedMessageID)
+			}
+			if info.GetParticipant() != tt.wantParticipant {
+				t.Errorf("Participant = %q, want %q", info.GetParticipant(), tt.wantParticipant)
+			}
+			if got := info.GetQuotedMessage().GetConversation(); got != tt.wantQuotedText {
+				t.Errorf("quoted text = %q, want %q", got, tt.wantQuotedText)
+			}
+		})
+	}
+}
+
+// Run with -race: SaveState, sends and GetState all take the client mutex
+func TestSaveStateConcurrentWithSends(t *testing.T) {
+	c := newTestClient(t, Options{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			if err := c.SaveState(); err != nil {
+				t.Errorf("SaveState: %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			// The client isn't connected, so the send fails once queued
+			if _, err := c.SendMessage("6281234567890", "hello"); !errors.Is(err, ErrNotConnected) {
+				t.Errorf("SendMessage error = %v, want ErrNotConnected", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			c.GetState()
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("SaveState and SendMessage deadlocked")
+	}
+}
+
+func TestValidateClientID(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		wantErr bool
+	}{
+		{name: "letters and digits", id: "client1"},
+		{name: "dash and underscore", id: "my-client_2"},
+		{name: "longest allowed", id: strings.Repeat("a", maxClientIDLength)},
+		{name: "empty", id: "", wantErr: true},
+		{name: "too long", id: strings.Repeat("a", maxClientIDLength+1), wantErr: true},
+		{name: "parent directory", id: "..", wantErr: true},
+		{name: "traversal", id: "../../etc", wantErr: true},
+		{name: "nested traversal", id: "a/../../b", wantErr: true},
+		{name: "windows traversal", id: `..\..\windows`, wantErr: true},
+		{name: "absolute path", id: "/etc/passwd", wantErr: true},
+		{name: "windows absolute path", id: `C:\Windows`, wantErr: true},
+		{name: "dot", id: ".", wantErr: true},
+		{name: "hidden", id: ".client", wantErr: true},
+		{name: "space", id: "my client", wantErr: true},
+		{name: "null byte", id: "client\x00", wantErr: true},
+		{name: "fullwidth dots and slash", id: "\uff0e\uff0e\uff0fetc", wantErr: true},
+		{name: "one dot leader", id: "\u2024\u2024", wantErr: true},
+		{name: "unicode letters", id: "cli\u00e9nt", wantErr: true},
+		{name: "cyrillic lookalike", id: "\u0441lient", wantErr: true},
+		{name: "zero width space", id: "client\u200b", wantErr: true},
+		{name: "right to left override", id: "client\u202e", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateClientID(tt.id)
+			if tt.wantErr && !errors.Is(err, ErrInvalidClientID) {
+				t.Errorf("ValidateClientID(%q) error = %v, want ErrInvalidClientID", tt.id, err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("ValidateClientID(%q) error = %v", tt.id, err)
+			}
+		})
+	}
+}
+
+func TestNewClientRejectsTraversal(t *testing.T) {
+	root := t.TempDir()
+	dataDir := filepath.Join(root, "data")
+
+	for _, id := range []string{"../escaped", "/tmp/escaped", "\uff0e\uff0e\uff0fescaped"} {
+		if _, err := NewClient(id, dataDir, Options{}); !errors.Is(err, ErrInvalidClientID) {
+			t.Errorf("NewClient(%q) error = %v, want ErrInvalidClientID", id, err)
+		}
+	}
+
+	// Nothing may be created, inside the data directory or outside it
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("rejected IDs created %d entries next to the data directory", len(entries))
+	}
+}
+
+func TestRecipientJIDTypes(t *testing.T) {
+	c := &Client{}
+
+	tests := []struct {
+		name      string
+		recipient string
+		jidType   string
+		want      types.JID
+		wantErr   bool
+	}{
+		{
+			name:      "phone number",
+			recipient: "6281234567890",
+			want:      types.NewJID("6281234567890", types.DefaultUserServer),
+		},
+		{
+			name:      "phone number with phone hint",
+			recipient: "+6281234567890",
+			jidType:   JIDTypePhone,
+			want:      types.NewJID("6281234567890", types.DefaultUserServer),
+		},
+		{
+			name:      "phone JID",
+			recipient: "6281234567890@s.whatsapp.net",
+			jidType:   JIDTypeAuto,
+			want:      types.NewJID("6281234567890", types.DefaultUserServer),
+		},
+		{
+			name:      "lid JID",
+			recipient: "123456789012345@lid",
+			want:      types.NewJID("123456789012345", types.HiddenUserServer),
+		},
+		{
+			name:      "bare lid with lid hint",
+			recipient: "123456789012345",
+			jidType:   JIDTypeLID,
+			want:      types.NewJID("123456789012345", types.HiddenUserServer),
+		},
+		{
+			name:      "lid JID with lid hint",
+			recipient: "123456789012345@lid",
+			jidType:   JIDTypeLID,
+			want:      types.NewJID("123456789012345", types.HiddenUserServer),
+		},
+		{
+			name:      "phone JID keeps its server with lid hint",
+			recipient: "6281234567890@s.whatsapp.net",
+			jidType:   JIDTypeLID,
+			want:      types.NewJID("6281234567890", types.DefaultUserServer),
+		},
+		{name: "empty lid", recipient: "@lid", wantErr: true},
+		{name: "unknown hint", recipient: "6281234567890", jidType: "email", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recipient, err := ApplyJIDType(tt.recipient, tt.jidType)
+			var jid types.JID
+			if err == nil {
+				jid, err = c.parseRecipient(recipient)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidRecipient) {
+					t.Fatalf("error = %v, want ErrInvalidRecipient", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if jid != tt.want {
+				t.Errorf("JID = %s, want %s", jid, tt.want)
+			}
+		})
+	}
+}
+
+func TestSendMessageValidation(t *testing.T) {
+	c := newTestClient(t, Options{})
+	own := types.NewJID("6281234567890", types.DefaultUserServer)
+	c.client.Store.ID = &own
+	c.client.Store.LID = types.NewJID("123456789012345", types.HiddenUserServer)
+
+	tests := []struct {
+		name      string
+		recipient string
+		message   string
+		opts      SendOptions
+		wantErr   error
+	}{
+		{name: "empty message", recipient: "6289876543210", message: "", wantErr: ErrInvalidMessage},
+		{name: "blank message", recipient: "6289876543210", message: " \n\t ", wantErr: ErrInvalidMessage},
+		{name: "own number", recipient: "6281234567890", message: "hello", wantErr: ErrSelfSend},
+		{name: "own JID", recipient: "6281234567890@s.whatsapp.net", message: "hello", wantErr: ErrSelfSend},
+		{name: "own lid", recipient: "123456789012345@lid", message: "hello", wantErr: ErrSelfSend},
+		// Valid messages are queued and fail there, the client isn't connected
+		{name: "own number allowed", recipient: "6281234567890", message: "hello", opts: SendOptions{AllowSelf: true}, wantErr: ErrNotConnected},
+		{name: "other number", recipient: "6289876543210", message: "hello", wantErr: ErrNotConnected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := c.SendMessageWithOptions(tt.recipient, tt.message, tt.opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("SendMessageWithOptions error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
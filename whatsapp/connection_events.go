@@ -0,0 +1,82 @@
+package whatsapp
+
+import (
+	"sync"
+	"time"
+)
+
+// connectionDebounce is how long a connection state has to hold before it is
+// reported, so a quick disconnect and reconnect doesn't fire any webhooks
+const connectionDebounce = 5 * time.Second
+
+// ConnectionEvent is the data of a connection state webhook
+type ConnectionEvent struct {
+	Status         ClientStatus `json:"status"`
+	PreviousStatus ClientStatus `json:"previous_status"`
+	ChangedAt      time.Time    `json:"changed_at"`
+}
+
+// connectionNotifier reports debounced connection state changes
+type connectionNotifier struct {
+	mutex     sync.Mutex
+	reported  ClientStatus
+	pending   ClientStatus
+	changedAt time.Time
+	timer     *time.Timer
+}
+
+// notifyConnection records a connection state change and reports it once it
+// has held for connectionDebounce
+func (c *Client) notifyConnection(status ClientStatus) {
+	if c.hook.Load() == nil {
+		return
+	}
+
+	n := &c.connNotifier
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	n.pending = status
+	n.changedAt = time.Now()
+	if n.timer == nil {
+		n.timer = time.AfterFunc(connectionDebounce, c.flushConnection)
+	} else {
+		n.timer.Reset(connectionDebounce)
+	}
+}
+
+// flushConnection sends the pending connection state if it differs from the
+// last one reported
+func (c *Client) flushConnection() {
+	n := &c.connNotifier
+	n.mutex.Lock()
+	if n.pending == n.reported {
+		n.mutex.Unlock()
+		return
+	}
+	event := ConnectionEvent{
+		Status:         n.pending,
+		PreviousStatus: n.reported,
+		ChangedAt:      n.changedAt,
+	}
+	n.reported = n.pending
+	n.mutex.Unlock()
+
+	c.hook.Load().send(WebhookPayload{
+		Event:     WebhookEventConnection,
+		ClientID:  c.ID,
+		Timestamp: time.Now(),
+		Data:      event,
+	})
+}
+
+// stopConnectionNotifier cancels any pending connection report
+func (c *Client) stopConnectionNotifier() {
+	n := &c.connNotifier
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if n.timer != nil {
+		n.timer.Stop()
+	}
+}
@@ -0,0 +1,50 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ContactInfo is a contact known to the client's WhatsApp account
+type ContactInfo struct {
+	JID          string `json:"jid"`
+	FullName     string `json:"full_name,omitempty"`
+	FirstName    string `json:"first_name,omitempty"`
+	PushName     string `json:"push_name,omitempty"`
+	BusinessName string `json:"business_name,omitempty"`
+}
+
+// GetContacts returns the contacts synced from the account's address book
+// and those seen in chats, ordered by JID
+func (c *Client) GetContacts() ([]ContactInfo, error) {
+	c.mutex.Lock()
+	c.lastActivity = time.Now()
+	c.mutex.Unlock()
+
+	if !c.client.IsLoggedIn() {
+		return nil, ErrNotLoggedIn
+	}
+
+	stored, err := c.client.Store.Contacts.GetAllContacts(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read contacts: %w", err)
+	}
+
+	contacts := make([]ContactInfo, 0, len(stored))
+	for jid, info := range stored {
+		contacts = append(contacts, ContactInfo{
+			JID:          jid.String(),
+			FullName:     info.FullName,
+			FirstName:    info.FirstName,
+			PushName:     info.PushName,
+			BusinessName: info.BusinessName,
+		})
+	}
+	sort.Slice(contacts, func(i, j int) bool {
+		return contacts[i].JID < contacts[j].JID
+	})
+
+	return contacts, nil
+}
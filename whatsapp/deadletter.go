@@ -0,0 +1,219 @@
+package whatsapp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// deadLetterFile is the name of the file holding a client's failed messages
+const deadLetterFile = "deadletter.json"
+
+// maxDeadLetters bounds the failed messages kept per client; the oldest are
+// dropped first
+const maxDeadLetters = 1000
+
+// ErrDeadLetterNotFound is returned when retrying a dead letter that doesn't exist
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// DeadLetter is a message that could not be sent, kept so it can be
+// inspected and replayed
+type DeadLetter struct {
+	ID        string `json:"id"`
+	Recipient string `json:"recipient"`
+	Message   string `json:"message,omitempty"`
+	SendOptions
+	// Content is a prebuilt message, such as buttons or a list, in
+	// protobuf JSON form. Message and the send options are unused when set.
+	Content  json.RawMessage `json:"content,omitempty"`
+	Reason   string          `json:"reason"`
+	Attempts int             `json:"attempts"`
+	FailedAt time.Time       `json:"failed_at"`
+}
+
+// DeadLetterRetry is the outcome of replaying one dead letter
+type DeadLetterRetry struct {
+	ID    string `json:"id"`
+	JobID string `json:"job_id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// shouldDeadLetter reports whether a failed send is worth keeping. Invalid
+// messages would fail again, and callers that gave up were told so.
+func shouldDeadLetter(err error) bool {
+	switch {
+	case errors.Is(err, ErrInvalidRecipient), errors.Is(err, ErrInvalidMessage),
+		errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	}
+	return true
+}
+
+// deadLetter records a message that failed for good
+func (c *Client) deadLetter(job *queuedMessage, reason error) {
+	if !shouldDeadLetter(reason) {
+		return
+	}
+
+	entry := DeadLetter{
+		ID:          newJobID(),
+		Recipient:   job.recipient,
+		Message:     job.message,
+		SendOptions: job.opts,
+		Reason:      reason.Error(),
+		Attempts:    job.attempts,
+		FailedAt:    time.Now(),
+	}
+	if job.content != nil {
+		content, err := protojson.Marshal(job.content)
+		if err != nil {
+			slog.Warn("Failed to marshal dead letter", "client", c.ID, "recipient", job.recipient, "error", err)
+			return
+		}
+		entry.Content = content
+		entry.Message = ""
+		entry.SendOptions = SendOptions{}
+	}
+
+	c.deadLetterMutex.Lock()
+	defer c.deadLetterMutex.Unlock()
+
+	c.deadLetters = append(c.deadLetters, entry)
+	if len(c.deadLetters) > maxDeadLetters {
+		c.deadLetters = c.deadLetters[len(c.deadLetters)-maxDeadLetters:]
+	}
+	if err := c.saveDeadLetters(); err != nil {
+		slog.Warn("Failed to save dead letters", "client", c.ID, "error", err)
+	}
+}
+
+// ListDeadLetters lists the client's failed messages, oldest first
+func (c *Client) ListDeadLetters() []DeadLetter {
+	c.deadLetterMutex.Lock()
+	defer c.deadLetterMutex.Unlock()
+
+	list := make([]DeadLetter, len(c.deadLetters))
+	copy(list, c.deadLetters)
+	return list
+}
+
+// RetryDeadLetters queues the dead letters with the given IDs, or all of
+// them when ids is empty, for sending again. Queued entries are removed;
+// ones that fail again come back as new entries.
+func (c *Client) RetryDeadLetters(ids []string) ([]DeadLetterRetry, error) {
+	c.deadLetterMutex.Lock()
+	defer c.deadLetterMutex.Unlock()
+
+	selected := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		selected[id] = true
+	}
+	for id := range selected {
+		if !c.hasDeadLetter(id) {
+			return nil, fmt.Errorf("%w: %s", ErrDeadLetterNotFound, id)
+		}
+	}
+
+	results := make([]DeadLetterRetry, 0)
+	remaining := c.deadLetters[:0:0]
+	for _, entry := range c.deadLetters {
+		if len(selected) > 0 && !selected[entry.ID] {
+			remaining = append(remaining, entry)
+			continue
+		}
+
+		result := DeadLetterRetry{ID: entry.ID}
+		jobID, err := c.requeueDeadLetter(entry)
+		if err != nil {
+			result.Error = err.Error()
+			remaining = append(remaining, entry)
+		} else {
+			result.JobID = jobID
+		}
+		results = append(results, result)
+	}
+
+	c.deadLetters = remaining
+	if err := c.saveDeadLetters(); err != nil {
+		return results, err
+	}
+	return results, nil
+}
+
+// hasDeadLetter reports whether a dead letter exists. Must hold deadLetterMutex.
+func (c *Client) hasDeadLetter(id string) bool {
+	for _, entry := range c.deadLetters {
+		if entry.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// requeueDeadLetter puts a dead letter back in the send queue and returns
+// the queue job ID
+func (c *Client) requeueDeadLetter(entry DeadLetter) (string, error) {
+	job := &queuedMessage{
+		id:        newJobID(),
+		recipient: entry.Recipient,
+		message:   entry.Message,
+		opts:      entry.SendOptions,
+		async:     true,
+	}
+	if len(entry.Content) > 0 {
+		job.content = &waProto.Message{}
+		if err := protojson.Unmarshal(entry.Content, job.content); err != nil {
+			return "", fmt.Errorf("failed to parse stored message: %w", err)
+		}
+	} else {
+		job.preview = c.linkPreviewFor(context.Background(), job.message, job.opts)
+	}
+	if err := c.queue.enqueue(job); err != nil {
+		return "", err
+	}
+	return job.id, nil
+}
+
+// loadDeadLetters reads the dead letters saved for the client
+func (c *Client) loadDeadLetters() error {
+	data, err := os.ReadFile(filepath.Join(c.dataDir, deadLetterFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read dead letter file: %w", err)
+	}
+
+	var deadLetters []DeadLetter
+	if err := json.Unmarshal(data, &deadLetters); err != nil {
+		return fmt.Errorf("failed to parse dead letter file: %w", err)
+	}
+
+	c.deadLetterMutex.Lock()
+	c.deadLetters = deadLetters
+	c.deadLetterMutex.Unlock()
+
+	return nil
+}
+
+// saveDeadLetters writes the dead letters to disk. Must hold deadLetterMutex.
+func (c *Client) saveDeadLetters() error {
+	data, err := json.MarshalIndent(c.deadLetters, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal dead letters: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(c.dataDir, deadLetterFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write dead letter file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,19 @@
+package whatsapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+)
+
+// logUnhandledEvent logs the type and contents of an event the client
+// doesn't act on, to find out what whatsmeow emits
+func (c *Client) logUnhandledEvent(evt interface{}) {
+	eventType := fmt.Sprintf("%T", evt)
+	data, err := json.Marshal(evt)
+	if err != nil {
+		slog.Info("Unhandled WhatsApp event", "client", c.ID, "type", eventType, "event", fmt.Sprintf("%+v", evt))
+		return
+	}
+	slog.Info("Unhandled WhatsApp event", "client", c.ID, "type", eventType, "event", string(data))
+}
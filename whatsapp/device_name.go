@@ -0,0 +1,29 @@
+package whatsapp
+
+import (
+	"go.mau.fi/whatsmeow/proto/waCompanionReg"
+	"go.mau.fi/whatsmeow/proto/waWa6"
+	"go.mau.fi/whatsmeow/store"
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultDeviceName is shown in the phone's linked devices list when no
+// device name is configured
+const DefaultDeviceName = "Go WA Gateway"
+
+// clientPayload builds the payload sent when connecting. Pairing payloads
+// carry the device name shown on the phone; it's set here rather than in the
+// global store.DeviceProps so clients can use different names.
+func (c *Client) clientPayload() *waWa6.ClientPayload {
+	payload := c.deviceStore.GetClientPayload()
+	if payload.DevicePairingData == nil {
+		return payload
+	}
+
+	props := proto.Clone(store.DeviceProps).(*waCompanionReg.DeviceProps)
+	props.Os = proto.String(c.deviceName)
+	if data, err := proto.Marshal(props); err == nil {
+		payload.DevicePairingData.DeviceProps = data
+	}
+	return payload
+}
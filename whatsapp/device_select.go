@@ -0,0 +1,59 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/store/sqlstore"
+)
+
+// ErrAmbiguousDevice is returned when a client's store holds several
+// sessions and none of them can be told apart as the client's own
+var ErrAmbiguousDevice = errors.New("store holds several devices")
+
+// selectDevice picks the client's session from its store. A store normally
+// holds at most one, but leftovers from a partial cleanup can add more; then
+// the one belonging to expectedPhone is used, and anything else is refused
+// rather than attaching the client to the wrong account. An empty store
+// gets a new device.
+func selectDevice(ctx context.Context, id string, container *sqlstore.Container, expectedPhone string) (*store.Device, error) {
+	devices, err := container.GetAllDevices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(devices) {
+	case 0:
+		return container.NewDevice(), nil
+	case 1:
+		device := devices[0]
+		if expectedPhone != "" && device.ID != nil && device.ID.User != expectedPhone {
+			slog.Warn("Client session belongs to another number than saved", "client", id, "expected", expectedPhone, "device", device.ID.String())
+		}
+		return device, nil
+	}
+
+	jids := make([]string, len(devices))
+	var match *store.Device
+	matches := 0
+	for i, device := range devices {
+		jids[i] = device.ID.String()
+		if expectedPhone != "" && device.ID.User == expectedPhone {
+			match = device
+			matches++
+		}
+	}
+	switch {
+	case expectedPhone == "":
+		return nil, fmt.Errorf("%w and the client has no saved number to pick one by: %s", ErrAmbiguousDevice, strings.Join(jids, ", "))
+	case matches != 1:
+		return nil, fmt.Errorf("%w and %d of them belong to %s: %s", ErrAmbiguousDevice, matches, expectedPhone, strings.Join(jids, ", "))
+	}
+
+	slog.Warn("Client store holds several devices, using the one for its number", "client", id, "device", match.ID.String(), "devices", jids)
+	return match, nil
+}
@@ -0,0 +1,100 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.mau.fi/whatsmeow/proto/waAdv"
+	"go.mau.fi/whatsmeow/store/sqlstore"
+	"go.mau.fi/whatsmeow/types"
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+// newTestContainer opens an empty sqlite store in a temporary directory
+func newTestContainer(t *testing.T) *sqlstore.Container {
+	t.Helper()
+
+	db, dialect, err := openStore("test", t.TempDir(), Options{})
+	if err != nil {
+		t.Fatalf("openStore: %v", err)
+	}
+	container := sqlstore.NewWithDB(db, dialect, waLog.Noop)
+	if err := container.Upgrade(context.Background()); err != nil {
+		db.Close()
+		t.Fatalf("Upgrade: %v", err)
+	}
+	t.Cleanup(func() {
+		container.Close()
+	})
+	return container
+}
+
+// seedDevice stores a linked device for phone, as pairing would
+func seedDevice(t *testing.T, container *sqlstore.Container, phone string, deviceID uint8) {
+	t.Helper()
+
+	device := container.NewDevice()
+	jid := types.NewADJID(phone, 0, deviceID)
+	device.ID = &jid
+	device.Account = &waAdv.ADVSignedDeviceIdentity{
+		Details:             []byte{1},
+		AccountSignature:    make([]byte, 64),
+		AccountSignatureKey: make([]byte, 32),
+		DeviceSignature:     make([]byte, 64),
+	}
+	if err := container.PutDevice(context.Background(), device); err != nil {
+		t.Fatalf("PutDevice(%s): %v", jid, err)
+	}
+}
+
+func TestSelectDevice(t *testing.T) {
+	tests := []struct {
+		name          string
+		devices       []string
+		expectedPhone string
+		wantPhone     string
+		wantNew       bool
+		wantErr       bool
+	}{
+		{name: "empty store", wantNew: true},
+		{name: "one device", devices: []string{"6281234567890"}, expectedPhone: "6281234567890", wantPhone: "6281234567890"},
+		{name: "one device without saved number", devices: []string{"6281234567890"}, wantPhone: "6281234567890"},
+		// The only session is used even if it's for another number
+		{name: "one device for another number", devices: []string{"6281234567890"}, expectedPhone: "6289876543210", wantPhone: "6281234567890"},
+		{name: "two devices, first expected", devices: []string{"6281234567890", "6289876543210"}, expectedPhone: "6281234567890", wantPhone: "6281234567890"},
+		{name: "two devices, second expected", devices: []string{"6281234567890", "6289876543210"}, expectedPhone: "6289876543210", wantPhone: "6289876543210"},
+		{name: "two devices without saved number", devices: []string{"6281234567890", "6289876543210"}, wantErr: true},
+		{name: "two devices, neither expected", devices: []string{"6281234567890", "6289876543210"}, expectedPhone: "6285555555555", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			container := newTestContainer(t)
+			for i, phone := range tt.devices {
+				seedDevice(t, container, phone, uint8(i+1))
+			}
+
+			device, err := selectDevice(context.Background(), "test", container, tt.expectedPhone)
+			if tt.wantErr {
+				if !errors.Is(err, ErrAmbiguousDevice) {
+					t.Fatalf("selectDevice error = %v, want ErrAmbiguousDevice", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("selectDevice: %v", err)
+			}
+
+			if tt.wantNew {
+				if device.ID != nil {
+					t.Errorf("selectDevice returned %s, want a new device", device.ID)
+				}
+				return
+			}
+			if device.ID == nil || device.ID.User != tt.wantPhone {
+				t.Errorf("selectDevice returned %v, want the device of %s", device.ID, tt.wantPhone)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package whatsapp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"go.mau.fi/whatsmeow/store"
+)
+
+// Diagnostics is a low-level view of a client's session and connection for
+// troubleshooting. Keys are never included, only whether they're present or
+// a fingerprint of public ones.
+type Diagnostics struct {
+	ClientID       string       `json:"client_id"`
+	Status         ClientStatus `json:"status"`
+	DeviceJID      string       `json:"device_jid,omitempty"`
+	LID            string       `json:"lid,omitempty"`
+	Platform       string       `json:"platform,omitempty"`
+	RegistrationID uint32       `json:"registration_id"`
+	// Presence of the session keys
+	HasADVSecret   bool `json:"has_adv_secret"`
+	HasNoiseKey    bool `json:"has_noise_key"`
+	HasIdentityKey bool `json:"has_identity_key"`
+	// IdentityKeyHash is a SHA-256 fingerprint of the public identity key
+	IdentityKeyHash string `json:"identity_key_hash,omitempty"`
+	// Socket state as whatsmeow sees it
+	SocketConnected       bool       `json:"socket_connected"`
+	LoggedIn              bool       `json:"logged_in"`
+	LastSuccessfulConnect *time.Time `json:"last_successful_connect,omitempty"`
+	LastPing              *time.Time `json:"last_ping,omitempty"`
+	LastConnectError      string     `json:"last_connect_error,omitempty"`
+	LogoutReason          string     `json:"logout_reason,omitempty"`
+	ReconnectAttempts     int        `json:"reconnect_attempts"`
+	// WAVersion is the WhatsApp Web version reported to the server
+	WAVersion string `json:"wa_version"`
+}
+
+// Diagnostics returns the client's low-level session and connection details
+func (c *Client) Diagnostics() Diagnostics {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	device := c.client.Store
+	diagnostics := Diagnostics{
+		ClientID:          c.ID,
+		Status:            c.getStateLocked().Status,
+		Platform:          device.Platform,
+		RegistrationID:    device.RegistrationID,
+		HasADVSecret:      len(device.AdvSecretKey) > 0,
+		HasNoiseKey:       device.NoiseKey != nil,
+		HasIdentityKey:    device.IdentityKey != nil,
+		SocketConnected:   c.client.IsConnected(),
+		LoggedIn:          c.client.IsLoggedIn(),
+		LastConnectError:  c.connError,
+		LogoutReason:      c.logoutReason,
+		ReconnectAttempts: c.reconnectAttempts,
+		WAVersion:         store.GetWAVersion().String(),
+	}
+	if device.ID != nil {
+		diagnostics.DeviceJID = device.ID.String()
+	}
+	if !device.LID.IsEmpty() {
+		diagnostics.LID = device.LID.String()
+	}
+	if device.IdentityKey != nil {
+		sum := sha256.Sum256(device.IdentityKey.Pub[:])
+		diagnostics.IdentityKeyHash = hex.EncodeToString(sum[:])
+	}
+	if at := c.client.LastSuccessfulConnect; !at.IsZero() {
+		diagnostics.LastSuccessfulConnect = &at
+	}
+	if !c.lastPing.IsZero() {
+		at := c.lastPing
+		diagnostics.LastPing = &at
+	}
+
+	return diagnostics
+}
@@ -0,0 +1,94 @@
+package whatsapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// MessagePreview describes a message that was validated in dry-run mode
+// instead of being sent
+type MessagePreview struct {
+	// JID is the resolved recipient
+	JID string `json:"jid"`
+	// Message is the message as it would be sent, in protobuf JSON form
+	Message json.RawMessage `json:"message"`
+}
+
+// PreviewMessage validates a text message like SendMessageWithOptions and
+// returns what would be sent, without sending it
+func (c *Client) PreviewMessage(recipient string, message string, opts SendOptions) (MessagePreview, error) {
+	jid, err := c.parseRecipient(recipient)
+	if err != nil {
+		return MessagePreview{}, err
+	}
+	content, err := c.buildTextMessage(jid, message, opts)
+	if err != nil {
+		return MessagePreview{}, err
+	}
+	if opts.LinkPreview {
+		content = c.withLinkPreview(context.Background(), content)
+	}
+	return newMessagePreview(jid, content)
+}
+
+// PreviewGroupMessage validates a group message like SendGroupMessage and
+// returns what would be sent, without sending it
+func (c *Client) PreviewGroupMessage(groupJID string, message string, opts SendOptions) (MessagePreview, error) {
+	if _, err := parseGroupJID(groupJID); err != nil {
+		return MessagePreview{}, err
+	}
+	return c.PreviewMessage(groupJID, message, opts)
+}
+
+// PreviewNewsletter validates a channel post like SendNewsletter and returns
+// what would be sent, without sending it
+func (c *Client) PreviewNewsletter(newsletterJID string, message string) (MessagePreview, error) {
+	jid, content, err := buildNewsletterMessage(newsletterJID, message)
+	if err != nil {
+		return MessagePreview{}, err
+	}
+	return newMessagePreview(jid, content)
+}
+
+// PreviewButtons validates a buttons message like SendButtons and returns
+// what would be sent, without sending it
+func (c *Client) PreviewButtons(recipient string, body string, buttons []Button) (MessagePreview, error) {
+	content, err := buildButtonsMessage(body, buttons)
+	if err != nil {
+		return MessagePreview{}, err
+	}
+	return c.previewContent(recipient, content)
+}
+
+// PreviewList validates a list message like SendList and returns what would
+// be sent, without sending it
+func (c *Client) PreviewList(recipient string, title string, description string, buttonText string, sections []ListSection) (MessagePreview, error) {
+	content, err := buildListMessage(title, description, buttonText, sections)
+	if err != nil {
+		return MessagePreview{}, err
+	}
+	return c.previewContent(recipient, content)
+}
+
+// previewContent resolves the recipient of a prebuilt message
+func (c *Client) previewContent(recipient string, content *waProto.Message) (MessagePreview, error) {
+	jid, err := c.parseRecipient(recipient)
+	if err != nil {
+		return MessagePreview{}, err
+	}
+	return newMessagePreview(jid, content)
+}
+
+// newMessagePreview describes a message that would be sent to jid
+func newMessagePreview(jid types.JID, content *waProto.Message) (MessagePreview, error) {
+	data, err := protojson.Marshal(content)
+	if err != nil {
+		return MessagePreview{}, fmt.Errorf("failed to marshal message: %w", err)
+	}
+	return MessagePreview{JID: jid.String(), Message: data}, nil
+}
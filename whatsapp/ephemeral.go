@@ -0,0 +1,77 @@
+package whatsapp
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrInvalidDisappearingTimer is returned for disappearing message timers
+// WhatsApp doesn't offer
+var ErrInvalidDisappearingTimer = errors.New("disappearing timer must be off, 24 hours, 7 days or 90 days")
+
+// validateDisappearingTimer checks a timer is one of the values WhatsApp
+// allows. Zero turns disappearing messages off.
+func validateDisappearingTimer(timer time.Duration) error {
+	switch timer {
+	case whatsmeow.DisappearingTimerOff, whatsmeow.DisappearingTimer24Hours,
+		whatsmeow.DisappearingTimer7Days, whatsmeow.DisappearingTimer90Days:
+		return nil
+	}
+	return ErrInvalidDisappearingTimer
+}
+
+// validate checks the options that don't depend on the recipient, so bad
+// ones are rejected before the message waits in the queue
+func (o SendOptions) validate() error {
+	if o.EphemeralSeconds < 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidMessage, ErrInvalidDisappearingTimer)
+	}
+	if err := validateDisappearingTimer(time.Duration(o.EphemeralSeconds) * time.Second); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidMessage, err)
+	}
+	if o.CallbackURL != "" {
+		return validateCallbackURL(o.CallbackURL)
+	}
+	return nil
+}
+
+// SetDisappearingTimer turns disappearing messages on or off for a chat.
+// A duration of zero turns them off; otherwise it must be 24 hours, 7 days or
+// 90 days.
+func (c *Client) SetDisappearingTimer(chatJID string, duration time.Duration) error {
+	jid, err := c.parseRecipient(chatJID)
+	if err != nil {
+		return err
+	}
+	if err := validateDisappearingTimer(duration); err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	c.lastActivity = time.Now()
+	c.mutex.Unlock()
+
+	if !c.client.IsLoggedIn() {
+		return ErrNotLoggedIn
+	}
+
+	if err := c.client.SetDisappearingTimer(jid, duration, time.Time{}); err != nil {
+		return fmt.Errorf("failed to set disappearing timer: %w", err)
+	}
+
+	return nil
+}
+
+// ephemeralContext marks a message as disappearing after seconds
+func ephemeralContext(contextInfo *waProto.ContextInfo, seconds int) *waProto.ContextInfo {
+	if contextInfo == nil {
+		contextInfo = &waProto.ContextInfo{}
+	}
+	contextInfo.Expiration = proto.Uint32(uint32(seconds))
+	return contextInfo
+}
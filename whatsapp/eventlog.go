@@ -0,0 +1,120 @@
+package whatsapp
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// Types of entries in a client's event log
+const (
+	LogEventConnected      = "connected"
+	LogEventDisconnected   = "disconnected"
+	LogEventQR             = "qr"
+	LogEventPaired         = "paired"
+	LogEventLoggedOut      = "logged_out"
+	LogEventStreamReplaced = "stream_replaced"
+	LogEventError          = "error"
+)
+
+// LogEvent is an entry in a client's event log
+type LogEvent struct {
+	Type   string    `json:"type"`
+	Time   time.Time `json:"time"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// eventLog keeps the most recent connection events of a client in a ring
+// buffer
+type eventLog struct {
+	mutex  sync.Mutex
+	events []LogEvent
+	// next is where the next event goes once the buffer is full
+	next int
+	size int
+}
+
+// newEventLog creates an event log holding up to size events. A size of 0 or
+// less disables the log.
+func newEventLog(size int) *eventLog {
+	if size < 0 {
+		size = 0
+	}
+	return &eventLog{size: size}
+}
+
+// add appends an event, overwriting the oldest once the log is full
+func (l *eventLog) add(eventType string, detail string) {
+	if l.size == 0 {
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	event := LogEvent{Type: eventType, Time: time.Now(), Detail: detail}
+	if len(l.events) < l.size {
+		l.events = append(l.events, event)
+		return
+	}
+	l.events[l.next] = event
+	l.next = (l.next + 1) % l.size
+}
+
+// recent returns up to limit events, newest first. A limit of 0 or less
+// returns all of them.
+func (l *eventLog) recent(limit int) []LogEvent {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	count := len(l.events)
+	if limit > 0 && limit < count {
+		count = limit
+	}
+
+	list := make([]LogEvent, 0, count)
+	for i := 0; i < count; i++ {
+		// The newest event sits just before next
+		index := (l.next - 1 - i + 2*len(l.events)) % len(l.events)
+		list = append(list, l.events[index])
+	}
+	return list
+}
+
+// record adds the connection related events from WhatsApp to the log
+func (l *eventLog) record(evt interface{}) {
+	switch e := evt.(type) {
+	case *events.Connected:
+		l.add(LogEventConnected, "")
+	case *events.Disconnected:
+		l.add(LogEventDisconnected, "")
+	case *events.QR:
+		l.add(LogEventQR, fmt.Sprintf("%d codes received", len(e.Codes)))
+	case *events.PairSuccess:
+		l.add(LogEventPaired, e.ID.String())
+	case *events.PairError:
+		l.add(LogEventError, fmt.Sprintf("pairing failed: %v", e.Error))
+	case *events.LoggedOut:
+		l.add(LogEventLoggedOut, logoutReason(e))
+	case *events.StreamReplaced:
+		l.add(LogEventStreamReplaced, "session was replaced by another connection")
+	case *events.ConnectFailure:
+		l.add(LogEventError, fmt.Sprintf("connect failed: %s", e.Reason))
+	case *events.TemporaryBan:
+		l.add(LogEventError, e.String())
+	case *events.ClientOutdated:
+		l.add(LogEventError, "client version is outdated")
+	case *events.StreamError:
+		l.add(LogEventError, fmt.Sprintf("stream error: %s", e.Code))
+	case *events.KeepAliveTimeout:
+		l.add(LogEventError, fmt.Sprintf("keepalive timeout after %d failures", e.ErrorCount))
+	}
+}
+
+// Events returns up to limit of the client's recent connection events, newest
+// first. A limit of 0 or less returns all kept events.
+func (c *Client) Events(limit int) []LogEvent {
+	return c.events.recent(limit)
+}
@@ -0,0 +1,183 @@
+package whatsapp
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// maxFilterEntries bounds each list in a client's inbound filters
+const maxFilterEntries = 100
+
+// Message types inbound filters can match on
+var filterMessageTypes = []string{"text", "image", "video", "audio", "document", "sticker"}
+
+// ErrInvalidFilter is returned for inbound filters that can't be applied
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// InboundFilters decide which received messages are forwarded to the
+// webhook. An empty list matches everything, so a message is forwarded when
+// it matches every list that is set.
+type InboundFilters struct {
+	// From holds phone numbers or JIDs of senders or chats; a group JID
+	// matches every message in the group
+	From []string `json:"from,omitempty"`
+	// Keywords match when the text contains any of them, ignoring case
+	Keywords []string `json:"keywords,omitempty"`
+	// Types are message types: text, image, video, audio, document or sticker
+	Types []string `json:"types,omitempty"`
+}
+
+// isEmpty reports whether the filters let every message through
+func (f *InboundFilters) isEmpty() bool {
+	return f == nil || len(f.From) == 0 && len(f.Keywords) == 0 && len(f.Types) == 0
+}
+
+// matches reports whether a received message passes the filters
+func (f *InboundFilters) matches(evt *events.Message) bool {
+	if f.isEmpty() {
+		return true
+	}
+
+	if len(f.From) > 0 {
+		sources := []string{evt.Info.Chat.String(), evt.Info.Sender.ToNonAD().String()}
+		if !evt.Info.SenderAlt.IsEmpty() {
+			sources = append(sources, evt.Info.SenderAlt.ToNonAD().String())
+		}
+		if !slices.ContainsFunc(sources, func(source string) bool { return slices.Contains(f.From, source) }) {
+			return false
+		}
+	}
+
+	text := messageText(evt.Message)
+	if len(f.Keywords) > 0 {
+		lower := strings.ToLower(text)
+		if !slices.ContainsFunc(f.Keywords, func(keyword string) bool { return strings.Contains(lower, keyword) }) {
+			return false
+		}
+	}
+
+	if len(f.Types) > 0 {
+		messageType := messageMediaType(evt.Message)
+		if messageType == "" && text != "" {
+			messageType = "text"
+		}
+		if !slices.Contains(f.Types, messageType) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// normalizeFilters validates filters and puts them in the form they're
+// matched in: JIDs without device, lower case keywords and no duplicates.
+// Filters with nothing set come back as nil.
+func (c *Client) normalizeFilters(filters InboundFilters) (*InboundFilters, error) {
+	if len(filters.From) > maxFilterEntries || len(filters.Keywords) > maxFilterEntries || len(filters.Types) > maxFilterEntries {
+		return nil, fmt.Errorf("%w: at most %d entries are allowed per list", ErrInvalidFilter, maxFilterEntries)
+	}
+
+	normalized := &InboundFilters{}
+	for _, from := range filters.From {
+		jid, err := c.parseRecipient(strings.TrimSpace(from))
+		if err != nil {
+			return nil, fmt.Errorf("%w: from %q: %v", ErrInvalidFilter, from, err)
+		}
+		if value := jid.ToNonAD().String(); !slices.Contains(normalized.From, value) {
+			normalized.From = append(normalized.From, value)
+		}
+	}
+	for _, keyword := range filters.Keywords {
+		keyword = strings.ToLower(strings.TrimSpace(keyword))
+		if keyword == "" {
+			return nil, fmt.Errorf("%w: keywords cannot be empty", ErrInvalidFilter)
+		}
+		if !slices.Contains(normalized.Keywords, keyword) {
+			normalized.Keywords = append(normalized.Keywords, keyword)
+		}
+	}
+	for _, messageType := range filters.Types {
+		messageType = strings.ToLower(strings.TrimSpace(messageType))
+		if !slices.Contains(filterMessageTypes, messageType) {
+			return nil, fmt.Errorf("%w: unknown message type %q, expected one of %s", ErrInvalidFilter, messageType, strings.Join(filterMessageTypes, ", "))
+		}
+		if !slices.Contains(normalized.Types, messageType) {
+			normalized.Types = append(normalized.Types, messageType)
+		}
+	}
+
+	if normalized.isEmpty() {
+		return nil, nil
+	}
+	return normalized, nil
+}
+
+// SetFilters replaces the client's inbound filters and saves them with the
+// rest of the client state. Empty filters forward every message.
+func (c *Client) SetFilters(filters InboundFilters) (*InboundFilters, error) {
+	normalized, err := c.normalizeFilters(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	previous := c.filters
+	c.filters = normalized
+	c.mutex.Unlock()
+
+	if err := c.SaveState(); err != nil {
+		c.mutex.Lock()
+		c.filters = previous
+		c.mutex.Unlock()
+		return nil, err
+	}
+
+	return normalized, nil
+}
+
+// MessageWebhookData is the data of a message webhook
+type MessageWebhookData struct {
+	StoredMessage
+	// Media is set when the message's media was downloaded
+	Media *WebhookMedia `json:"media,omitempty"`
+}
+
+// notifyMessage forwards a received message to the webhook if it passes the
+// client's inbound filters. Messages sent from the account itself aren't
+// forwarded.
+func (c *Client) notifyMessage(evt *events.Message, media *WebhookMedia) {
+	hook := c.hook.Load()
+	if hook == nil || evt.Info.IsFromMe {
+		return
+	}
+
+	c.mutex.RLock()
+	filters := c.filters
+	c.mutex.RUnlock()
+	if !filters.matches(evt) {
+		return
+	}
+
+	hook.send(WebhookPayload{
+		Event:     WebhookEventMessage,
+		ClientID:  c.ID,
+		Timestamp: time.Now(),
+		Data: MessageWebhookData{
+			StoredMessage: StoredMessage{
+				ID:        evt.Info.ID,
+				ChatJID:   evt.Info.Chat.String(),
+				SenderJID: evt.Info.Sender.ToNonAD().String(),
+				PushName:  evt.Info.PushName,
+				Text:      messageText(evt.Message),
+				MediaType: messageMediaType(evt.Message),
+				Timestamp: evt.Info.Timestamp,
+			},
+			Media: media,
+		},
+	})
+}
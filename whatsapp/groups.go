@@ -0,0 +1,302 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// GroupInfo is a trimmed down view of a joined group
+type GroupInfo struct {
+	JID              string `json:"jid"`
+	Name             string `json:"name"`
+	Topic            string `json:"topic,omitempty"`
+	ParticipantCount int    `json:"participant_count"`
+	IsAdmin          bool   `json:"is_admin"`
+}
+
+// ListGroups lists the groups the client is a member of
+func (c *Client) ListGroups() ([]GroupInfo, error) {
+	c.mutex.Lock()
+	c.lastActivity = time.Now()
+	c.mutex.Unlock()
+
+	if !c.client.IsLoggedIn() {
+		return nil, ErrNotLoggedIn
+	}
+
+	groups, err := c.client.GetJoinedGroups(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get joined groups: %w", err)
+	}
+
+	result := make([]GroupInfo, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, GroupInfo{
+			JID:              group.JID.String(),
+			Name:             group.Name,
+			Topic:            group.Topic,
+			ParticipantCount: len(group.Participants),
+			IsAdmin:          c.isGroupAdmin(group),
+		})
+	}
+
+	return result, nil
+}
+
+// GetGroupInfo gets the metadata and participant list of a group
+func (c *Client) GetGroupInfo(groupJID string) (*types.GroupInfo, error) {
+	jid, err := parseGroupJID(groupJID)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	c.lastActivity = time.Now()
+	c.mutex.Unlock()
+
+	if !c.client.IsLoggedIn() {
+		return nil, ErrNotLoggedIn
+	}
+
+	info, err := c.client.GetGroupInfo(jid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group info: %w", err)
+	}
+
+	return info, nil
+}
+
+// isGroupAdmin reports whether the logged in account is an admin of the group
+func (c *Client) isGroupAdmin(group *types.GroupInfo) bool {
+	if c.client.Store.ID == nil {
+		return false
+	}
+	self := c.client.Store.ID.ToNonAD()
+	selfLID := c.client.Store.GetLID().ToNonAD()
+
+	for _, participant := range group.Participants {
+		if !participant.IsAdmin && !participant.IsSuperAdmin {
+			continue
+		}
+		jid := participant.JID.ToNonAD()
+		if jid == self || (!selfLID.IsEmpty() && jid == selfLID) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxGroupNameLength is the longest group name WhatsApp accepts
+const maxGroupNameLength = 25
+
+// maxGroupTopicLength is the longest group description WhatsApp accepts
+const maxGroupTopicLength = 2048
+
+// ValidateGroupName checks a group name against WhatsApp's limits
+func ValidateGroupName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("%w: group name is required", ErrInvalidMessage)
+	}
+	if utf8.RuneCountInString(name) > maxGroupNameLength {
+		return fmt.Errorf("%w: group name is longer than %d characters", ErrInvalidMessage, maxGroupNameLength)
+	}
+	return nil
+}
+
+// ValidateGroupTopic checks a group description against WhatsApp's limits.
+// An empty topic is valid and clears the description.
+func ValidateGroupTopic(topic string) error {
+	if utf8.RuneCountInString(topic) > maxGroupTopicLength {
+		return fmt.Errorf("%w: group topic is longer than %d characters", ErrInvalidMessage, maxGroupTopicLength)
+	}
+	return nil
+}
+
+// CreateGroup creates a group with the given participants and returns its info.
+// The client's own account is added by WhatsApp and doesn't need to be listed.
+func (c *Client) CreateGroup(name string, participants []string) (*types.GroupInfo, error) {
+	name = strings.TrimSpace(name)
+	if err := ValidateGroupName(name); err != nil {
+		return nil, err
+	}
+	jids, err := c.parseParticipants(participants)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	c.lastActivity = time.Now()
+	c.mutex.Unlock()
+
+	if !c.client.IsLoggedIn() {
+		return nil, ErrNotLoggedIn
+	}
+
+	info, err := c.client.CreateGroup(context.Background(), whatsmeow.ReqCreateGroup{
+		Name:         name,
+		Participants: jids,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create group: %w", err)
+	}
+
+	return info, nil
+}
+
+// AddParticipants adds participants to a group and returns the updated group info
+func (c *Client) AddParticipants(groupJID string, participants []string) (*types.GroupInfo, error) {
+	return c.updateParticipants(groupJID, participants, whatsmeow.ParticipantChangeAdd)
+}
+
+// RemoveParticipants removes participants from a group and returns the updated group info
+func (c *Client) RemoveParticipants(groupJID string, participants []string) (*types.GroupInfo, error) {
+	return c.updateParticipants(groupJID, participants, whatsmeow.ParticipantChangeRemove)
+}
+
+// PromoteParticipants makes participants admins of a group and returns the updated group info
+func (c *Client) PromoteParticipants(groupJID string, participants []string) (*types.GroupInfo, error) {
+	return c.updateParticipants(groupJID, participants, whatsmeow.ParticipantChangePromote)
+}
+
+// DemoteParticipants revokes the admin rights of participants and returns the updated group info
+func (c *Client) DemoteParticipants(groupJID string, participants []string) (*types.GroupInfo, error) {
+	return c.updateParticipants(groupJID, participants, whatsmeow.ParticipantChangeDemote)
+}
+
+// SetGroupName changes the name (subject) of a group
+func (c *Client) SetGroupName(groupJID string, name string) error {
+	jid, err := parseGroupJID(groupJID)
+	if err != nil {
+		return err
+	}
+	name = strings.TrimSpace(name)
+	if err := ValidateGroupName(name); err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	c.lastActivity = time.Now()
+	c.mutex.Unlock()
+
+	if !c.client.IsLoggedIn() {
+		return ErrNotLoggedIn
+	}
+
+	if err := c.client.SetGroupName(jid, name); err != nil {
+		return fmt.Errorf("failed to set group name: %w", err)
+	}
+
+	return nil
+}
+
+// SetGroupTopic changes the topic (description) of a group. An empty topic
+// removes the description.
+func (c *Client) SetGroupTopic(groupJID string, topic string) error {
+	jid, err := parseGroupJID(groupJID)
+	if err != nil {
+		return err
+	}
+	if err := ValidateGroupTopic(topic); err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	c.lastActivity = time.Now()
+	c.mutex.Unlock()
+
+	if !c.client.IsLoggedIn() {
+		return ErrNotLoggedIn
+	}
+
+	// whatsmeow looks up the previous topic ID and generates the new one
+	if err := c.client.SetGroupTopic(jid, "", "", topic); err != nil {
+		return fmt.Errorf("failed to set group topic: %w", err)
+	}
+
+	return nil
+}
+
+// LeaveGroup makes the client leave a group
+func (c *Client) LeaveGroup(groupJID string) error {
+	jid, err := parseGroupJID(groupJID)
+	if err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	c.lastActivity = time.Now()
+	c.mutex.Unlock()
+
+	if !c.client.IsLoggedIn() {
+		return ErrNotLoggedIn
+	}
+
+	if err := c.client.LeaveGroup(jid); err != nil {
+		return fmt.Errorf("failed to leave group: %w", err)
+	}
+
+	return nil
+}
+
+// updateParticipants applies a participant change to a group and fetches the
+// group info afterwards so callers see the resulting member list
+func (c *Client) updateParticipants(groupJID string, participants []string, action whatsmeow.ParticipantChange) (*types.GroupInfo, error) {
+	jid, err := parseGroupJID(groupJID)
+	if err != nil {
+		return nil, err
+	}
+	jids, err := c.parseParticipants(participants)
+	if err != nil {
+		return nil, err
+	}
+	if len(jids) == 0 {
+		return nil, fmt.Errorf("%w: no participants given", ErrInvalidRecipient)
+	}
+
+	c.mutex.Lock()
+	c.lastActivity = time.Now()
+	c.mutex.Unlock()
+
+	if !c.client.IsLoggedIn() {
+		return nil, ErrNotLoggedIn
+	}
+
+	if _, err := c.client.UpdateGroupParticipants(jid, jids, action); err != nil {
+		return nil, fmt.Errorf("failed to %s participants: %w", action, err)
+	}
+
+	info, err := c.client.GetGroupInfo(jid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group info: %w", err)
+	}
+
+	return info, nil
+}
+
+// parseParticipants parses group participants, which must be users rather
+// than groups. Duplicates are dropped.
+func (c *Client) parseParticipants(participants []string) ([]types.JID, error) {
+	jids := make([]types.JID, 0, len(participants))
+	seen := make(map[types.JID]bool, len(participants))
+	for _, participant := range participants {
+		jid, err := c.parseRecipient(participant)
+		if err != nil {
+			return nil, fmt.Errorf("invalid participant %q: %w", participant, err)
+		}
+		if jid.Server == types.GroupServer {
+			return nil, fmt.Errorf("%w: participant %s is a group", ErrInvalidRecipient, participant)
+		}
+		if seen[jid] {
+			continue
+		}
+		seen[jid] = true
+		jids = append(jids, jid)
+	}
+	return jids, nil
+}
@@ -0,0 +1,214 @@
+package whatsapp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// historyTableSchema creates the table holding received messages. It lives in
+// the same database as the whatsmeow store.
+const historyTableSchema = `
+CREATE TABLE IF NOT EXISTS gateway_messages (
+	id         TEXT    NOT NULL,
+	chat_jid   TEXT    NOT NULL,
+	sender_jid TEXT    NOT NULL,
+	push_name  TEXT    NOT NULL DEFAULT '',
+	text       TEXT    NOT NULL DEFAULT '',
+	media_type TEXT    NOT NULL DEFAULT '',
+	timestamp  BIGINT  NOT NULL,
+	PRIMARY KEY (chat_jid, id)
+);
+CREATE INDEX IF NOT EXISTS gateway_messages_timestamp_idx ON gateway_messages (timestamp);
+`
+
+// ErrMessageNotFound is returned when a message isn't in the history, either
+// because it was never stored or because it was trimmed
+var ErrMessageNotFound = errors.New("message not found in history")
+
+// StoredMessage is a received message kept in the client's history
+type StoredMessage struct {
+	ID        string    `json:"id"`
+	ChatJID   string    `json:"chat_jid"`
+	SenderJID string    `json:"sender_jid"`
+	PushName  string    `json:"push_name,omitempty"`
+	Text      string    `json:"text,omitempty"`
+	MediaType string    `json:"media_type,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// HistoryQuery filters the stored message history
+type HistoryQuery struct {
+	// ChatJID limits results to one chat when set
+	ChatJID string
+	// Before limits results to messages older than this time when set
+	Before time.Time
+	// Limit is the maximum number of messages returned
+	Limit int
+}
+
+// initHistory creates the message history table if needed
+func (c *Client) initHistory() error {
+	if _, err := c.db.Exec(historyTableSchema); err != nil {
+		return fmt.Errorf("failed to create message history table: %w", err)
+	}
+	return nil
+}
+
+// storeMessage saves a received message and trims the history to its cap
+func (c *Client) storeMessage(evt *events.Message) {
+	if c.historyLimit <= 0 {
+		return
+	}
+
+	ctx := context.Background()
+	_, err := c.db.ExecContext(ctx, `
+		INSERT INTO gateway_messages (id, chat_jid, sender_jid, push_name, text, media_type, timestamp)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (chat_jid, id) DO NOTHING`,
+		evt.Info.ID,
+		evt.Info.Chat.String(),
+		evt.Info.Sender.ToNonAD().String(),
+		evt.Info.PushName,
+		messageText(evt.Message),
+		messageMediaType(evt.Message),
+		evt.Info.Timestamp.UnixMilli(),
+	)
+	if err != nil {
+		slog.Warn("Failed to store message", "client", c.ID, "message_id", evt.Info.ID, "error", err)
+		return
+	}
+
+	// Drop everything older than the newest historyLimit messages
+	_, err = c.db.ExecContext(ctx, `
+		DELETE FROM gateway_messages WHERE timestamp < (
+			SELECT timestamp FROM gateway_messages ORDER BY timestamp DESC LIMIT 1 OFFSET $1
+		)`, c.historyLimit-1)
+	if err != nil {
+		slog.Warn("Failed to trim message history", "client", c.ID, "error", err)
+	}
+}
+
+// GetMessages returns stored messages, newest first
+func (c *Client) GetMessages(query HistoryQuery) ([]StoredMessage, error) {
+	sqlQuery := `SELECT id, chat_jid, sender_jid, push_name, text, media_type, timestamp FROM gateway_messages WHERE 1=1`
+	var args []interface{}
+	if query.ChatJID != "" {
+		args = append(args, query.ChatJID)
+		sqlQuery += fmt.Sprintf(" AND chat_jid = $%d", len(args))
+	}
+	if !query.Before.IsZero() {
+		args = append(args, query.Before.UnixMilli())
+		sqlQuery += fmt.Sprintf(" AND timestamp < $%d", len(args))
+	}
+	args = append(args, query.Limit)
+	sqlQuery += fmt.Sprintf(" ORDER BY timestamp DESC LIMIT $%d", len(args))
+
+	rows, err := c.db.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages := make([]StoredMessage, 0)
+	for rows.Next() {
+		msg, err := scanStoredMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+// GetMessage returns a stored message with a reference to its downloaded
+// media, in the same shape as the message webhook. The media is never
+// inlined; fetch it from the media URL. chatJID narrows the lookup when set,
+// since message IDs are only unique within a chat.
+func (c *Client) GetMessage(chatJID string, messageID string) (MessageWebhookData, error) {
+	sqlQuery := `SELECT id, chat_jid, sender_jid, push_name, text, media_type, timestamp FROM gateway_messages WHERE id = $1`
+	args := []interface{}{messageID}
+	if chatJID != "" {
+		args = append(args, chatJID)
+		sqlQuery += " AND chat_jid = $2"
+	}
+	sqlQuery += " ORDER BY timestamp DESC LIMIT 1"
+
+	msg, err := scanStoredMessage(c.db.QueryRow(sqlQuery, args...))
+	if err == sql.ErrNoRows {
+		return MessageWebhookData{}, ErrMessageNotFound
+	} else if err != nil {
+		return MessageWebhookData{}, err
+	}
+
+	data := MessageWebhookData{StoredMessage: msg}
+	if msg.MediaType != "" {
+		_, info, err := c.GetMedia(msg.ChatJID, msg.ID)
+		switch {
+		case err == nil:
+			// Without the data nothing is inlined
+			data.Media = c.webhookMedia(&info, nil)
+		case !errors.Is(err, ErrMediaNotFound):
+			return MessageWebhookData{}, err
+		}
+	}
+	return data, nil
+}
+
+// scanStoredMessage reads one message history row
+func scanStoredMessage(row interface{ Scan(...interface{}) error }) (StoredMessage, error) {
+	var msg StoredMessage
+	var timestamp int64
+	err := row.Scan(&msg.ID, &msg.ChatJID, &msg.SenderJID, &msg.PushName, &msg.Text, &msg.MediaType, &timestamp)
+	if err == sql.ErrNoRows {
+		return msg, err
+	} else if err != nil {
+		return msg, fmt.Errorf("failed to scan message: %w", err)
+	}
+	msg.Timestamp = time.UnixMilli(timestamp)
+	return msg, nil
+}
+
+// messageText extracts the text or caption of a message
+func messageText(msg *waProto.Message) string {
+	switch {
+	case msg.GetConversation() != "":
+		return msg.GetConversation()
+	case msg.GetExtendedTextMessage() != nil:
+		return msg.GetExtendedTextMessage().GetText()
+	case msg.GetImageMessage() != nil:
+		return msg.GetImageMessage().GetCaption()
+	case msg.GetVideoMessage() != nil:
+		return msg.GetVideoMessage().GetCaption()
+	case msg.GetDocumentMessage() != nil:
+		return msg.GetDocumentMessage().GetCaption()
+	}
+	return ""
+}
+
+// messageMediaType names the kind of media attached to a message, if any
+func messageMediaType(msg *waProto.Message) string {
+	switch {
+	case msg.GetImageMessage() != nil:
+		return "image"
+	case msg.GetVideoMessage() != nil:
+		return "video"
+	case msg.GetAudioMessage() != nil:
+		return "audio"
+	case msg.GetDocumentMessage() != nil:
+		return "document"
+	case msg.GetStickerMessage() != nil:
+		return "sticker"
+	}
+	return ""
+}
@@ -0,0 +1,138 @@
+package whatsapp
+
+import (
+	"fmt"
+	"strings"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxButtons is the most reply buttons WhatsApp shows on a message
+const maxButtons = 3
+
+// Button is a quick-reply button. The ID comes back in the recipient's reply.
+type Button struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+}
+
+// SendButtons sends a message with 1 to 3 quick-reply buttons and returns its
+// message ID.
+//
+// WhatsApp's support for buttons varies by account type and client version:
+// messages from regular (non-business) accounts may be dropped or shown
+// without the buttons, so don't rely on them as the only way to reply.
+func (c *Client) SendButtons(recipient string, body string, buttons []Button) (string, error) {
+	content, err := buildButtonsMessage(body, buttons)
+	if err != nil {
+		return "", err
+	}
+	return c.sendContent(recipient, content)
+}
+
+// buildButtonsMessage validates and builds a buttons message
+func buildButtonsMessage(body string, buttons []Button) (*waProto.Message, error) {
+	if strings.TrimSpace(body) == "" {
+		return nil, fmt.Errorf("%w: body cannot be empty", ErrInvalidMessage)
+	}
+	if len(buttons) < 1 || len(buttons) > maxButtons {
+		return nil, fmt.Errorf("%w: need 1 to %d buttons, got %d", ErrInvalidMessage, maxButtons, len(buttons))
+	}
+
+	protoButtons := make([]*waProto.ButtonsMessage_Button, len(buttons))
+	for i, button := range buttons {
+		if strings.TrimSpace(button.ID) == "" || strings.TrimSpace(button.Text) == "" {
+			return nil, fmt.Errorf("%w: button %d needs an id and text", ErrInvalidMessage, i+1)
+		}
+		protoButtons[i] = &waProto.ButtonsMessage_Button{
+			ButtonID: proto.String(button.ID),
+			ButtonText: &waProto.ButtonsMessage_Button_ButtonText{
+				DisplayText: proto.String(button.Text),
+			},
+			Type: waProto.ButtonsMessage_Button_RESPONSE.Enum(),
+		}
+	}
+
+	return &waProto.Message{
+		ButtonsMessage: &waProto.ButtonsMessage{
+			ContentText: proto.String(body),
+			HeaderType:  waProto.ButtonsMessage_EMPTY.Enum(),
+			Buttons:     protoButtons,
+		},
+	}, nil
+}
+
+// ListSection is a titled group of rows in a list message
+type ListSection struct {
+	Title string    `json:"title"`
+	Rows  []ListRow `json:"rows"`
+}
+
+// ListRow is a selectable entry of a list message. The ID comes back in the
+// recipient's reply.
+type ListRow struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+}
+
+// SendList sends a list message, which opens a menu of rows grouped in
+// sections when buttonText is tapped, and returns its message ID. Like
+// buttons, list support varies by account.
+func (c *Client) SendList(recipient string, title string, description string, buttonText string, sections []ListSection) (string, error) {
+	content, err := buildListMessage(title, description, buttonText, sections)
+	if err != nil {
+		return "", err
+	}
+	return c.sendContent(recipient, content)
+}
+
+// buildListMessage validates and builds a list message
+func buildListMessage(title string, description string, buttonText string, sections []ListSection) (*waProto.Message, error) {
+	if strings.TrimSpace(buttonText) == "" {
+		return nil, fmt.Errorf("%w: button text cannot be empty", ErrInvalidMessage)
+	}
+	if len(sections) == 0 {
+		return nil, fmt.Errorf("%w: need at least one section", ErrInvalidMessage)
+	}
+
+	seen := make(map[string]bool)
+	protoSections := make([]*waProto.ListMessage_Section, len(sections))
+	for i, section := range sections {
+		if len(section.Rows) == 0 {
+			return nil, fmt.Errorf("%w: section %d has no rows", ErrInvalidMessage, i+1)
+		}
+		rows := make([]*waProto.ListMessage_Row, len(section.Rows))
+		for j, row := range section.Rows {
+			if strings.TrimSpace(row.ID) == "" || strings.TrimSpace(row.Title) == "" {
+				return nil, fmt.Errorf("%w: row %d of section %d needs an id and title", ErrInvalidMessage, j+1, i+1)
+			}
+			if seen[row.ID] {
+				return nil, fmt.Errorf("%w: duplicate row id %q", ErrInvalidMessage, row.ID)
+			}
+			seen[row.ID] = true
+			rows[j] = &waProto.ListMessage_Row{
+				RowID: proto.String(row.ID),
+				Title: proto.String(row.Title),
+			}
+			if row.Description != "" {
+				rows[j].Description = proto.String(row.Description)
+			}
+		}
+		protoSections[i] = &waProto.ListMessage_Section{
+			Title: proto.String(section.Title),
+			Rows:  rows,
+		}
+	}
+
+	return &waProto.Message{
+		ListMessage: &waProto.ListMessage{
+			Title:       proto.String(title),
+			Description: proto.String(description),
+			ButtonText:  proto.String(buttonText),
+			ListType:    waProto.ListMessage_SINGLE_SELECT.Enum(),
+			Sections:    protoSections,
+		},
+	}, nil
+}
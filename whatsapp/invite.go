@@ -0,0 +1,82 @@
+package whatsapp
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+)
+
+// ErrInvalidInviteLink is returned for malformed, unknown or revoked invite links
+var ErrInvalidInviteLink = errors.New("invalid group invite link")
+
+// inviteCodePattern matches the code part of a group invite link
+var inviteCodePattern = regexp.MustCompile(`^[A-Za-z0-9]{16,32}$`)
+
+// GetGroupInviteLink gets the invite link of a group. When reset is set the
+// current link is revoked and a new one is generated.
+func (c *Client) GetGroupInviteLink(groupJID string, reset bool) (string, error) {
+	jid, err := parseGroupJID(groupJID)
+	if err != nil {
+		return "", err
+	}
+
+	c.mutex.Lock()
+	c.lastActivity = time.Now()
+	c.mutex.Unlock()
+
+	if !c.client.IsLoggedIn() {
+		return "", ErrNotLoggedIn
+	}
+
+	link, err := c.client.GetGroupInviteLink(jid, reset)
+	if err != nil {
+		return "", fmt.Errorf("failed to get group invite link: %w", err)
+	}
+
+	return link, nil
+}
+
+// JoinGroupWithLink joins a group using an invite link or bare invite code and
+// returns the JID of the joined group
+func (c *Client) JoinGroupWithLink(link string) (string, error) {
+	code, err := parseInviteCode(link)
+	if err != nil {
+		return "", err
+	}
+
+	c.mutex.Lock()
+	c.lastActivity = time.Now()
+	c.mutex.Unlock()
+
+	if !c.client.IsLoggedIn() {
+		return "", ErrNotLoggedIn
+	}
+
+	jid, err := c.client.JoinGroupWithLink(code)
+	if errors.Is(err, whatsmeow.ErrInviteLinkInvalid) || errors.Is(err, whatsmeow.ErrInviteLinkRevoked) {
+		return "", fmt.Errorf("%w: %v", ErrInvalidInviteLink, err)
+	} else if err != nil {
+		return "", fmt.Errorf("failed to join group: %w", err)
+	}
+
+	return jid.String(), nil
+}
+
+// parseInviteCode extracts the invite code from a chat.whatsapp.com link or
+// checks a bare code
+func parseInviteCode(link string) (string, error) {
+	code := strings.TrimSpace(link)
+	code = strings.TrimPrefix(code, "http://")
+	code = strings.TrimPrefix(code, "https://")
+	code = strings.TrimPrefix(code, "chat.whatsapp.com/")
+	code = strings.TrimSuffix(code, "/")
+
+	if !inviteCodePattern.MatchString(code) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidInviteLink, link)
+	}
+	return code, nil
+}
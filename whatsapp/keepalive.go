@@ -0,0 +1,96 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+)
+
+// pingTimeout bounds how long the server may take to answer a ping
+const pingTimeout = 10 * time.Second
+
+// ping checks the connection is alive with a round trip to the server.
+// whatsmeow has no ping of its own, so this asks for the push notification
+// settings, which every account has. Any answer, including an error from the
+// server, proves the socket works.
+func (c *Client) ping() error {
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+
+	_, err := c.client.GetServerPushNotificationConfig(ctx)
+	var iqErr *whatsmeow.IQError
+	if err != nil && !errors.As(err, &iqErr) {
+		return err
+	}
+	return nil
+}
+
+// checkConnection pings a client that should be connected. A dead socket is
+// dropped and the client marked disconnected, which starts the reconnect
+// supervisor when auto-reconnect is on.
+func (c *Client) checkConnection() {
+	// Only one check at a time; a slow ping shouldn't pile up more
+	if !c.pinging.CompareAndSwap(false, true) {
+		return
+	}
+	defer c.pinging.Store(false)
+
+	// Clients that never logged in or were disconnected on purpose have
+	// nothing to check
+	c.mutex.RLock()
+	expected := c.status == StatusConnected
+	c.mutex.RUnlock()
+	if !expected || c.client.Store.ID == nil {
+		return
+	}
+
+	var err error
+	if !c.client.IsConnected() {
+		err = whatsmeow.ErrNotConnected
+	} else {
+		err = c.ping()
+	}
+	if err == nil {
+		c.mutex.Lock()
+		c.lastPing = time.Now()
+		c.mutex.Unlock()
+		return
+	}
+
+	slog.Warn("Connection check failed, reconnecting", "client", c.ID, "error", err)
+	c.events.add(LogEventError, "connection check failed: "+err.Error())
+
+	c.mutex.Lock()
+	// Disconnecting on purpose doesn't fire a Disconnected event, so the
+	// state change is made here
+	c.client.Disconnect()
+	c.status = StatusDisconnected
+	c.connectedSince = time.Time{}
+	c.connError = "connection check failed: " + err.Error()
+	c.notifyConnection(StatusDisconnected)
+	c.startReconnectLocked()
+	c.mutex.Unlock()
+
+	c.publishStatus()
+}
+
+// runConnectionChecks pings every client each interval until the manager is
+// closed
+func (cm *ClientManager) runConnectionChecks(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-cm.stop:
+			return
+		case <-ticker.C:
+			for _, client := range cm.snapshotClients() {
+				go client.checkConnection()
+			}
+		}
+	}
+}
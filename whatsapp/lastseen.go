@@ -0,0 +1,106 @@
+package whatsapp
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// lastSeenTimeout is how long GetLastSeen waits for WhatsApp to report a
+// contact's presence
+const lastSeenTimeout = 5 * time.Second
+
+// ErrPresenceUnavailable is returned when WhatsApp doesn't report a contact's
+// presence in time, usually because the contact hides it
+var ErrPresenceUnavailable = errors.New("presence not available for this contact")
+
+// presenceWaiters hands presence updates to callers waiting for a contact
+type presenceWaiters struct {
+	mutex   sync.Mutex
+	waiters map[types.JID][]chan *events.Presence
+}
+
+// newPresenceWaiters creates an empty set of presence waiters
+func newPresenceWaiters() *presenceWaiters {
+	return &presenceWaiters{
+		waiters: make(map[types.JID][]chan *events.Presence),
+	}
+}
+
+// wait registers for the next presence update of jid. The returned function
+// must be called once the caller stops waiting.
+func (w *presenceWaiters) wait(jid types.JID) (<-chan *events.Presence, func()) {
+	ch := make(chan *events.Presence, 1)
+
+	w.mutex.Lock()
+	w.waiters[jid] = append(w.waiters[jid], ch)
+	w.mutex.Unlock()
+
+	return ch, func() {
+		w.mutex.Lock()
+		defer w.mutex.Unlock()
+
+		waiting := w.waiters[jid]
+		for i, other := range waiting {
+			if other == ch {
+				waiting = append(waiting[:i:i], waiting[i+1:]...)
+				break
+			}
+		}
+		if len(waiting) == 0 {
+			delete(w.waiters, jid)
+		} else {
+			w.waiters[jid] = waiting
+		}
+	}
+}
+
+// record passes a presence update to everyone waiting for its sender
+func (w *presenceWaiters) record(evt *events.Presence) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	for _, ch := range w.waiters[evt.From.ToNonAD()] {
+		select {
+		case ch <- evt:
+		default:
+		}
+	}
+}
+
+// GetLastSeen subscribes to a contact's presence and waits briefly for
+// WhatsApp to report it. It returns when the contact was last seen and
+// whether they're online right now. The last-seen time is zero when the
+// contact is online or hides it in their privacy settings, and
+// ErrPresenceUnavailable is returned if no presence arrives at all.
+func (c *Client) GetLastSeen(jid string) (time.Time, bool, error) {
+	contact, err := c.parseRecipient(jid)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	if contact.Server == types.GroupServer {
+		return time.Time{}, false, fmt.Errorf("%w: last seen is only available for contacts", ErrInvalidRecipient)
+	}
+
+	// Register before subscribing so the reply to the subscription isn't missed
+	updates, done := c.presences.wait(contact.ToNonAD())
+	defer done()
+
+	if err := c.SubscribePresence(contact.String()); err != nil {
+		return time.Time{}, false, err
+	}
+
+	timer := time.NewTimer(lastSeenTimeout)
+	defer timer.Stop()
+
+	select {
+	case evt := <-updates:
+		return evt.LastSeen, !evt.Unavailable, nil
+	case <-timer.C:
+		return time.Time{}, false, ErrPresenceUnavailable
+	}
+}
@@ -0,0 +1,344 @@
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"golang.org/x/net/html"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// linkPreviewTimeout caps fetching a page and its image for a preview
+	linkPreviewTimeout = 5 * time.Second
+	// maxLinkPreviewPage caps how much of a page is read looking for its
+	// metadata
+	maxLinkPreviewPage = 512 << 10
+	// maxLinkPreviewImage caps the size of a preview image
+	maxLinkPreviewImage = 2 << 20
+	// linkThumbnailSize is the side preview thumbnails are scaled down to
+	linkThumbnailSize = 160
+	// maxLinkPreviewText caps the title and description
+	maxLinkPreviewText = 300
+)
+
+// linkPattern finds http(s) links in message text
+var linkPattern = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+// linkPreviewHTTPClient fetches pages for link previews. It only connects to
+// public addresses, so message text can't be used to probe the gateway's
+// own network. It never goes through a proxy, since the address check would
+// then see the proxy rather than the page's host.
+var linkPreviewHTTPClient = &http.Client{
+	Timeout: linkPreviewTimeout,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: linkPreviewTimeout,
+			Control: publicAddressOnly,
+		}).DialContext,
+		TLSHandshakeTimeout:   linkPreviewTimeout,
+		ResponseHeaderTimeout: linkPreviewTimeout,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 5 {
+			return errors.New("too many redirects")
+		}
+		return nil
+	},
+}
+
+// errNoLinkPreview is returned for pages without metadata to preview
+var errNoLinkPreview = errors.New("page has no title or description")
+
+// linkPreview is the OpenGraph metadata of a linked page
+type linkPreview struct {
+	URL         string
+	Title       string
+	Description string
+	ImageURL    string
+	// Thumbnail is a small JPEG of the page's image, if it has one
+	Thumbnail []byte
+}
+
+// publicAddressOnly refuses connections to loopback, private and other
+// non-public addresses
+func publicAddressOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !isPublicAddr(ip) {
+		return fmt.Errorf("refusing to connect to non-public address %s", ip.Unmap())
+	}
+	return nil
+}
+
+// isPublicAddr reports whether ip is a public unicast address
+func isPublicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsGlobalUnicast() && !ip.IsPrivate()
+}
+
+// findLink returns the first link in text, without trailing punctuation
+func findLink(text string) string {
+	link := linkPattern.FindString(text)
+	return strings.TrimRight(link, ".,;:!?)]}'")
+}
+
+// withLinkPreview returns msg with a preview of the first link in its text.
+// A preview that can't be fetched isn't an error: the message is sent
+// without one.
+func (c *Client) withLinkPreview(ctx context.Context, msg *waProto.Message) *waProto.Message {
+	text := msg.GetConversation()
+	if ext := msg.GetExtendedTextMessage(); ext != nil {
+		text = ext.GetText()
+	}
+	return applyLinkPreview(msg, c.fetchMessagePreview(ctx, text))
+}
+
+// linkPreviewFor fetches the preview for a text message that asks for one.
+// It's called before the message is queued, so a slow page holds up only
+// its own sender and not the client's other messages.
+func (c *Client) linkPreviewFor(ctx context.Context, message string, opts SendOptions) *linkPreview {
+	if !opts.LinkPreview {
+		return nil
+	}
+	return c.fetchMessagePreview(ctx, message)
+}
+
+// fetchMessagePreview fetches a preview of the first link in text. It
+// returns nil if there's no link or the preview can't be fetched.
+func (c *Client) fetchMessagePreview(ctx context.Context, text string) *linkPreview {
+	link := findLink(text)
+	if link == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, linkPreviewTimeout)
+	defer cancel()
+	preview, err := fetchLinkPreview(ctx, link)
+	if err != nil {
+		slog.Warn("Sending message without link preview", "client", c.ID, "url", link, "error", err)
+		return nil
+	}
+	return preview
+}
+
+// applyLinkPreview returns msg with preview attached. A nil preview leaves
+// msg unchanged.
+func applyLinkPreview(msg *waProto.Message, preview *linkPreview) *waProto.Message {
+	if preview == nil {
+		return msg
+	}
+
+	ext := msg.GetExtendedTextMessage()
+	if ext == nil {
+		ext = &waProto.ExtendedTextMessage{Text: proto.String(msg.GetConversation())}
+		msg = &waProto.Message{ExtendedTextMessage: ext}
+	}
+	ext.MatchedText = proto.String(preview.URL)
+	ext.PreviewType = waProto.ExtendedTextMessage_NONE.Enum()
+	if preview.Title != "" {
+		ext.Title = proto.String(preview.Title)
+	}
+	if preview.Description != "" {
+		ext.Description = proto.String(preview.Description)
+	}
+	if len(preview.Thumbnail) > 0 {
+		ext.JPEGThumbnail = preview.Thumbnail
+		ext.ThumbnailWidth = proto.Uint32(linkThumbnailSize)
+		ext.ThumbnailHeight = proto.Uint32(linkThumbnailSize)
+	}
+	return msg
+}
+
+// fetchLinkPreview reads a page's OpenGraph metadata and a thumbnail of its
+// image. A missing or broken image only leaves the thumbnail out.
+func fetchLinkPreview(ctx context.Context, link string) (*linkPreview, error) {
+	page, err := fetchLimited(ctx, link, maxLinkPreviewPage, "text/html")
+	if err != nil {
+		return nil, err
+	}
+
+	preview := parseLinkPreview(page.body)
+	if preview.Title == "" && preview.Description == "" {
+		return nil, errNoLinkPreview
+	}
+	preview.URL = link
+
+	if preview.ImageURL != "" {
+		// Image links are often relative to the page
+		if imageURL, err := page.url.Parse(preview.ImageURL); err == nil {
+			preview.Thumbnail, err = fetchThumbnail(ctx, imageURL.String())
+			if err != nil {
+				slog.Debug("Link preview without thumbnail", "url", link, "error", err)
+			}
+		}
+	}
+
+	return preview, nil
+}
+
+// fetchedPage is the start of a fetched document and where it ended up
+// after redirects
+type fetchedPage struct {
+	url  *url.URL
+	body []byte
+}
+
+// fetchLimited fetches up to limit bytes of a document whose content type
+// starts with mediaType
+func fetchLimited(ctx context.Context, link string, limit int64, mediaType string) (*fetchedPage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "WhatsApp/2 LinkPreview")
+
+	resp, err := linkPreviewHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !strings.HasPrefix(contentType, mediaType) {
+		return nil, fmt.Errorf("unexpected content type %q", contentType)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return nil, err
+	}
+	return &fetchedPage{url: resp.Request.URL, body: body}, nil
+}
+
+// parseLinkPreview reads the OpenGraph tags from a page's head, falling back
+// to its title and description meta tag
+func parseLinkPreview(page []byte) *linkPreview {
+	preview := &linkPreview{}
+	var title, description string
+
+	tokenizer := html.NewTokenizer(bytes.NewReader(page))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return finishLinkPreview(preview, title, description)
+		case html.EndTagToken:
+			if name, _ := tokenizer.TagName(); string(name) == "head" {
+				return finishLinkPreview(preview, title, description)
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			switch string(name) {
+			case "body":
+				return finishLinkPreview(preview, title, description)
+			case "title":
+				if title == "" && tokenizer.Next() == html.TextToken {
+					title = string(tokenizer.Text())
+				}
+			case "meta":
+				attrs := map[string]string{}
+				for hasAttr {
+					var key, value []byte
+					key, value, hasAttr = tokenizer.TagAttr()
+					attrs[string(key)] = string(value)
+				}
+				property := attrs["property"]
+				if property == "" {
+					property = attrs["name"]
+				}
+				switch strings.ToLower(property) {
+				case "og:title":
+					preview.Title = attrs["content"]
+				case "og:description":
+					preview.Description = attrs["content"]
+				case "og:image", "og:image:url":
+					if preview.ImageURL == "" {
+						preview.ImageURL = attrs["content"]
+					}
+				case "description":
+					description = attrs["content"]
+				}
+			}
+		}
+	}
+}
+
+// finishLinkPreview fills in what the OpenGraph tags left out and trims the
+// text to a sensible length
+func finishLinkPreview(preview *linkPreview, title, description string) *linkPreview {
+	if preview.Title == "" {
+		preview.Title = title
+	}
+	if preview.Description == "" {
+		preview.Description = description
+	}
+	preview.Title = truncateText(strings.Join(strings.Fields(preview.Title), " "), maxLinkPreviewText)
+	preview.Description = truncateText(strings.Join(strings.Fields(preview.Description), " "), maxLinkPreviewText)
+	return preview
+}
+
+// truncateText cuts text to at most limit runes, marking the cut
+func truncateText(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
+
+// fetchThumbnail downloads a preview image and turns it into a small square
+// JPEG
+func fetchThumbnail(ctx context.Context, link string) ([]byte, error) {
+	fetched, err := fetchLimited(ctx, link, maxLinkPreviewImage+1, "image/")
+	if err != nil {
+		return nil, err
+	}
+	if len(fetched.body) > maxLinkPreviewImage {
+		return nil, errors.New("image is too large")
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(fetched.body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	// Crop the centre square like profile pictures
+	bounds := img.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	crop := image.Rect(0, 0, side, side).Add(image.Pt(
+		bounds.Min.X+(bounds.Dx()-side)/2,
+		bounds.Min.Y+(bounds.Dy()-side)/2,
+	))
+	out := scaleSquare(img, crop, linkThumbnailSize)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, out, &jpeg.Options{Quality: avatarJPEGQuality}); err != nil {
+		return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,263 @@
+package whatsapp
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// mediaDir is the directory under a client's data directory holding media
+const mediaDir = "media"
+
+// mediaCleanupInterval is how often expired media is removed
+const mediaCleanupInterval = time.Hour
+
+// ErrMediaNotFound is returned when no media is stored for a message
+var ErrMediaNotFound = errors.New("media not found")
+
+// ErrNoMedia is returned when downloading a message that has no attachment
+var ErrNoMedia = errors.New("message has no downloadable media")
+
+// messageIDPattern matches message IDs that are safe to use as file names
+var messageIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// chatJIDPattern matches chat JIDs that are safe to use as directory names.
+// The @ keeps them from ever being . or ..
+var chatJIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+@[A-Za-z0-9.-]+$`)
+
+// MediaInfo describes a downloaded media file
+type MediaInfo struct {
+	MessageID string    `json:"message_id"`
+	ChatJID   string    `json:"chat_jid"`
+	MimeType  string    `json:"mime_type"`
+	FileName  string    `json:"file_name,omitempty"`
+	Size      int       `json:"size"`
+	SavedAt   time.Time `json:"saved_at"`
+}
+
+// WebhookMedia points a message webhook at the message's downloaded media
+type WebhookMedia struct {
+	// URL is the path of the media download endpoint, relative to the
+	// gateway's address
+	URL      string `json:"media_url"`
+	MimeType string `json:"mime_type"`
+	FileName string `json:"file_name,omitempty"`
+	Size     int    `json:"size"`
+	// Data is the media in base64 when it's no larger than the inline limit
+	Data string `json:"data,omitempty"`
+}
+
+// ContentType returns the content type to serve the media with
+func (m MediaInfo) ContentType() string {
+	if m.MimeType == "" {
+		return "application/octet-stream"
+	}
+	return m.MimeType
+}
+
+// DownloadMedia downloads the attachment of a received message
+func (c *Client) DownloadMedia(msg *events.Message) ([]byte, error) {
+	media, _, _ := downloadableMedia(msg.Message)
+	if media == nil {
+		return nil, ErrNoMedia
+	}
+
+	data, err := c.client.Download(context.Background(), media)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download media: %w", err)
+	}
+
+	return data, nil
+}
+
+// saveMedia downloads the attachment of a received message to disk and
+// returns it along with its details, or nil if it couldn't be saved
+func (c *Client) saveMedia(msg *events.Message) (*MediaInfo, []byte) {
+	_, mimeType, fileName := downloadableMedia(msg.Message)
+	chatJID := msg.Info.Chat.String()
+	dir, ok := c.mediaChatDir(chatJID, msg.Info.ID)
+	if !ok {
+		slog.Warn("Not saving media with unexpected chat or message ID", "client", c.ID, "chat", chatJID, "message_id", msg.Info.ID)
+		return nil, nil
+	}
+
+	data, err := c.DownloadMedia(msg)
+	if err != nil {
+		slog.Warn("Failed to download media", "client", c.ID, "message_id", msg.Info.ID, "error", err)
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		slog.Warn("Failed to create media directory", "client", c.ID, "error", err)
+		return nil, nil
+	}
+
+	info := MediaInfo{
+		MessageID: msg.Info.ID,
+		ChatJID:   chatJID,
+		MimeType:  mimeType,
+		FileName:  fileName,
+		Size:      len(data),
+		SavedAt:   time.Now(),
+	}
+	meta, err := json.Marshal(info)
+	if err != nil {
+		slog.Warn("Failed to marshal media info", "client", c.ID, "message_id", msg.Info.ID, "error", err)
+		return nil, nil
+	}
+
+	// The data file is written first so the metadata never points at nothing
+	if err := os.WriteFile(filepath.Join(dir, msg.Info.ID), data, 0644); err != nil {
+		slog.Warn("Failed to write media", "client", c.ID, "message_id", msg.Info.ID, "error", err)
+		return nil, nil
+	}
+	if err := os.WriteFile(filepath.Join(dir, msg.Info.ID+".json"), meta, 0644); err != nil {
+		slog.Warn("Failed to write media info", "client", c.ID, "message_id", msg.Info.ID, "error", err)
+		return nil, nil
+	}
+
+	return &info, data
+}
+
+// webhookMedia describes saved media for a message webhook, inlining it if
+// it's small enough. It returns nil for media that wasn't saved.
+func (c *Client) webhookMedia(info *MediaInfo, data []byte) *WebhookMedia {
+	if info == nil {
+		return nil
+	}
+
+	media := &WebhookMedia{
+		URL:      "/api/clients/" + url.PathEscape(c.ID) + "/media/" + url.PathEscape(info.ChatJID) + "/" + url.PathEscape(info.MessageID),
+		MimeType: info.MimeType,
+		FileName: info.FileName,
+		Size:     info.Size,
+	}
+	if c.inlineMediaLimit > 0 && info.Size <= c.inlineMediaLimit {
+		media.Data = base64.StdEncoding.EncodeToString(data)
+	}
+	return media
+}
+
+// mediaChatDir returns the directory holding a chat's media. Message IDs are
+// only unique within a chat, so media is stored per chat. ok is false if
+// either ID isn't safe to use in a path.
+func (c *Client) mediaChatDir(chatJID string, messageID string) (dir string, ok bool) {
+	if !chatJIDPattern.MatchString(chatJID) || !messageIDPattern.MatchString(messageID) {
+		return "", false
+	}
+	return filepath.Join(c.dataDir, mediaDir, chatJID), true
+}
+
+// GetMedia returns the path and details of the stored media for a message
+// in a chat
+func (c *Client) GetMedia(chatJID string, messageID string) (string, MediaInfo, error) {
+	var info MediaInfo
+	dir, ok := c.mediaChatDir(chatJID, messageID)
+	if !ok {
+		return "", info, ErrMediaNotFound
+	}
+
+	meta, err := os.ReadFile(filepath.Join(dir, messageID+".json"))
+	if os.IsNotExist(err) {
+		return "", info, ErrMediaNotFound
+	} else if err != nil {
+		return "", info, fmt.Errorf("failed to read media info: %w", err)
+	}
+	if err := json.Unmarshal(meta, &info); err != nil {
+		return "", info, fmt.Errorf("failed to parse media info: %w", err)
+	}
+
+	return filepath.Join(dir, messageID), info, nil
+}
+
+// cleanupMedia removes stored media older than retention, along with chat
+// directories left empty
+func (c *Client) cleanupMedia(retention time.Duration) {
+	dir := filepath.Join(c.dataDir, mediaDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Warn("Failed to read media directory", "client", c.ID, "error", err)
+		}
+		return
+	}
+
+	cutoff := time.Now().Add(-retention)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			// Media saved before it was kept per chat
+			removeExpiredMedia(c.ID, dir, entry, cutoff)
+			continue
+		}
+
+		chatDir := filepath.Join(dir, entry.Name())
+		files, err := os.ReadDir(chatDir)
+		if err != nil {
+			slog.Warn("Failed to read media directory", "client", c.ID, "chat", entry.Name(), "error", err)
+			continue
+		}
+		for _, file := range files {
+			removeExpiredMedia(c.ID, chatDir, file, cutoff)
+		}
+		// Only succeeds once the directory is empty
+		os.Remove(chatDir)
+	}
+}
+
+// removeExpiredMedia removes a media file last modified before cutoff
+func removeExpiredMedia(clientID string, dir string, entry os.DirEntry, cutoff time.Time) {
+	info, err := entry.Info()
+	if err != nil || info.IsDir() || !info.ModTime().Before(cutoff) {
+		return
+	}
+	if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil {
+		slog.Warn("Failed to remove expired media", "client", clientID, "file", entry.Name(), "error", err)
+	}
+}
+
+// downloadableMedia returns the downloadable part of a message along with
+// its mime type and original file name, or nil if there isn't one
+func downloadableMedia(msg *waProto.Message) (whatsmeow.DownloadableMessage, string, string) {
+	switch {
+	case msg.GetImageMessage() != nil:
+		return msg.GetImageMessage(), msg.GetImageMessage().GetMimetype(), ""
+	case msg.GetVideoMessage() != nil:
+		return msg.GetVideoMessage(), msg.GetVideoMessage().GetMimetype(), ""
+	case msg.GetAudioMessage() != nil:
+		return msg.GetAudioMessage(), msg.GetAudioMessage().GetMimetype(), ""
+	case msg.GetDocumentMessage() != nil:
+		return msg.GetDocumentMessage(), msg.GetDocumentMessage().GetMimetype(), msg.GetDocumentMessage().GetFileName()
+	case msg.GetStickerMessage() != nil:
+		return msg.GetStickerMessage(), msg.GetStickerMessage().GetMimetype(), ""
+	}
+	return nil, "", ""
+}
+
+// runMediaCleanup periodically removes expired media for all clients
+func (cm *ClientManager) runMediaCleanup() {
+	ticker := time.NewTicker(mediaCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-cm.stop:
+			return
+		case <-ticker.C:
+			for _, client := range cm.snapshotClients() {
+				client.cleanupMedia(cm.options().MediaRetention)
+			}
+		}
+	}
+}
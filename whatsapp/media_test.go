@@ -0,0 +1,65 @@
+package whatsapp
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetMediaPerChat(t *testing.T) {
+	c := newTestClient(t, Options{})
+
+	// The same message ID in two chats refers to two different files
+	chats := []string{"6281234567890@s.whatsapp.net", "120363000000000000@g.us"}
+	for _, chat := range chats {
+		dir, ok := c.mediaChatDir(chat, "3EB0C767D26A8B4A")
+		if !ok {
+			t.Fatalf("mediaChatDir(%q) rejected a valid chat", chat)
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		meta, _ := json.Marshal(MediaInfo{MessageID: "3EB0C767D26A8B4A", ChatJID: chat})
+		if err := os.WriteFile(filepath.Join(dir, "3EB0C767D26A8B4A"), []byte(chat), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "3EB0C767D26A8B4A.json"), meta, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, chat := range chats {
+		path, info, err := c.GetMedia(chat, "3EB0C767D26A8B4A")
+		if err != nil {
+			t.Fatalf("GetMedia(%q): %v", chat, err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != chat || info.ChatJID != chat {
+			t.Errorf("GetMedia(%q) returned the media of %q", chat, data)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		chat      string
+		messageID string
+	}{
+		{name: "unknown chat", chat: "6289876543210@s.whatsapp.net", messageID: "3EB0C767D26A8B4A"},
+		{name: "no chat", messageID: "3EB0C767D26A8B4A"},
+		{name: "parent chat", chat: "..", messageID: "3EB0C767D26A8B4A"},
+		{name: "chat with a separator", chat: "a/b@s.whatsapp.net", messageID: "3EB0C767D26A8B4A"},
+		{name: "message with a separator", chat: chats[0], messageID: "../3EB0C767D26A8B4A"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := c.GetMedia(tt.chat, tt.messageID); !errors.Is(err, ErrMediaNotFound) {
+				t.Errorf("GetMedia(%q, %q) error = %v, want ErrMediaNotFound", tt.chat, tt.messageID, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package whatsapp
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// maxMentions caps the users one message can mention
+const maxMentions = 256
+
+// parseMentions turns the phone numbers or user JIDs to mention into JIDs.
+// Only users can be mentioned, not groups.
+func (c *Client) parseMentions(mentions []string) ([]types.JID, error) {
+	if len(mentions) > maxMentions {
+		return nil, fmt.Errorf("%w: at most %d mentions are allowed", ErrInvalidMessage, maxMentions)
+	}
+
+	jids := make([]types.JID, 0, len(mentions))
+	seen := make(map[types.JID]bool, len(mentions))
+	for _, mention := range mentions {
+		jid, err := c.parseRecipient(strings.TrimSpace(mention))
+		if err != nil {
+			return nil, fmt.Errorf("invalid mention %q: %w", mention, err)
+		}
+		if jid.Server == types.GroupServer {
+			return nil, fmt.Errorf("%w: mention %q is a group, not a user", ErrInvalidRecipient, mention)
+		}
+		jid = jid.ToNonAD()
+		if !seen[jid] {
+			seen[jid] = true
+			jids = append(jids, jid)
+		}
+	}
+	return jids, nil
+}
+
+// withMentionTokens puts an @number token for each mentioned user that the
+// text doesn't tag yet in front of it. WhatsApp only highlights mentions
+// whose token appears in the text.
+func withMentionTokens(text string, mentioned []types.JID) string {
+	var missing []string
+	for _, jid := range mentioned {
+		if !hasMentionToken(text, jid.User) {
+			missing = append(missing, "@"+jid.User)
+		}
+	}
+	if len(missing) == 0 {
+		return text
+	}
+	return strings.Join(missing, " ") + " " + text
+}
+
+// hasMentionToken reports whether text contains @user on its own, so @123
+// isn't taken for a tag of @1234
+func hasMentionToken(text string, user string) bool {
+	token := "@" + user
+	for rest := text; ; {
+		i := strings.Index(rest, token)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(token):]
+		if rest == "" || !unicode.IsDigit(rune(rest[0])) {
+			return true
+		}
+	}
+}
+
+// mentionContext adds the mentioned users to a message's context info,
+// creating it if needed. Without mentions, contextInfo is returned as is.
+func mentionContext(contextInfo *waProto.ContextInfo, mentioned []types.JID) *waProto.ContextInfo {
+	if len(mentioned) == 0 {
+		return contextInfo
+	}
+	if contextInfo == nil {
+		contextInfo = &waProto.ContextInfo{}
+	}
+	for _, jid := range mentioned {
+		contextInfo.MentionedJID = append(contextInfo.MentionedJID, jid.String())
+	}
+	return contextInfo
+}
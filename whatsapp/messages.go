@@ -0,0 +1,87 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// editWindow is how long after sending a message can still be edited
+const editWindow = 15 * time.Minute
+
+// ErrEditWindowExpired is returned when editing a message sent too long ago
+var ErrEditWindowExpired = errors.New("messages can only be edited within 15 minutes of sending")
+
+// RevokeMessage deletes a message sent by this client for everyone in the chat
+func (c *Client) RevokeMessage(chatJID, messageID string) error {
+	if messageID == "" {
+		return errors.New("message ID cannot be empty")
+	}
+
+	chat, err := c.parseRecipient(chatJID)
+	if err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	c.lastActivity = time.Now()
+	c.mutex.Unlock()
+
+	if !c.client.IsLoggedIn() {
+		return ErrNotLoggedIn
+	}
+
+	// An empty sender revokes our own message
+	msg := c.client.BuildRevoke(chat, types.EmptyJID, messageID)
+	if _, err := c.client.SendMessage(context.Background(), chat, msg); err != nil {
+		return fmt.Errorf("failed to revoke message: %w", err)
+	}
+
+	return nil
+}
+
+// EditMessage replaces the text of a message sent by this client. Messages
+// the client knows were sent more than 15 minutes ago are rejected; for
+// older ones it has no record of, such as those sent before a restart, it's
+// left to WhatsApp.
+func (c *Client) EditMessage(chatJID, messageID, newText string) error {
+	if messageID == "" {
+		return errors.New("message ID cannot be empty")
+	}
+	if newText == "" {
+		return fmt.Errorf("%w: message cannot be empty", ErrInvalidMessage)
+	}
+
+	chat, err := c.parseRecipient(chatJID)
+	if err != nil {
+		return err
+	}
+
+	if receipt, ok := c.receipts.get(messageID); ok && receipt.SentAt != nil {
+		if time.Since(*receipt.SentAt) > editWindow {
+			return ErrEditWindowExpired
+		}
+	}
+
+	c.mutex.Lock()
+	c.lastActivity = time.Now()
+	c.mutex.Unlock()
+
+	if !c.client.IsLoggedIn() {
+		return ErrNotLoggedIn
+	}
+
+	msg := c.client.BuildEdit(chat, messageID, &waProto.Message{
+		Conversation: proto.String(newText),
+	})
+	if _, err := c.client.SendMessage(context.Background(), chat, msg); err != nil {
+		return fmt.Errorf("failed to edit message: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package whatsapp
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Limits on the labels and metadata kept for a client
+const (
+	maxClientLabels     = 50
+	maxLabelLength      = 64
+	maxMetadataEntries  = 50
+	maxMetadataKeyLen   = 64
+	maxMetadataValueLen = 1024
+)
+
+// ErrInvalidMetadata is returned for labels or metadata that break the limits
+var ErrInvalidMetadata = errors.New("invalid metadata")
+
+// MetadataUpdate changes a client's labels and metadata. Labels replace the
+// current ones when set. Metadata entries are merged into the current ones;
+// a nil value removes the key.
+type MetadataUpdate struct {
+	Labels   *[]string          `json:"labels"`
+	Metadata map[string]*string `json:"metadata"`
+}
+
+// HasLabel reports whether the client carries the given label
+func (s ClientState) HasLabel(label string) bool {
+	return slices.Contains(s.Labels, label)
+}
+
+// UpdateMetadata applies an update to the client's labels and metadata and
+// saves them with the rest of the client state. They're only bookkeeping for
+// organizing clients and have no effect on WhatsApp.
+func (c *Client) UpdateMetadata(update MetadataUpdate) (ClientState, error) {
+	c.mutex.Lock()
+	previousLabels, previousMetadata := c.labels, c.metadata
+
+	labels := c.labels
+	if update.Labels != nil {
+		var err error
+		if labels, err = normalizeLabels(*update.Labels); err != nil {
+			c.mutex.Unlock()
+			return ClientState{}, err
+		}
+	}
+
+	metadata := maps.Clone(c.metadata)
+	for key, value := range update.Metadata {
+		if value == nil {
+			delete(metadata, key)
+			continue
+		}
+		if metadata == nil {
+			metadata = make(map[string]string)
+		}
+		metadata[key] = *value
+	}
+	if err := validateMetadata(metadata); err != nil {
+		c.mutex.Unlock()
+		return ClientState{}, err
+	}
+
+	c.labels, c.metadata = labels, metadata
+	c.mutex.Unlock()
+
+	if err := c.SaveState(); err != nil {
+		c.mutex.Lock()
+		c.labels, c.metadata = previousLabels, previousMetadata
+		c.mutex.Unlock()
+		return ClientState{}, err
+	}
+
+	return c.GetState(), nil
+}
+
+// normalizeLabels trims labels and drops duplicates, keeping their order
+func normalizeLabels(labels []string) ([]string, error) {
+	normalized := make([]string, 0, len(labels))
+	for _, label := range labels {
+		label = strings.TrimSpace(label)
+		if label == "" {
+			return nil, fmt.Errorf("%w: labels cannot be empty", ErrInvalidMetadata)
+		}
+		if len(label) > maxLabelLength {
+			return nil, fmt.Errorf("%w: label %q is longer than %d characters", ErrInvalidMetadata, label, maxLabelLength)
+		}
+		if !slices.Contains(normalized, label) {
+			normalized = append(normalized, label)
+		}
+	}
+	if len(normalized) > maxClientLabels {
+		return nil, fmt.Errorf("%w: at most %d labels are allowed", ErrInvalidMetadata, maxClientLabels)
+	}
+	return normalized, nil
+}
+
+// validateMetadata checks metadata against the size limits
+func validateMetadata(metadata map[string]string) error {
+	if len(metadata) > maxMetadataEntries {
+		return fmt.Errorf("%w: at most %d metadata entries are allowed", ErrInvalidMetadata, maxMetadataEntries)
+	}
+	for key, value := range metadata {
+		if key == "" || len(key) > maxMetadataKeyLen {
+			return fmt.Errorf("%w: metadata keys must be 1 to %d characters", ErrInvalidMetadata, maxMetadataKeyLen)
+		}
+		if len(value) > maxMetadataValueLen {
+			return fmt.Errorf("%w: value of %q is longer than %d characters", ErrInvalidMetadata, key, maxMetadataValueLen)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package whatsapp
+
+import (
+	"errors"
+	"log/slog"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metricsNamespace prefixes every metric exported by the gateway
+const metricsNamespace = "whatsapp_gateway"
+
+var (
+	messagesSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "messages_sent_total",
+		Help:      "Messages sent successfully.",
+	}, []string{"client"})
+
+	sendFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "send_failures_total",
+		Help:      "Messages that failed to send.",
+	}, []string{"client"})
+
+	qrGenerations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "qr_generations_total",
+		Help:      "QR codes handed out for login.",
+	}, []string{"client"})
+)
+
+// messagesSentSinceStart counts messages sent by all clients, including
+// deleted ones, since the gateway started
+var messagesSentSinceStart atomic.Int64
+
+func init() {
+	prometheus.MustRegister(messagesSent, sendFailures, qrGenerations)
+}
+
+// recordSend counts the outcome of a send attempt
+func recordSend(clientID string, err error) {
+	if err != nil {
+		sendFailures.WithLabelValues(clientID).Inc()
+		return
+	}
+	messagesSent.WithLabelValues(clientID).Inc()
+	messagesSentSinceStart.Add(1)
+}
+
+// forgetClientMetrics drops the per-client series of a deleted client
+func forgetClientMetrics(clientID string) {
+	messagesSent.DeleteLabelValues(clientID)
+	sendFailures.DeleteLabelValues(clientID)
+	qrGenerations.DeleteLabelValues(clientID)
+}
+
+// managerCollector reports client gauges straight from the manager on each
+// scrape, so they can't drift from the actual client state
+type managerCollector struct {
+	cm              *ClientManager
+	clientsDesc     *prometheus.Desc
+	connectedDesc   *prometheus.Desc
+	clientConnected *prometheus.Desc
+}
+
+// Describe implements prometheus.Collector
+func (m *managerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.clientsDesc
+	ch <- m.connectedDesc
+	ch <- m.clientConnected
+}
+
+// Collect implements prometheus.Collector
+func (m *managerCollector) Collect(ch chan<- prometheus.Metric) {
+	clients := m.cm.snapshotClients()
+	connected := 0
+	for _, client := range clients {
+		value := 0.0
+		if client.client.IsConnected() {
+			connected++
+			value = 1
+		}
+		ch <- prometheus.MustNewConstMetric(m.clientConnected, prometheus.GaugeValue, value, client.ID)
+	}
+	ch <- prometheus.MustNewConstMetric(m.clientsDesc, prometheus.GaugeValue, float64(len(clients)))
+	ch <- prometheus.MustNewConstMetric(m.connectedDesc, prometheus.GaugeValue, float64(connected))
+}
+
+// registerMetrics registers the manager's gauges with the default registry
+func (cm *ClientManager) registerMetrics() {
+	collector := &managerCollector{
+		cm: cm,
+		clientsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "", "clients"),
+			"Clients managed by the gateway.", nil, nil),
+		connectedDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "", "clients_connected"),
+			"Clients currently connected to WhatsApp.", nil, nil),
+		clientConnected: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "", "client_connected"),
+			"Whether a client is connected to WhatsApp (1) or not (0).", []string{"client"}, nil),
+	}
+
+	if err := prometheus.Register(collector); err != nil {
+		var already prometheus.AlreadyRegisteredError
+		if !errors.As(err, &already) {
+			slog.Warn("Failed to register client metrics", "error", err)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package whatsapp
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// ErrClientOutdated is returned when WhatsApp rejects the client because the
+// WhatsApp Web version built into whatsmeow is too old
+var ErrClientOutdated = errors.New("WhatsApp Web client outdated, update the whatsmeow dependency")
+
+// GatewayStatus reports problems that affect every client of the gateway
+type GatewayStatus struct {
+	// WAVersion is the WhatsApp Web version the gateway identifies as
+	WAVersion string `json:"wa_version"`
+	// ClientOutdated is set once WhatsApp has rejected that version. It
+	// stays set until restart since only a new build can fix it.
+	ClientOutdated bool       `json:"client_outdated"`
+	OutdatedSince  *time.Time `json:"outdated_since,omitempty"`
+	// OutdatedClient is the client that was rejected first
+	OutdatedClient string `json:"outdated_client,omitempty"`
+}
+
+// outdatedFlag records the first time WhatsApp rejected the client version
+type outdatedFlag struct {
+	mutex    sync.Mutex
+	since    time.Time
+	clientID string
+}
+
+// isOutdatedEvent reports whether a whatsmeow event means WhatsApp rejected
+// the client version
+func isOutdatedEvent(evt interface{}) bool {
+	switch e := evt.(type) {
+	case *events.ClientOutdated:
+		return true
+	case *events.ConnectFailure:
+		return e.Reason == events.ConnectFailureClientOutdated
+	}
+	return false
+}
+
+// markOutdated flags the gateway as outdated after a client was rejected
+func (cm *ClientManager) markOutdated(clientID string) {
+	f := &cm.outdated
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if !f.since.IsZero() {
+		return
+	}
+	f.since = time.Now()
+	f.clientID = clientID
+	slog.Warn("WhatsApp rejected the client version as outdated; sends and logins will fail until whatsmeow is updated",
+		"client", clientID, "wa_version", store.GetWAVersion().String())
+}
+
+// GatewayStatus returns the state of the gateway as a whole
+func (cm *ClientManager) GatewayStatus() GatewayStatus {
+	f := &cm.outdated
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	status := GatewayStatus{WAVersion: store.GetWAVersion().String()}
+	if !f.since.IsZero() {
+		since := f.since
+		status.ClientOutdated = true
+		status.OutdatedSince = &since
+		status.OutdatedClient = f.clientID
+	}
+	return status
+}
+
+// reportOutdated records that this client was rejected as outdated and
+// tells the manager
+func (c *Client) reportOutdated() {
+	c.outdated.Store(true)
+	if c.onOutdated != nil {
+		c.onOutdated(c.ID)
+	}
+}
@@ -0,0 +1,54 @@
+package whatsapp
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// minPhoneDigits is the shortest plausible international number,
+	// country code included
+	minPhoneDigits = 8
+	// maxPhoneDigits is the longest number E.164 allows
+	maxPhoneDigits = 15
+)
+
+// NormalizePhoneNumber turns a phone number as users tend to type it into
+// international digits without a leading +. Spaces, dashes, dots and
+// parentheses are dropped. Numbers starting with + or 00 are taken as
+// international; numbers starting with a single 0 are national and get
+// defaultCountryCode in place of the 0. Anything else is assumed to already
+// start with a country code.
+func NormalizePhoneNumber(number string, defaultCountryCode string) (string, error) {
+	original := number
+	number = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(number))
+
+	switch {
+	case strings.HasPrefix(number, "+"):
+		number = number[1:]
+	case strings.HasPrefix(number, "00"):
+		number = number[2:]
+	case strings.HasPrefix(number, "0"):
+		if defaultCountryCode == "" {
+			return "", fmt.Errorf("%w: %q has no country code", ErrInvalidRecipient, original)
+		}
+		number = defaultCountryCode + number[1:]
+	}
+
+	for _, r := range number {
+		if r < '0' || r > '9' {
+			return "", fmt.Errorf("%w: %q is not a phone number", ErrInvalidRecipient, original)
+		}
+	}
+	if len(number) < minPhoneDigits || len(number) > maxPhoneDigits {
+		return "", fmt.Errorf("%w: %q should have %d to %d digits including the country code", ErrInvalidRecipient, original, minPhoneDigits, maxPhoneDigits)
+	}
+
+	return number, nil
+}
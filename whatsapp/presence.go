@@ -0,0 +1,132 @@
+package whatsapp
+
+import (
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// SendPresence shows or clears the "typing…" indicator in a chat
+func (c *Client) SendPresence(chatJID string, composing bool) error {
+	jid, err := c.parseRecipient(chatJID)
+	if err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	c.lastActivity = time.Now()
+	c.mutex.Unlock()
+
+	if !c.client.IsLoggedIn() {
+		return ErrNotLoggedIn
+	}
+
+	state := types.ChatPresencePaused
+	if composing {
+		state = types.ChatPresenceComposing
+	}
+	if err := c.client.SendChatPresence(jid, state, types.ChatPresenceMediaText); err != nil {
+		return fmt.Errorf("failed to send chat presence: %w", err)
+	}
+
+	return nil
+}
+
+// SetPresence marks the account as globally online or offline
+func (c *Client) SetPresence(available bool) error {
+	c.mutex.Lock()
+	c.lastActivity = time.Now()
+	c.mutex.Unlock()
+
+	if !c.client.IsLoggedIn() {
+		return ErrNotLoggedIn
+	}
+
+	presence := types.PresenceUnavailable
+	if available {
+		presence = types.PresenceAvailable
+	}
+	if err := c.client.SendPresence(presence); err != nil {
+		return fmt.Errorf("failed to send presence: %w", err)
+	}
+
+	return nil
+}
+
+// PresenceEvent is the data of a presence webhook
+type PresenceEvent struct {
+	From     string     `json:"from"`
+	Online   bool       `json:"online"`
+	LastSeen *time.Time `json:"last_seen,omitempty"`
+}
+
+// ChatPresenceEvent is the data of a chat presence (typing) webhook
+type ChatPresenceEvent struct {
+	ChatJID   string `json:"chat_jid"`
+	SenderJID string `json:"sender_jid"`
+	State     string `json:"state"`
+	Media     string `json:"media,omitempty"`
+}
+
+// SubscribePresence asks WhatsApp for a contact's online and typing updates.
+// WhatsApp only sends presence for contacts the session subscribed to, and
+// the subscription is lost on reconnect, so it has to be renewed after the
+// client reconnects. Updates are delivered through the webhook.
+func (c *Client) SubscribePresence(contactJID string) error {
+	jid, err := c.parseRecipient(contactJID)
+	if err != nil {
+		return err
+	}
+	if jid.Server == types.GroupServer {
+		return fmt.Errorf("%w: presence can only be subscribed for contacts", ErrInvalidRecipient)
+	}
+
+	c.mutex.Lock()
+	c.lastActivity = time.Now()
+	c.mutex.Unlock()
+
+	if !c.client.IsLoggedIn() {
+		return ErrNotLoggedIn
+	}
+
+	if err := c.client.SubscribePresence(jid); err != nil {
+		return fmt.Errorf("failed to subscribe to presence: %w", err)
+	}
+
+	return nil
+}
+
+// notifyPresence forwards a contact's presence update to the webhook
+func (c *Client) notifyPresence(evt *events.Presence) {
+	data := PresenceEvent{
+		From:   evt.From.String(),
+		Online: !evt.Unavailable,
+	}
+	if !evt.LastSeen.IsZero() {
+		data.LastSeen = &evt.LastSeen
+	}
+
+	c.hook.Load().send(WebhookPayload{
+		Event:     WebhookEventPresence,
+		ClientID:  c.ID,
+		Timestamp: time.Now(),
+		Data:      data,
+	})
+}
+
+// notifyChatPresence forwards a typing update to the webhook
+func (c *Client) notifyChatPresence(evt *events.ChatPresence) {
+	c.hook.Load().send(WebhookPayload{
+		Event:     WebhookEventChatPresence,
+		ClientID:  c.ID,
+		Timestamp: time.Now(),
+		Data: ChatPresenceEvent{
+			ChatJID:   evt.Chat.String(),
+			SenderJID: evt.Sender.ToNonAD().String(),
+			State:     string(evt.State),
+			Media:     string(evt.Media),
+		},
+	})
+}
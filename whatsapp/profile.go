@@ -0,0 +1,111 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow/appstate"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// maxStatusLength is the longest "about" text WhatsApp accepts
+const maxStatusLength = 139
+
+// Profile is the client's own public profile
+type Profile struct {
+	PushName string `json:"push_name"`
+	Status   string `json:"status"`
+}
+
+// GetPushName returns the client's display name
+func (c *Client) GetPushName() string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.deviceStore.PushName
+}
+
+// SetPushName changes the client's display name. It is synced to the other
+// linked devices and saved in the device store, so GetState reflects it right
+// away.
+func (c *Client) SetPushName(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("%w: push name cannot be empty", ErrInvalidMessage)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.lastActivity = time.Now()
+
+	if !c.client.IsLoggedIn() {
+		return ErrNotLoggedIn
+	}
+
+	ctx := context.Background()
+	if err := c.client.SendAppState(ctx, appstate.BuildSettingPushName(name)); err != nil {
+		return fmt.Errorf("failed to set push name: %w", err)
+	}
+
+	c.deviceStore.PushName = name
+	if err := c.deviceStore.Save(ctx); err != nil {
+		return fmt.Errorf("failed to save push name: %w", err)
+	}
+
+	return nil
+}
+
+// GetStatus returns the client's "about" text
+func (c *Client) GetStatus() (string, error) {
+	c.mutex.Lock()
+	c.lastActivity = time.Now()
+	c.mutex.Unlock()
+
+	if !c.client.IsLoggedIn() || c.client.Store.ID == nil {
+		return "", ErrNotLoggedIn
+	}
+
+	own := c.client.Store.ID.ToNonAD()
+	info, err := c.client.GetUserInfo([]types.JID{own})
+	if err != nil {
+		return "", fmt.Errorf("failed to get status: %w", err)
+	}
+
+	return info[own].Status, nil
+}
+
+// SetStatus changes the client's "about" text
+func (c *Client) SetStatus(text string) error {
+	if len([]rune(text)) > maxStatusLength {
+		return fmt.Errorf("%w: status is longer than %d characters", ErrInvalidMessage, maxStatusLength)
+	}
+
+	c.mutex.Lock()
+	c.lastActivity = time.Now()
+	c.mutex.Unlock()
+
+	if !c.client.IsLoggedIn() {
+		return ErrNotLoggedIn
+	}
+
+	if err := c.client.SetStatusMessage(text); err != nil {
+		return fmt.Errorf("failed to set status: %w", err)
+	}
+
+	return nil
+}
+
+// GetProfile returns the client's push name and status
+func (c *Client) GetProfile() (Profile, error) {
+	status, err := c.GetStatus()
+	if err != nil {
+		return Profile{}, err
+	}
+
+	return Profile{
+		PushName: c.GetPushName(),
+		Status:   status,
+	}, nil
+}
@@ -0,0 +1,68 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// qrStopTimeout bounds the wait for a replaced QR login to wind down
+const qrStopTimeout = 5 * time.Second
+
+// qrCodeTimeout bounds the wait for the first code of a QR login
+const qrCodeTimeout = 30 * time.Second
+
+// ErrQRSuperseded is returned to a QR request that was replaced by a newer
+// one before a code arrived
+var ErrQRSuperseded = errors.New("QR request replaced by a newer one")
+
+// qrAttempt is a QR login in progress, from requesting the QR channel until
+// the channel is closed
+type qrAttempt struct {
+	// ctx is cancelled when the attempt is abandoned or replaced
+	ctx    context.Context
+	cancel context.CancelFunc
+	// done is closed once the attempt's QR channel is no longer read
+	done chan struct{}
+}
+
+// replaceQRAttemptLocked cancels the QR login in progress, if any, and waits
+// for it to wind down, so a new one can start on a clean connection. Must
+// hold c.mutex; it is released while waiting.
+func (c *Client) replaceQRAttemptLocked() {
+	// Another request may start an attempt while the lock is released
+	for c.qrAttempt != nil {
+		attempt := c.qrAttempt
+		c.qrAttempt = nil
+		c.mutex.Unlock()
+
+		attempt.cancel()
+		select {
+		case <-attempt.done:
+		case <-time.After(qrStopTimeout):
+			slog.Warn("Previous QR request didn't stop in time", "client", c.ID)
+		}
+
+		c.mutex.Lock()
+	}
+}
+
+// dropQRHandlers removes the event handlers of earlier QR channels.
+// whatsmeow only drops a channel's handler once the channel sees a final
+// event, so a channel that was abandoned before that would pick up the
+// events of the next attempt. Only the client's own handler is kept.
+func (c *Client) dropQRHandlers() {
+	c.client.RemoveEventHandlers()
+	c.client.AddEventHandler(c.handleEvent)
+}
+
+// finishQRAttempt marks a QR login as over
+func (c *Client) finishQRAttempt(attempt *qrAttempt) {
+	c.mutex.Lock()
+	if c.qrAttempt == attempt {
+		c.qrAttempt = nil
+	}
+	c.mutex.Unlock()
+	close(attempt.done)
+}
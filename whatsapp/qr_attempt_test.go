@@ -0,0 +1,156 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.uber.org/goleak"
+)
+
+// startQRAttempt runs a QR request in the background, whose connection
+// hands out qrChan. It returns once the request's attempt is registered.
+func startQRAttempt(c *Client, ctx context.Context, qrChan <-chan whatsmeow.QRChannelItem) <-chan error {
+	connected := make(chan struct{})
+	connect := func(context.Context) (<-chan whatsmeow.QRChannelItem, error) {
+		close(connected)
+		return qrChan, nil
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := c.generateQR(ctx, connect)
+		errs <- err
+	}()
+
+	// connect runs under the client's lock, which is only released once the
+	// attempt is registered
+	select {
+	case <-connected:
+		c.mutex.Lock()
+		c.mutex.Unlock()
+	case err := <-errs:
+		errs <- err
+	}
+	return errs
+}
+
+func TestAwaitQRCodeStopsWithContext(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     func() (context.Context, context.CancelFunc)
+		cancel  bool
+		wantErr error
+	}{
+		{
+			name:    "cancelled",
+			ctx:     func() (context.Context, context.CancelFunc) { return context.WithCancel(context.Background()) },
+			cancel:  true,
+			wantErr: context.Canceled,
+		},
+		{
+			name: "deadline",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 50*time.Millisecond)
+			},
+			wantErr: context.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, Options{})
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			// The channel never delivers a code
+			errs := startQRAttempt(c, ctx, make(chan whatsmeow.QRChannelItem))
+			if tt.cancel {
+				cancel()
+			}
+
+			select {
+			case err := <-errs:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("QR request didn't return after its context was done")
+			}
+
+			c.mutex.RLock()
+			attempt := c.qrAttempt
+			c.mutex.RUnlock()
+			if attempt != nil {
+				t.Error("QR attempt is still registered after cancellation")
+			}
+		})
+	}
+}
+
+func TestGenerateQRConnectFailure(t *testing.T) {
+	c := newTestClient(t, Options{})
+	connectErr := errors.New("connect failed")
+
+	var pairCtx context.Context
+	_, err := c.generateQR(context.Background(), func(ctx context.Context) (<-chan whatsmeow.QRChannelItem, error) {
+		pairCtx = ctx
+		return nil, connectErr
+	})
+	if !errors.Is(err, connectErr) {
+		t.Fatalf("error = %v, want %v", err, connectErr)
+	}
+	if pairCtx.Err() == nil {
+		t.Error("pairing context is still live after the connection failed")
+	}
+
+	c.mutex.RLock()
+	attempt := c.qrAttempt
+	c.mutex.RUnlock()
+	if attempt != nil {
+		t.Error("QR attempt is registered after the connection failed")
+	}
+}
+
+func TestGenerateQRTwiceLeaksNothing(t *testing.T) {
+	c := newTestClient(t, Options{})
+	// Only goroutines started by the QR requests count
+	ignore := goleak.IgnoreCurrent()
+
+	// The first request is still waiting for a code when the second starts
+	first := startQRAttempt(c, context.Background(), make(chan whatsmeow.QRChannelItem))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	second := startQRAttempt(c, ctx, make(chan whatsmeow.QRChannelItem))
+
+	select {
+	case err := <-first:
+		if !errors.Is(err, ErrQRSuperseded) {
+			t.Fatalf("first request error = %v, want ErrQRSuperseded", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("first request wasn't cancelled by the second")
+	}
+
+	// The second one runs until its timeout
+	select {
+	case err := <-second:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("second request error = %v, want context.DeadlineExceeded", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("second request didn't time out")
+	}
+
+	c.mutex.RLock()
+	attempt := c.qrAttempt
+	c.mutex.RUnlock()
+	if attempt != nil {
+		t.Error("QR attempt is still registered after both requests ended")
+	}
+
+	goleak.VerifyNone(t, ignore)
+}
@@ -0,0 +1,66 @@
+package whatsapp
+
+import (
+	"time"
+
+	"go.mau.fi/whatsmeow"
+)
+
+// QREvent is the data of a qr webhook
+type QREvent struct {
+	Code string `json:"code"`
+	// ExpiresIn is how many seconds the code stays valid
+	ExpiresIn int `json:"expires_in"`
+}
+
+// SetQRWebhook turns the qr webhook on or off for the client and saves the
+// setting. QR codes let anyone link the account, so they're only posted to
+// the webhook when a client opts in.
+func (c *Client) SetQRWebhook(enabled bool) error {
+	c.mutex.Lock()
+	previous := c.qrWebhook
+	c.qrWebhook = enabled
+	c.mutex.Unlock()
+
+	if err := c.SaveState(); err != nil {
+		c.mutex.Lock()
+		c.qrWebhook = previous
+		c.mutex.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// forwardQRCodes posts the first code and every code the channel rotates to
+// afterwards, until the channel closes. It keeps reading even when the
+// webhook is off so the channel is drained.
+func (c *Client) forwardQRCodes(first whatsmeow.QRChannelItem, qrChan <-chan whatsmeow.QRChannelItem) {
+	c.notifyQR(first)
+	for evt := range qrChan {
+		if evt.Event == whatsmeow.QRChannelEventCode {
+			qrGenerations.WithLabelValues(c.ID).Inc()
+			c.notifyQR(evt)
+		}
+	}
+}
+
+// notifyQR posts a QR code to the webhook if the client opted in
+func (c *Client) notifyQR(evt whatsmeow.QRChannelItem) {
+	c.mutex.RLock()
+	enabled := c.qrWebhook
+	c.mutex.RUnlock()
+	if !enabled {
+		return
+	}
+
+	c.hook.Load().send(WebhookPayload{
+		Event:     WebhookEventQR,
+		ClientID:  c.ID,
+		Timestamp: time.Now(),
+		Data: QREvent{
+			Code:      evt.Code,
+			ExpiresIn: int(evt.Timeout.Seconds()),
+		},
+	})
+}
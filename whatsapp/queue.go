@@ -0,0 +1,343 @@
+package whatsapp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+)
+
+// maxQueuedMessages bounds the number of messages waiting to be sent per client
+const maxQueuedMessages = 1000
+
+// ErrQueueFull is returned when a client's send queue can't take more messages
+var ErrQueueFull = errors.New("send queue is full")
+
+// errQueueClosed is returned when sending through a closed client
+var errQueueClosed = errors.New("client is closed")
+
+// errQueueDrained is reported for queued messages dropped on disconnect
+var errQueueDrained = errors.New("client disconnected before the message was sent")
+
+// QueueStatus describes the state of a client's send queue
+type QueueStatus struct {
+	Pending           int `json:"pending"`
+	MessagesPerMinute int `json:"messages_per_minute"`
+}
+
+// sendOutcome is the result of sending a queued message
+type sendOutcome struct {
+	messageID string
+	err       error
+}
+
+// queuedMessage is a message waiting in the send queue
+type queuedMessage struct {
+	id        string
+	recipient string
+	message   string
+	opts      SendOptions
+	// content is a prebuilt message; when set, message and opts are unused
+	content *waProto.Message
+	// preview is the link preview, fetched before the message is queued
+	preview *linkPreview
+	// ctx is the caller's context for synchronous sends
+	ctx   context.Context
+	async bool
+	done  chan sendOutcome
+	// attempts counts the sends tried so far
+	attempts int
+}
+
+// context returns the job's context, or a background context for jobs
+// nobody waits on
+func (job *queuedMessage) context() context.Context {
+	if job.ctx == nil {
+		return context.Background()
+	}
+	return job.ctx
+}
+
+// sendQueue sends a client's outgoing messages with a bounded number of
+// workers and spaces them out with a token bucket so bursts don't get the
+// account banned. With one worker, messages go out in the order queued.
+type sendQueue struct {
+	jobs    chan *queuedMessage
+	workers int
+	pending atomic.Int64
+	limiter *rateLimiter
+	stop    chan struct{}
+	mutex   sync.Mutex
+	closed  bool
+}
+
+// newSendQueue creates a send queue limited to perMinute messages per minute,
+// sending up to workers messages at once. A limit of 0 or less disables rate
+// limiting; fewer than one worker means one.
+func newSendQueue(perMinute int, workers int) *sendQueue {
+	if workers < 1 {
+		workers = 1
+	}
+	return &sendQueue{
+		jobs:    make(chan *queuedMessage, maxQueuedMessages),
+		workers: workers,
+		limiter: newRateLimiter(perMinute),
+		stop:    make(chan struct{}),
+	}
+}
+
+// run sends queued messages until the queue is stopped. Each of the queue's
+// workers runs it in its own goroutine.
+func (q *sendQueue) run(c *Client) {
+	for {
+		select {
+		case <-q.stop:
+			return
+		case job := <-q.jobs:
+			if !q.limiter.wait(q.stop) {
+				q.pending.Add(-1)
+				q.finish(c, job, sendOutcome{err: errQueueDrained})
+				return
+			}
+			// The caller gave up while the message was waiting
+			if err := job.context().Err(); err != nil {
+				q.pending.Add(-1)
+				q.finish(c, job, sendOutcome{err: err})
+				continue
+			}
+			messageID, err := q.send(c, job)
+			q.pending.Add(-1)
+			q.finish(c, job, sendOutcome{messageID: messageID, err: err})
+		}
+	}
+}
+
+// send sends a job, retrying transient failures with exponential backoff.
+// Waiting for a retry holds up the queue, which is what later messages would
+// run into anyway while the connection is down.
+func (q *sendQueue) send(c *Client, job *queuedMessage) (string, error) {
+	backoff := c.sendRetryBackoff
+	for {
+		job.attempts++
+		messageID, err := c.sendNow(job)
+		recordSend(c.ID, err)
+		if err == nil || job.attempts > c.sendRetries || !isTransientSendError(err) {
+			return messageID, err
+		}
+
+		slog.Debug("Retrying failed send", "client", c.ID, "recipient", job.recipient, "attempt", job.attempts, "backoff", backoff, "error", err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-q.stop:
+			timer.Stop()
+			return "", err
+		case <-job.context().Done():
+			timer.Stop()
+			return "", job.context().Err()
+		}
+		backoff *= 2
+	}
+}
+
+// isTransientSendError reports whether a failed send may succeed if tried
+// again without changes
+func isTransientSendError(err error) bool {
+	switch {
+	case errors.Is(err, ErrInvalidRecipient), errors.Is(err, ErrInvalidMessage),
+		errors.Is(err, ErrNotLoggedIn), errors.Is(err, ErrClientOutdated),
+		errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	}
+	return true
+}
+
+// enqueue adds a message to the queue
+func (q *sendQueue) enqueue(job *queuedMessage) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.closed {
+		return errQueueClosed
+	}
+
+	q.pending.Add(1)
+	select {
+	case q.jobs <- job:
+		return nil
+	default:
+		q.pending.Add(-1)
+		return ErrQueueFull
+	}
+}
+
+// drain fails every message still waiting in the queue
+func (q *sendQueue) drain(c *Client) {
+	for {
+		select {
+		case job := <-q.jobs:
+			q.pending.Add(-1)
+			q.finish(c, job, sendOutcome{err: errQueueDrained})
+		default:
+			return
+		}
+	}
+}
+
+// close stops the worker and fails anything left in the queue
+func (q *sendQueue) close(c *Client) {
+	q.mutex.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.stop)
+	}
+	q.mutex.Unlock()
+
+	q.drain(c)
+}
+
+// finish reports the outcome of a job to its waiting caller, or logs and
+// dead-letters it for async jobs nobody is waiting on. A synchronous caller
+// gets the error back and may retry itself, so keeping its message as well
+// would send it twice on replay.
+func (q *sendQueue) finish(c *Client, job *queuedMessage, outcome sendOutcome) {
+	if job.async {
+		if outcome.err != nil {
+			slog.Warn("Queued message failed", "client", c.ID, "job_id", job.id, "recipient", job.recipient, "error", outcome.err)
+			c.deadLetter(job, outcome.err)
+		}
+		return
+	}
+	job.done <- outcome
+}
+
+// EnqueueMessage queues a message for sending without waiting for it and
+// returns the queue job ID
+func (c *Client) EnqueueMessage(recipient string, message string, opts SendOptions) (string, error) {
+	// Reject bad messages now rather than after they've waited in the queue
+	jid, err := c.parseRecipient(recipient)
+	if err != nil {
+		return "", err
+	}
+	if err := c.checkTextMessage(jid, message, opts); err != nil {
+		return "", err
+	}
+
+	job := &queuedMessage{
+		id:        newJobID(),
+		recipient: recipient,
+		message:   message,
+		opts:      opts,
+		preview:   c.linkPreviewFor(context.Background(), message, opts),
+		async:     true,
+	}
+	if err := c.queue.enqueue(job); err != nil {
+		return "", err
+	}
+
+	return job.id, nil
+}
+
+// QueueStatus returns the state of the client's send queue
+func (c *Client) QueueStatus() QueueStatus {
+	return QueueStatus{
+		Pending:           int(c.queue.pending.Load()),
+		MessagesPerMinute: c.queue.limiter.rate(),
+	}
+}
+
+// SetRateLimit changes how many messages per minute the client may send.
+// A limit of 0 or less disables rate limiting.
+func (c *Client) SetRateLimit(perMinute int) {
+	c.queue.limiter.setRate(perMinute)
+}
+
+// newJobID generates a random ID for a queued job
+func newJobID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// rateLimiter is a token bucket allowing bursts of up to perMinute messages
+type rateLimiter struct {
+	mutex     sync.Mutex
+	perMinute int
+	tokens    float64
+	last      time.Time
+}
+
+// newRateLimiter creates a full token bucket
+func newRateLimiter(perMinute int) *rateLimiter {
+	return &rateLimiter{
+		perMinute: perMinute,
+		tokens:    float64(perMinute),
+		last:      time.Now(),
+	}
+}
+
+// rate returns the configured messages per minute
+func (l *rateLimiter) rate() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.perMinute
+}
+
+// setRate changes the messages per minute, keeping the current tokens
+func (l *rateLimiter) setRate(perMinute int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.refill(time.Now())
+	l.perMinute = perMinute
+	if l.tokens > float64(perMinute) {
+		l.tokens = float64(perMinute)
+	}
+}
+
+// refill adds the tokens earned since the last refill. Must hold mutex.
+func (l *rateLimiter) refill(now time.Time) {
+	if l.perMinute > 0 {
+		l.tokens += now.Sub(l.last).Minutes() * float64(l.perMinute)
+		if l.tokens > float64(l.perMinute) {
+			l.tokens = float64(l.perMinute)
+		}
+	}
+	l.last = now
+}
+
+// wait blocks until a token is available and takes it. It returns false if
+// stop is closed first.
+func (l *rateLimiter) wait(stop <-chan struct{}) bool {
+	for {
+		l.mutex.Lock()
+		if l.perMinute <= 0 {
+			l.mutex.Unlock()
+			return true
+		}
+		l.refill(time.Now())
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mutex.Unlock()
+			return true
+		}
+		delay := time.Duration((1 - l.tokens) / float64(l.perMinute) * float64(time.Minute))
+		l.mutex.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-stop:
+			timer.Stop()
+			return false
+		}
+	}
+}
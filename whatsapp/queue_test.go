@@ -0,0 +1,61 @@
+package whatsapp
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSendQueueConcurrency(t *testing.T) {
+	// The client isn't connected, so every send fails and is retried once
+	// after backoff. A send therefore takes about one backoff, and only the
+	// number of workers decides whether two of them overlap.
+	const backoff = 400 * time.Millisecond
+
+	tests := []struct {
+		name        string
+		concurrency int
+		overlap     bool
+	}{
+		{name: "default", concurrency: 0, overlap: false},
+		{name: "one worker", concurrency: 1, overlap: false},
+		{name: "two workers", concurrency: 2, overlap: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, Options{
+				SendConcurrency:  tt.concurrency,
+				SendRetries:      1,
+				SendRetryBackoff: backoff,
+			})
+
+			start := time.Now()
+			var wg sync.WaitGroup
+			for i := 0; i < 2; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if _, err := c.SendMessage("6281234567890", "hello"); !errors.Is(err, ErrNotConnected) {
+						t.Errorf("SendMessage error = %v, want ErrNotConnected", err)
+					}
+				}()
+			}
+
+			// The client stays usable while its sends are under way
+			time.Sleep(backoff / 4)
+			stateStart := time.Now()
+			c.GetState()
+			if waited := time.Since(stateStart); waited > backoff/4 {
+				t.Errorf("GetState waited %v for sends in progress", waited)
+			}
+
+			wg.Wait()
+			elapsed := time.Since(start)
+			if overlapped := elapsed < 2*backoff; overlapped != tt.overlap {
+				t.Errorf("two sends took %v with %d workers, overlap = %v, want %v", elapsed, c.queue.workers, overlapped, tt.overlap)
+			}
+		})
+	}
+}
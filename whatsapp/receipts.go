@@ -0,0 +1,123 @@
+package whatsapp
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// maxTrackedReceipts bounds the messages whose receipts are kept in memory
+const maxTrackedReceipts = 10000
+
+// ErrReceiptNotFound is returned when no receipts are known for a message
+var ErrReceiptNotFound = errors.New("no receipts for message")
+
+// MessageReceipt holds the latest delivery state of a sent message
+type MessageReceipt struct {
+	MessageID   string     `json:"message_id"`
+	ChatJID     string     `json:"chat_jid,omitempty"`
+	SentAt      *time.Time `json:"sent_at,omitempty"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+	ReadAt      *time.Time `json:"read_at,omitempty"`
+	PlayedAt    *time.Time `json:"played_at,omitempty"`
+	FailedAt    *time.Time `json:"failed_at,omitempty"`
+}
+
+// receiptTracker keeps receipts for the most recent messages, dropping the
+// oldest once maxTrackedReceipts is reached
+type receiptTracker struct {
+	mutex    sync.Mutex
+	receipts map[string]*list.Element
+	order    *list.List
+}
+
+// newReceiptTracker creates an empty receipt tracker
+func newReceiptTracker() *receiptTracker {
+	return &receiptTracker{
+		receipts: make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// entry returns the receipt for a message, creating it if needed. Must hold
+// mutex.
+func (t *receiptTracker) entry(messageID string) *MessageReceipt {
+	if elem, ok := t.receipts[messageID]; ok {
+		return elem.Value.(*MessageReceipt)
+	}
+
+	receipt := &MessageReceipt{MessageID: messageID}
+	t.receipts[messageID] = t.order.PushBack(receipt)
+	if t.order.Len() > maxTrackedReceipts {
+		oldest := t.order.Front()
+		t.order.Remove(oldest)
+		delete(t.receipts, oldest.Value.(*MessageReceipt).MessageID)
+	}
+	return receipt
+}
+
+// sent records that a message was sent
+func (t *receiptTracker) sent(messageID string, chat types.JID, at time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	receipt := t.entry(messageID)
+	receipt.ChatJID = chat.String()
+	receipt.SentAt = &at
+}
+
+// record applies a receipt event to the messages it covers
+func (t *receiptTracker) record(evt *events.Receipt) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	at := evt.Timestamp
+	for _, id := range evt.MessageIDs {
+		var field **time.Time
+		switch evt.Type {
+		case types.ReceiptTypeDelivered:
+			field = &t.entry(id).DeliveredAt
+		case types.ReceiptTypeRead:
+			field = &t.entry(id).ReadAt
+		case types.ReceiptTypePlayed:
+			field = &t.entry(id).PlayedAt
+		case types.ReceiptTypeServerError:
+			field = &t.entry(id).FailedAt
+		default:
+			// Receipts for our own reads and retries don't say anything
+			// about delivery
+			return
+		}
+		*field = &at
+
+		receipt := t.entry(id)
+		if receipt.ChatJID == "" {
+			receipt.ChatJID = evt.Chat.String()
+		}
+	}
+}
+
+// get returns a copy of the receipt for a message
+func (t *receiptTracker) get(messageID string) (MessageReceipt, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	elem, ok := t.receipts[messageID]
+	if !ok {
+		return MessageReceipt{}, false
+	}
+	return *elem.Value.(*MessageReceipt), true
+}
+
+// GetReceipt returns the latest delivery state of a message sent by the client
+func (c *Client) GetReceipt(messageID string) (MessageReceipt, error) {
+	receipt, ok := c.receipts.get(messageID)
+	if !ok {
+		return MessageReceipt{}, ErrReceiptNotFound
+	}
+	return receipt, nil
+}
@@ -0,0 +1,105 @@
+package whatsapp
+
+import (
+	"log/slog"
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	// reconnectBaseDelay is the wait before the first reconnect attempt
+	reconnectBaseDelay = 2 * time.Second
+	// reconnectMaxDelay caps the wait between reconnect attempts
+	reconnectMaxDelay = 5 * time.Minute
+)
+
+// startReconnectLocked starts the reconnect supervisor unless auto-reconnect
+// is off or one is already running. During a temporary ban it waits for the
+// ban to expire, and doesn't start at all if the ban has no known end. Must
+// hold c.mutex.
+func (c *Client) startReconnectLocked() {
+	if !c.autoReconnect || c.reconnectStop != nil {
+		return
+	}
+	notBefore, ok := c.reconnectNotBeforeLocked()
+	if !ok {
+		slog.Warn("Not reconnecting while temporarily banned without a known expiry", "client", c.ID)
+		return
+	}
+
+	stop := make(chan struct{})
+	c.reconnectStop = stop
+	go c.superviseReconnect(stop, notBefore)
+}
+
+// stopReconnectLocked stops the reconnect supervisor if it is running. Must
+// hold c.mutex.
+func (c *Client) stopReconnectLocked() {
+	if c.reconnectStop != nil {
+		close(c.reconnectStop)
+		c.reconnectStop = nil
+	}
+}
+
+// superviseReconnect retries connecting with exponential backoff until the
+// connection is back, the session is gone or stop is closed. The first
+// attempt waits until notBefore when it's set.
+func (c *Client) superviseReconnect(stop chan struct{}, notBefore time.Time) {
+	if wait := time.Until(notBefore); !notBefore.IsZero() && wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		timer := time.NewTimer(reconnectDelay(attempt))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		c.mutex.Lock()
+		// Stopped while we were waiting for the lock
+		if c.reconnectStop != stop {
+			c.mutex.Unlock()
+			return
+		}
+		// Nothing left to reconnect
+		if c.client.Store.ID == nil || c.client.IsConnected() {
+			c.reconnectStop = nil
+			c.mutex.Unlock()
+			return
+		}
+
+		c.reconnectAttempts++
+		err := c.client.Connect()
+		if err == nil {
+			// The retry count is reset once the Connected event arrives
+			c.reconnectStop = nil
+			c.mutex.Unlock()
+			return
+		}
+		c.connError = err.Error()
+		attempts := c.reconnectAttempts
+		c.mutex.Unlock()
+
+		slog.Warn("Reconnect attempt failed", "client", c.ID, "attempt", attempts, "error", err)
+	}
+}
+
+// reconnectDelay returns the jittered backoff before the given attempt
+func reconnectDelay(attempt int) time.Duration {
+	delay := reconnectMaxDelay
+	if attempt < 16 {
+		delay = min(reconnectBaseDelay<<attempt, reconnectMaxDelay)
+	}
+	// Spread retries over the upper half of the window so clients that
+	// dropped together don't all reconnect at the same moment
+	return delay/2 + rand.N(delay/2+1)
+}
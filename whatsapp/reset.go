@@ -0,0 +1,87 @@
+package whatsapp
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// ResetClient wipes a client's session store and gives it a fresh one, so a
+// client with a broken session can be linked again under the same ID. The
+// settings saved with the client, such as its API key, labels and metadata,
+// are kept, as are scheduled messages, templates and failed messages. The
+// session and the received message history are lost.
+func (cm *ClientManager) ResetClient(id string) (*Client, error) {
+	// Logging out and swapping the store are slow, so the client is only
+	// claimed under the lock and the work happens outside it
+	client, err := cm.claimClient(id)
+	if err != nil {
+		return nil, err
+	}
+	defer cm.releaseClient(id)
+
+	// Unlink the device from the phone. A broken session may not manage
+	// that, which is fine since the store is wiped either way.
+	if err := client.Logout(); err != nil {
+		slog.Warn("Failed to log out client before reset", "client", id, "error", err)
+	}
+
+	// Keep the client's settings for the fresh store
+	if err := client.SaveState(); err != nil {
+		return nil, err
+	}
+	if err := client.Close(); err != nil {
+		return nil, err
+	}
+
+	// The old client is unusable from here on, so it must be replaced or
+	// dropped whatever happens next
+	resetErr := resetStore(id, filepath.Join(cm.dataDir, id), cm.options())
+	fresh, _, err := cm.openSavedClient(id)
+
+	cm.mutex.Lock()
+	if err != nil {
+		delete(cm.clients, id)
+	} else {
+		cm.clients[id] = fresh
+	}
+	cm.mutex.Unlock()
+
+	if err != nil {
+		if resetErr != nil {
+			return nil, resetErr
+		}
+		return nil, fmt.Errorf("failed to reopen client after reset: %w", err)
+	}
+	if resetErr != nil {
+		return nil, resetErr
+	}
+
+	if err := fresh.SaveState(); err != nil {
+		slog.Warn("Failed to save state after reset", "client", id, "error", err)
+	}
+	cm.publishClientState(fresh.GetState())
+
+	return fresh, nil
+}
+
+// resetStore deletes a client's session store. The client's database must be
+// closed.
+func resetStore(id string, clientDir string, opts Options) error {
+	switch opts.DBDriver {
+	case "", DriverSQLite:
+		dbPath := filepath.Join(clientDir, "whatsapp.db")
+		for _, path := range []string{dbPath, dbPath + "-wal", dbPath + "-shm", dbPath + "-journal"} {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove store: %w", err)
+			}
+		}
+		return nil
+
+	case DriverPostgres:
+		return dropPostgresSchema(id, opts.DBDSN)
+	}
+
+	return fmt.Errorf("unsupported database driver: %q", opts.DBDriver)
+}
@@ -0,0 +1,46 @@
+package whatsapp
+
+import (
+	"context"
+	"time"
+
+	"go.mau.fi/whatsmeow/appstate"
+)
+
+// ResyncResult is the outcome of resyncing one kind of app state
+type ResyncResult struct {
+	Name    string `json:"name"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Resync fetches every kind of app state (contacts, chat settings and so on)
+// from scratch. App state can go stale while a client is offline for long,
+// and WhatsApp only sends patches since the last sync. A failure for one kind
+// doesn't stop the others; each is reported in the results.
+func (c *Client) Resync(ctx context.Context) ([]ResyncResult, error) {
+	c.mutex.Lock()
+	c.lastActivity = time.Now()
+	c.mutex.Unlock()
+
+	if !c.client.IsLoggedIn() {
+		return nil, ErrNotLoggedIn
+	}
+
+	results := make([]ResyncResult, 0, len(appstate.AllPatchNames))
+	for _, name := range appstate.AllPatchNames {
+		result := ResyncResult{Name: string(name), Success: true}
+		if err := c.client.FetchAppState(ctx, name, true, false); err != nil {
+			result.Success = false
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+
+		// Don't keep going for a caller that has left
+		if ctx.Err() != nil {
+			return results, ctx.Err()
+		}
+	}
+
+	return results, nil
+}
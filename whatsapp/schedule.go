@@ -0,0 +1,203 @@
+package whatsapp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// scheduleFile is the name of the file holding a client's scheduled messages
+const scheduleFile = "schedule.json"
+
+// schedulerInterval is how often the manager looks for due messages
+const schedulerInterval = time.Second
+
+// ErrScheduledNotFound is returned when a scheduled message doesn't exist
+var ErrScheduledNotFound = errors.New("scheduled message not found")
+
+// ScheduledMessage is a message waiting to be sent at a later time
+type ScheduledMessage struct {
+	ID        string    `json:"id"`
+	Recipient string    `json:"recipient"`
+	Message   string    `json:"message"`
+	SendAt    time.Time `json:"send_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ScheduleMessage schedules a message to be sent at sendAt
+func (c *Client) ScheduleMessage(recipient string, message string, sendAt time.Time) (ScheduledMessage, error) {
+	jid, err := c.parseRecipient(recipient)
+	if err != nil {
+		return ScheduledMessage{}, err
+	}
+	if err := c.checkTextMessage(jid, message, SendOptions{}); err != nil {
+		return ScheduledMessage{}, err
+	}
+	if !sendAt.After(time.Now()) {
+		return ScheduledMessage{}, errors.New("send_at must be in the future")
+	}
+
+	scheduled := ScheduledMessage{
+		ID:        newJobID(),
+		Recipient: recipient,
+		Message:   message,
+		SendAt:    sendAt,
+		CreatedAt: time.Now(),
+	}
+
+	c.scheduleMutex.Lock()
+	defer c.scheduleMutex.Unlock()
+
+	c.scheduled = append(c.scheduled, scheduled)
+	if err := c.saveSchedule(); err != nil {
+		c.scheduled = c.scheduled[:len(c.scheduled)-1]
+		return ScheduledMessage{}, err
+	}
+
+	return scheduled, nil
+}
+
+// ListScheduled lists the client's pending scheduled messages, soonest first
+func (c *Client) ListScheduled() []ScheduledMessage {
+	c.scheduleMutex.Lock()
+	defer c.scheduleMutex.Unlock()
+
+	list := make([]ScheduledMessage, len(c.scheduled))
+	copy(list, c.scheduled)
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].SendAt.Before(list[j].SendAt)
+	})
+
+	return list
+}
+
+// CancelScheduled cancels a pending scheduled message
+func (c *Client) CancelScheduled(id string) error {
+	c.scheduleMutex.Lock()
+	defer c.scheduleMutex.Unlock()
+
+	for i, scheduled := range c.scheduled {
+		if scheduled.ID != id {
+			continue
+		}
+		previous := c.scheduled
+		c.scheduled = append(c.scheduled[:i:i], c.scheduled[i+1:]...)
+		if err := c.saveSchedule(); err != nil {
+			c.scheduled = previous
+			return err
+		}
+		return nil
+	}
+
+	return ErrScheduledNotFound
+}
+
+// dispatchDueScheduled queues the scheduled messages that are due. A message
+// leaves the schedule only once it's queued: one the queue can't take right
+// now stays for the next run, and one that's refused for good is
+// dead-lettered. Nothing is queued while the client can't send, so messages
+// due during an outage go out once it's back.
+func (c *Client) dispatchDueScheduled(now time.Time) {
+	if !c.client.IsConnected() || !c.client.IsLoggedIn() {
+		return
+	}
+
+	c.scheduleMutex.Lock()
+	defer c.scheduleMutex.Unlock()
+
+	remaining := c.scheduled[:0:0]
+	changed := false
+	for _, scheduled := range c.scheduled {
+		if scheduled.SendAt.After(now) {
+			remaining = append(remaining, scheduled)
+			continue
+		}
+
+		_, err := c.EnqueueMessage(scheduled.Recipient, scheduled.Message, SendOptions{})
+		switch {
+		case err == nil:
+			changed = true
+		case errors.Is(err, ErrQueueFull), errors.Is(err, errQueueClosed):
+			slog.Warn("Failed to queue scheduled message, keeping it for later", "client", c.ID, "scheduled_id", scheduled.ID, "error", err)
+			remaining = append(remaining, scheduled)
+		default:
+			slog.Warn("Failed to queue scheduled message", "client", c.ID, "scheduled_id", scheduled.ID, "error", err)
+			c.deadLetter(&queuedMessage{recipient: scheduled.Recipient, message: scheduled.Message}, err)
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+
+	c.scheduled = remaining
+	if err := c.saveSchedule(); err != nil {
+		slog.Warn("Failed to save schedule", "client", c.ID, "error", err)
+	}
+}
+
+// loadSchedule reads the scheduled messages saved for the client
+func (c *Client) loadSchedule() error {
+	data, err := os.ReadFile(filepath.Join(c.dataDir, scheduleFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read schedule file: %w", err)
+	}
+
+	var scheduled []ScheduledMessage
+	if err := json.Unmarshal(data, &scheduled); err != nil {
+		return fmt.Errorf("failed to parse schedule file: %w", err)
+	}
+
+	c.scheduleMutex.Lock()
+	c.scheduled = scheduled
+	c.scheduleMutex.Unlock()
+
+	return nil
+}
+
+// saveSchedule writes the scheduled messages to disk. Must hold scheduleMutex.
+func (c *Client) saveSchedule() error {
+	data, err := json.MarshalIndent(c.scheduled, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal schedule: %w", err)
+	}
+
+	// Write to a temporary file and rename it over the old one, so a crash
+	// mid-write leaves the previous schedule intact
+	file := filepath.Join(c.dataDir, scheduleFile)
+	tmpFile := file + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0600); err != nil {
+		return fmt.Errorf("failed to write schedule file: %w", err)
+	}
+	if err := os.Rename(tmpFile, file); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to write schedule file: %w", err)
+	}
+
+	return nil
+}
+
+// runScheduler dispatches due scheduled messages until the manager is closed
+func (cm *ClientManager) runScheduler() {
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-cm.stop:
+			return
+		case now := <-ticker.C:
+			for _, client := range cm.snapshotClients() {
+				client.dispatchDueScheduled(now)
+			}
+		}
+	}
+}
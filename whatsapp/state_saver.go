@@ -0,0 +1,76 @@
+package whatsapp
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// stateSaveDelay is how long a client's state change waits for further
+// changes before it is saved, so a flapping connection isn't saved on every
+// flap
+const stateSaveDelay = 2 * time.Second
+
+// stateSaver saves the state of clients shortly after their connection
+// state changes, so a crash doesn't lose it until the next periodic save
+type stateSaver struct {
+	mutex   sync.Mutex
+	pending map[string]*time.Timer
+	closed  bool
+}
+
+// newStateSaver creates a state saver with nothing pending
+func newStateSaver() *stateSaver {
+	return &stateSaver{pending: make(map[string]*time.Timer)}
+}
+
+// request schedules saving a client's state, pushing back a save that is
+// already pending for it. save runs without the saver's lock held.
+func (s *stateSaver) request(id string, save func()) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		return
+	}
+	if timer, ok := s.pending[id]; ok {
+		timer.Reset(stateSaveDelay)
+		return
+	}
+	s.pending[id] = time.AfterFunc(stateSaveDelay, func() {
+		s.mutex.Lock()
+		delete(s.pending, id)
+		s.mutex.Unlock()
+		save()
+	})
+}
+
+// close drops pending saves. The manager saves every client when it closes.
+func (s *stateSaver) close() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.closed = true
+	for id, timer := range s.pending {
+		timer.Stop()
+		delete(s.pending, id)
+	}
+}
+
+// clientStatusChanged reports a client's new connection state to
+// subscribers and saves it soon after
+func (cm *ClientManager) clientStatusChanged(state ClientState) {
+	cm.publishClientState(state)
+
+	id := state.ID
+	cm.saver.request(id, func() {
+		client, err := cm.GetClient(id)
+		if err != nil {
+			// Deleted in the meantime
+			return
+		}
+		if err := client.SaveState(); err != nil {
+			slog.Warn("Failed to save state", "client", id, "error", err)
+		}
+	})
+}
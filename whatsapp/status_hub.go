@@ -0,0 +1,94 @@
+package whatsapp
+
+import "sync"
+
+// statusBuffer is how many updates a subscriber may fall behind before it
+// is dropped
+const statusBuffer = 64
+
+// Status update types
+const (
+	// StatusUpdateChanged carries the new state of a created or changed client
+	StatusUpdateChanged = "status"
+	// StatusUpdateRemoved reports a deleted client
+	StatusUpdateRemoved = "removed"
+)
+
+// StatusUpdate reports a change to one client
+type StatusUpdate struct {
+	Type     string       `json:"type"`
+	ClientID string       `json:"client_id"`
+	Client   *ClientState `json:"client,omitempty"`
+}
+
+// statusHub fans client status updates out to subscribers
+type statusHub struct {
+	mutex       sync.Mutex
+	subscribers map[chan StatusUpdate]struct{}
+}
+
+// newStatusHub creates a hub without subscribers
+func newStatusHub() *statusHub {
+	return &statusHub{subscribers: make(map[chan StatusUpdate]struct{})}
+}
+
+// subscribe registers a subscriber. The channel is closed when the returned
+// function is called or when the subscriber falls too far behind.
+func (h *statusHub) subscribe() (<-chan StatusUpdate, func()) {
+	ch := make(chan StatusUpdate, statusBuffer)
+
+	h.mutex.Lock()
+	h.subscribers[ch] = struct{}{}
+	h.mutex.Unlock()
+
+	return ch, func() {
+		h.mutex.Lock()
+		defer h.mutex.Unlock()
+		if _, ok := h.subscribers[ch]; ok {
+			delete(h.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// publish sends an update to every subscriber without blocking
+func (h *statusHub) publish(update StatusUpdate) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for ch := range h.subscribers {
+		select {
+		case ch <- update:
+		default:
+			// Too slow; dropping it is better than holding up the clients
+			delete(h.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// SubscribeStatus streams client status updates: a StatusUpdateChanged when
+// a client is created or its connection state changes, and a
+// StatusUpdateRemoved when it's deleted. Call the returned function to
+// unsubscribe. The channel is also closed if the subscriber stops reading.
+func (cm *ClientManager) SubscribeStatus() (<-chan StatusUpdate, func()) {
+	return cm.statusHub.subscribe()
+}
+
+// publishClientState reports the current state of a client to subscribers
+func (cm *ClientManager) publishClientState(state ClientState) {
+	cm.statusHub.publish(StatusUpdate{Type: StatusUpdateChanged, ClientID: state.ID, Client: &state})
+}
+
+// publishClientRemoved reports a deleted client to subscribers
+func (cm *ClientManager) publishClientRemoved(id string) {
+	cm.statusHub.publish(StatusUpdate{Type: StatusUpdateRemoved, ClientID: id})
+}
+
+// publishStatus reports the client's state to the status listener, if any.
+// Must not hold c.mutex.
+func (c *Client) publishStatus() {
+	if c.onStatusChange != nil {
+		c.onStatusChange(c.GetState())
+	}
+}
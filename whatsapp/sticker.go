@@ -0,0 +1,166 @@
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"image/png"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// Largest stickers WhatsApp accepts, in bytes
+const (
+	maxStickerSize         = 100 << 10
+	maxAnimatedStickerSize = 500 << 10
+)
+
+// stickerUploadTimeout bounds how long uploading a sticker may take
+const stickerUploadTimeout = 60 * time.Second
+
+// webpInfo holds what a sticker message needs to know about a WebP image
+type webpInfo struct {
+	width    uint32
+	height   uint32
+	animated bool
+}
+
+// SendSticker uploads a WebP image and sends it as a sticker. It returns the
+// message ID.
+func (c *Client) SendSticker(recipient string, data []byte) (string, error) {
+	info, err := checkSticker(data)
+	if err != nil {
+		return "", err
+	}
+	if _, err := c.parseRecipient(recipient); err != nil {
+		return "", err
+	}
+
+	c.mutex.Lock()
+	c.lastActivity = time.Now()
+	c.mutex.Unlock()
+
+	if !c.client.IsLoggedIn() {
+		return "", ErrNotLoggedIn
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), stickerUploadTimeout)
+	defer cancel()
+
+	// Stickers are encrypted and stored like images
+	upload, err := c.client.Upload(ctx, data, whatsmeow.MediaImage)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload sticker: %w", err)
+	}
+
+	content := buildStickerMessage(info)
+	sticker := content.GetStickerMessage()
+	sticker.URL = proto.String(upload.URL)
+	sticker.DirectPath = proto.String(upload.DirectPath)
+	sticker.MediaKey = upload.MediaKey
+	sticker.FileEncSHA256 = upload.FileEncSHA256
+	sticker.FileSHA256 = upload.FileSHA256
+	sticker.FileLength = proto.Uint64(upload.FileLength)
+
+	return c.sendContent(recipient, content)
+}
+
+// PreviewSticker validates a sticker like SendSticker and returns the message
+// that would be sent. Nothing is uploaded, so the media fields are left out.
+func (c *Client) PreviewSticker(recipient string, data []byte) (MessagePreview, error) {
+	info, err := checkSticker(data)
+	if err != nil {
+		return MessagePreview{}, err
+	}
+	return c.previewContent(recipient, buildStickerMessage(info))
+}
+
+// buildStickerMessage builds a sticker message without its media fields
+func buildStickerMessage(info webpInfo) *waProto.Message {
+	return &waProto.Message{
+		StickerMessage: &waProto.StickerMessage{
+			Mimetype:   proto.String("image/webp"),
+			Width:      proto.Uint32(info.width),
+			Height:     proto.Uint32(info.height),
+			IsAnimated: proto.Bool(info.animated),
+		},
+	}
+}
+
+// checkSticker validates that data is a WebP image small enough to be sent
+// as a sticker
+func checkSticker(data []byte) (webpInfo, error) {
+	if len(data) == 0 {
+		return webpInfo{}, fmt.Errorf("%w: sticker is empty", ErrInvalidMessage)
+	}
+
+	info, err := parseWebP(data)
+	if err != nil {
+		// PNGs are a common mistake; there's no WebP encoder to convert them
+		if bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")) {
+			if _, err := png.DecodeConfig(bytes.NewReader(data)); err == nil {
+				return webpInfo{}, fmt.Errorf("%w: stickers must be WebP, convert the PNG first", ErrInvalidMessage)
+			}
+		}
+		return webpInfo{}, err
+	}
+
+	limit := maxStickerSize
+	if info.animated {
+		limit = maxAnimatedStickerSize
+	}
+	if len(data) > limit {
+		return webpInfo{}, fmt.Errorf("%w: sticker is %d bytes, the limit is %d", ErrInvalidMessage, len(data), limit)
+	}
+	return info, nil
+}
+
+// parseWebP reads the dimensions and animation flag from a WebP header
+func parseWebP(data []byte) (webpInfo, error) {
+	errNotWebP := fmt.Errorf("%w: sticker is not a WebP image", ErrInvalidMessage)
+	if len(data) < 30 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return webpInfo{}, errNotWebP
+	}
+
+	// The first chunk tells the encoding and holds the canvas size
+	payload := data[20:]
+	switch string(data[12:16]) {
+	case "VP8 ":
+		// Lossy: a frame tag, the start code and two 14-bit dimensions
+		if !bytes.Equal(payload[3:6], []byte{0x9d, 0x01, 0x2a}) {
+			return webpInfo{}, errNotWebP
+		}
+		return webpInfo{
+			width:  uint32(binary.LittleEndian.Uint16(payload[6:8]) & 0x3fff),
+			height: uint32(binary.LittleEndian.Uint16(payload[8:10]) & 0x3fff),
+		}, nil
+	case "VP8L":
+		// Lossless: a signature byte and the dimensions minus one in 14 bits each
+		if payload[0] != 0x2f {
+			return webpInfo{}, errNotWebP
+		}
+		bits := binary.LittleEndian.Uint32(payload[1:5])
+		return webpInfo{
+			width:  bits&0x3fff + 1,
+			height: bits>>14&0x3fff + 1,
+		}, nil
+	case "VP8X":
+		// Extended: feature flags and the canvas size minus one in 24 bits each
+		flags := payload[0]
+		return webpInfo{
+			width:    uint24(payload[4:7]) + 1,
+			height:   uint24(payload[7:10]) + 1,
+			animated: flags&0x02 != 0,
+		}, nil
+	}
+	return webpInfo{}, errNotWebP
+}
+
+// uint24 decodes a little-endian 24-bit integer
+func uint24(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
@@ -0,0 +1,88 @@
+package whatsapp
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// Database drivers supported for client stores
+const (
+	DriverSQLite   = "sqlite3"
+	DriverPostgres = "postgres"
+)
+
+// postgresMaxOpenConns caps the connections a single client keeps open.
+// Every client has its own *sql.DB and therefore its own pool, so a gateway
+// with N clients can hold up to N*postgresMaxOpenConns connections, and every
+// gateway instance sharing the database adds as many again. Keep this small
+// and size max_connections (or a pooler like PgBouncer) accordingly.
+const postgresMaxOpenConns = 4
+
+// postgresMaxIdleConns is how many of those connections stay open when idle
+const postgresMaxIdleConns = 1
+
+// openStore opens the database holding a client's session and message
+// history and returns it along with the dialect whatsmeow should use
+func openStore(id string, clientDir string, opts Options) (*sql.DB, string, error) {
+	switch opts.DBDriver {
+	case "", DriverSQLite:
+		dbPath := filepath.Join(clientDir, "whatsapp.db")
+		db, err := openSQLiteStore(dbPath, opts.EncryptionKey)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to connect to database: %w", err)
+		}
+		return db, DriverSQLite, nil
+
+	case DriverPostgres:
+		return openPostgresStore(id, opts.DBDSN)
+	}
+
+	return nil, "", fmt.Errorf("unsupported database driver: %q", opts.DBDriver)
+}
+
+// openPostgresStore connects to the shared Postgres database. whatsmeow's
+// table names are fixed, so each client gets its own schema (wa_<id>) and the
+// connection's search_path points at it.
+func openPostgresStore(id string, dsn string) (*sql.DB, string, error) {
+	connConfig, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid database DSN: %w", err)
+	}
+
+	// Client IDs are validated to [A-Za-z0-9_-], so quoting is enough to
+	// make the schema name a safe identifier
+	schema := `"wa_` + id + `"`
+	connConfig.RuntimeParams["search_path"] = schema
+
+	db := stdlib.OpenDB(*connConfig)
+	db.SetMaxOpenConns(postgresMaxOpenConns)
+	db.SetMaxIdleConns(postgresMaxIdleConns)
+
+	if _, err := db.Exec("CREATE SCHEMA IF NOT EXISTS " + schema); err != nil {
+		db.Close()
+		return nil, "", fmt.Errorf("failed to create schema for client: %w", err)
+	}
+
+	return db, DriverPostgres, nil
+}
+
+// dropPostgresSchema removes a client's schema, and with it the client's
+// session and message history, from the shared database
+func dropPostgresSchema(id string, dsn string) error {
+	connConfig, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return fmt.Errorf("invalid database DSN: %w", err)
+	}
+	db := stdlib.OpenDB(*connConfig)
+	defer db.Close()
+
+	// Client IDs are validated, see openPostgresStore
+	if _, err := db.Exec(`DROP SCHEMA IF EXISTS "wa_` + id + `" CASCADE`); err != nil {
+		return fmt.Errorf("failed to drop schema for client: %w", err)
+	}
+	return nil
+}
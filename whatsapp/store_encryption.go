@@ -0,0 +1,213 @@
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// ErrEncryptionUnsupported is returned when an encryption key is set but the
+// gateway wasn't built against SQLCipher
+var ErrEncryptionUnsupported = errors.New("store encryption needs a build linked against SQLCipher")
+
+// ErrStoreEncrypted is returned when an encrypted store is opened without a
+// key
+var ErrStoreEncrypted = errors.New("store is encrypted, set ENCRYPTION_KEY to open it")
+
+// storeEncrypted reports whether the options call for encrypted sqlite
+// stores. The key is ignored for Postgres.
+func (o Options) storeEncrypted() bool {
+	return o.EncryptionKey != "" && (o.DBDriver == "" || o.DBDriver == DriverSQLite)
+}
+
+// openSQLiteStore opens a client's sqlite database. With a key, the
+// database is opened through SQLCipher, and a plaintext database left from
+// before encryption was turned on is encrypted first.
+func openSQLiteStore(dbPath string, key string) (*sql.DB, error) {
+	exists, encrypted, err := inspectStoreFile(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if key == "" {
+		if encrypted {
+			return nil, ErrStoreEncrypted
+		}
+		return sql.Open("sqlite3", "file:"+dbPath+"?_foreign_keys=on")
+	}
+
+	if exists && !encrypted {
+		if err := encryptStore(dbPath, key); err != nil {
+			return nil, err
+		}
+	}
+
+	db := sql.OpenDB(&keyedConnector{dsn: "file:" + dbPath, key: key})
+	// Check the key now rather than on the first query
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// inspectStoreFile reports whether a database file with content exists and
+// whether it's encrypted. Encrypted databases lack the sqlite header.
+func inspectStoreFile(dbPath string) (exists bool, encrypted bool, err error) {
+	f, err := os.Open(dbPath)
+	if os.IsNotExist(err) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, fmt.Errorf("failed to open store: %w", err)
+	}
+	defer f.Close()
+
+	header := make([]byte, len(sqliteHeader))
+	n, _ := io.ReadFull(f, header)
+	if n == 0 {
+		// An empty file is a new database
+		return false, false, nil
+	}
+	return true, !bytes.Equal(header[:n], []byte(sqliteHeader)), nil
+}
+
+// keyedConnector opens SQLCipher connections and keys them before anything
+// is read from the database
+type keyedConnector struct {
+	dsn string
+	key string
+}
+
+// Connect implements driver.Connector
+func (k *keyedConnector) Connect(context.Context) (driver.Conn, error) {
+	return k.Driver().Open(k.dsn)
+}
+
+// Driver implements driver.Connector
+func (k *keyedConnector) Driver() driver.Driver {
+	return &sqlite3.SQLiteDriver{ConnectHook: k.keyConn}
+}
+
+// keyConn sets the key on a new connection and checks it opens the database
+func (k *keyedConnector) keyConn(conn *sqlite3.SQLiteConn) error {
+	if _, err := conn.Exec("PRAGMA key = "+quoteSQLString(k.key), nil); err != nil {
+		return fmt.Errorf("failed to set store key: %w", err)
+	}
+	if err := checkSQLCipher(conn); err != nil {
+		return err
+	}
+	// A wrong key only shows when the first page is read
+	if _, err := conn.Exec("SELECT count(*) FROM sqlite_master", nil); err != nil {
+		return fmt.Errorf("failed to open encrypted store, is ENCRYPTION_KEY right? %w", err)
+	}
+	if _, err := conn.Exec("PRAGMA foreign_keys = ON", nil); err != nil {
+		return fmt.Errorf("failed to enable foreign keys: %w", err)
+	}
+	return nil
+}
+
+// checkSQLCipher makes sure the linked sqlite library is SQLCipher. Plain
+// sqlite ignores PRAGMA key, which would leave the store unencrypted.
+func checkSQLCipher(conn *sqlite3.SQLiteConn) error {
+	rows, err := conn.Query("PRAGMA cipher_version", nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrEncryptionUnsupported, err)
+	}
+	defer rows.Close()
+
+	values := make([]driver.Value, len(rows.Columns()))
+	if err := rows.Next(values); err != nil {
+		return ErrEncryptionUnsupported
+	}
+	return nil
+}
+
+// encryptStore replaces a plaintext database with an encrypted copy. The
+// copy is written next to it and only renamed over it once complete, so a
+// failure leaves the plaintext database in place.
+func encryptStore(dbPath string, key string) error {
+	encryptedPath := dbPath + ".encrypting"
+	os.Remove(encryptedPath)
+
+	if err := encryptCopy(dbPath, encryptedPath, key); err != nil {
+		os.Remove(encryptedPath)
+		return fmt.Errorf("failed to encrypt store: %w", err)
+	}
+
+	if err := os.Rename(encryptedPath, dbPath); err != nil {
+		os.Remove(encryptedPath)
+		return fmt.Errorf("failed to replace plaintext store: %w", err)
+	}
+	for _, path := range []string{dbPath + "-wal", dbPath + "-shm", dbPath + "-journal"} {
+		os.Remove(path)
+	}
+
+	slog.Info("Encrypted session store", "path", dbPath)
+	return nil
+}
+
+// encryptCopy copies the plaintext database at srcPath to a new database at
+// dstPath encrypted with key
+func encryptCopy(srcPath string, dstPath string, key string) error {
+	db, err := sql.Open("sqlite3", "file:"+srcPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return exportDB(context.Background(), db, dstPath, key)
+}
+
+// exportDB copies an open database to a new database at dstPath encrypted
+// with dstKey, or in plaintext if dstKey is empty
+func exportDB(ctx context.Context, db *sql.DB, dstPath string, dstKey string) error {
+	// ATTACH only applies to the connection it runs on
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := conn.Raw(func(driverConn any) error {
+		if sqliteConn, ok := driverConn.(*sqlite3.SQLiteConn); ok {
+			return checkSQLCipher(sqliteConn)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if _, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS export KEY ?", dstPath, dstKey); err != nil {
+		return fmt.Errorf("failed to create copy: %w", err)
+	}
+	defer conn.ExecContext(ctx, "DETACH DATABASE export")
+
+	if _, err := conn.ExecContext(ctx, "SELECT sqlcipher_export('export')"); err != nil {
+		return fmt.Errorf("failed to copy store: %w", err)
+	}
+	// sqlcipher_export leaves the schema version behind
+	var version int
+	if err := conn.QueryRowContext(ctx, "PRAGMA main.user_version").Scan(&version); err != nil {
+		return fmt.Errorf("failed to read store version: %w", err)
+	}
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("PRAGMA export.user_version = %d", version)); err != nil {
+		return fmt.Errorf("failed to copy store version: %w", err)
+	}
+	return nil
+}
+
+// quoteSQLString quotes s as an SQL string literal. PRAGMA doesn't take
+// bound parameters.
+func quoteSQLString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
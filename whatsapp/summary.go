@@ -0,0 +1,48 @@
+package whatsapp
+
+import "time"
+
+// Summary aggregates the state of all clients for an overview
+type Summary struct {
+	Clients       int                  `json:"clients"`
+	ByStatus      map[ClientStatus]int `json:"by_status"`
+	MessagesSent  int64                `json:"messages_sent"`
+	StartedAt     time.Time            `json:"started_at"`
+	UptimeSeconds int64                `json:"uptime_seconds"`
+	DefaultClient string               `json:"default_client,omitempty"`
+	// OldestActivity and LatestActivity are the least and most recent
+	// activity of any client
+	OldestActivity *time.Time `json:"oldest_activity,omitempty"`
+	LatestActivity *time.Time `json:"latest_activity,omitempty"`
+}
+
+// Summary counts the clients by status and reports the gateway's totals.
+// MessagesSent counts messages sent since the gateway started.
+func (cm *ClientManager) Summary() Summary {
+	summary := Summary{
+		ByStatus:      make(map[ClientStatus]int),
+		MessagesSent:  messagesSentSinceStart.Load(),
+		StartedAt:     cm.startedAt,
+		UptimeSeconds: int64(time.Since(cm.startedAt).Seconds()),
+		DefaultClient: cm.GetDefaultClient(),
+	}
+
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+
+	summary.Clients = len(cm.clients)
+	for _, client := range cm.clients {
+		state := client.GetState()
+		summary.ByStatus[state.Status]++
+
+		activity := state.LastActivity
+		if summary.OldestActivity == nil || activity.Before(*summary.OldestActivity) {
+			summary.OldestActivity = &activity
+		}
+		if summary.LatestActivity == nil || activity.After(*summary.LatestActivity) {
+			summary.LatestActivity = &activity
+		}
+	}
+
+	return summary
+}
@@ -0,0 +1,237 @@
+package whatsapp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+// templatesFile is the name of the file holding a client's message templates
+const templatesFile = "templates.json"
+
+// templateNamePattern is the allowlist for template names
+var templateNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// ErrTemplateNotFound is returned when a message template doesn't exist
+var ErrTemplateNotFound = errors.New("template not found")
+
+// ErrInvalidTemplate is returned for templates that can't be saved
+var ErrInvalidTemplate = errors.New("invalid template")
+
+// MessageTemplate is a named message body with {{.placeholders}} filled in
+// at send time
+type MessageTemplate struct {
+	Name string `json:"name"`
+	Body string `json:"body"`
+	// Variables lists the placeholders a send has to provide
+	Variables []string  `json:"variables"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SaveTemplate saves a message template, replacing any with the same name.
+// The body uses text/template syntax, e.g. "Hi {{.name}}".
+func (c *Client) SaveTemplate(name string, body string) (MessageTemplate, error) {
+	if !templateNamePattern.MatchString(name) {
+		return MessageTemplate{}, fmt.Errorf("%w: name may only use letters, digits, dashes and underscores", ErrInvalidTemplate)
+	}
+	if strings.TrimSpace(body) == "" {
+		return MessageTemplate{}, fmt.Errorf("%w: body cannot be empty", ErrInvalidTemplate)
+	}
+	tmpl, err := parseTemplate(name, body)
+	if err != nil {
+		return MessageTemplate{}, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+
+	saved := MessageTemplate{
+		Name:      name,
+		Body:      body,
+		Variables: templateVariables(tmpl),
+		UpdatedAt: time.Now(),
+	}
+
+	c.templateMutex.Lock()
+	defer c.templateMutex.Unlock()
+
+	previous, existed := c.templates[name]
+	c.templates[name] = saved
+	if err := c.saveTemplates(); err != nil {
+		if existed {
+			c.templates[name] = previous
+		} else {
+			delete(c.templates, name)
+		}
+		return MessageTemplate{}, err
+	}
+
+	return saved, nil
+}
+
+// ListTemplates lists the client's message templates by name
+func (c *Client) ListTemplates() []MessageTemplate {
+	c.templateMutex.Lock()
+	defer c.templateMutex.Unlock()
+
+	list := make([]MessageTemplate, 0, len(c.templates))
+	for _, tmpl := range c.templates {
+		list = append(list, tmpl)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	return list
+}
+
+// DeleteTemplate removes a message template
+func (c *Client) DeleteTemplate(name string) error {
+	c.templateMutex.Lock()
+	defer c.templateMutex.Unlock()
+
+	previous, ok := c.templates[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+	}
+	delete(c.templates, name)
+	if err := c.saveTemplates(); err != nil {
+		c.templates[name] = previous
+		return err
+	}
+
+	return nil
+}
+
+// RenderTemplate fills in a message template. Every variable the template
+// uses must be given.
+func (c *Client) RenderTemplate(name string, variables map[string]string) (string, error) {
+	c.templateMutex.Lock()
+	saved, ok := c.templates[name]
+	c.templateMutex.Unlock()
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+	}
+
+	var missing []string
+	for _, variable := range saved.Variables {
+		if _, ok := variables[variable]; !ok {
+			missing = append(missing, variable)
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("%w: missing template variables: %s", ErrInvalidMessage, strings.Join(missing, ", "))
+	}
+
+	tmpl, err := parseTemplate(name, saved.Body)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, variables); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+
+	return out.String(), nil
+}
+
+// parseTemplate parses a template body. Missing keys are errors rather than
+// "<no value>" in a sent message.
+func parseTemplate(name string, body string) (*template.Template, error) {
+	return template.New(name).Option("missingkey=error").Parse(body)
+}
+
+// templateVariables lists the top-level fields a template reads, sorted
+func templateVariables(tmpl *template.Template) []string {
+	seen := make(map[string]bool)
+	var walk func(node parse.Node)
+	walkPipe := func(pipe *parse.PipeNode) {
+		if pipe == nil {
+			return
+		}
+		for _, cmd := range pipe.Cmds {
+			for _, arg := range cmd.Args {
+				walk(arg)
+			}
+		}
+	}
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case *parse.ActionNode:
+			walkPipe(n.Pipe)
+		case *parse.PipeNode:
+			walkPipe(n)
+		case *parse.FieldNode:
+			seen[n.Ident[0]] = true
+		case *parse.IfNode:
+			walkPipe(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			// The body of range and with reads fields of another value
+			walkPipe(n.Pipe)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walkPipe(n.Pipe)
+			walk(n.ElseList)
+		}
+	}
+	if tmpl.Tree != nil {
+		walk(tmpl.Tree.Root)
+	}
+
+	variables := make([]string, 0, len(seen))
+	for name := range seen {
+		variables = append(variables, name)
+	}
+	sort.Strings(variables)
+	return variables
+}
+
+// loadTemplates reads the message templates saved for the client
+func (c *Client) loadTemplates() error {
+	data, err := os.ReadFile(filepath.Join(c.dataDir, templatesFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read templates file: %w", err)
+	}
+
+	templates := make(map[string]MessageTemplate)
+	if err := json.Unmarshal(data, &templates); err != nil {
+		return fmt.Errorf("failed to parse templates file: %w", err)
+	}
+
+	c.templateMutex.Lock()
+	c.templates = templates
+	c.templateMutex.Unlock()
+
+	return nil
+}
+
+// saveTemplates writes the message templates to disk. Must hold templateMutex.
+func (c *Client) saveTemplates() error {
+	data, err := json.MarshalIndent(c.templates, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal templates: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(c.dataDir, templatesFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write templates file: %w", err)
+	}
+
+	return nil
+}
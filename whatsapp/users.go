@@ -0,0 +1,68 @@
+package whatsapp
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// NumberStatus reports whether a phone number is registered on WhatsApp
+type NumberStatus struct {
+	Number       string `json:"number"`
+	JID          string `json:"jid,omitempty"`
+	InWhatsApp   bool   `json:"in_whatsapp"`
+	BusinessName string `json:"business_name,omitempty"`
+}
+
+// CheckNumbers checks which of the given phone numbers are registered on WhatsApp
+func (c *Client) CheckNumbers(numbers []string) ([]NumberStatus, error) {
+	c.mutex.Lock()
+	c.lastActivity = time.Now()
+	c.mutex.Unlock()
+
+	if !c.client.IsLoggedIn() {
+		return nil, ErrNotLoggedIn
+	}
+
+	// Normalize to international digits, then query with a leading +
+	queries := make([]string, 0, len(numbers))
+	normalized := make([]string, len(numbers))
+	for i, number := range numbers {
+		digits, err := NormalizePhoneNumber(number, c.defaultCountryCode)
+		if err != nil {
+			return nil, err
+		}
+		normalized[i] = digits
+		queries = append(queries, "+"+digits)
+	}
+
+	responses, err := c.client.IsOnWhatsApp(queries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check numbers: %w", err)
+	}
+
+	// Index responses by the digits they were queried with
+	byNumber := make(map[string]types.IsOnWhatsAppResponse, len(responses))
+	for _, resp := range responses {
+		byNumber[strings.TrimPrefix(resp.Query, "+")] = resp
+	}
+
+	result := make([]NumberStatus, len(numbers))
+	for i, digits := range normalized {
+		status := NumberStatus{Number: digits}
+		if resp, ok := byNumber[digits]; ok {
+			status.InWhatsApp = resp.IsIn
+			if resp.IsIn {
+				status.JID = resp.JID.String()
+			}
+			if resp.VerifiedName != nil && resp.VerifiedName.Details != nil {
+				status.BusinessName = resp.VerifiedName.Details.GetVerifiedName()
+			}
+		}
+		result[i] = status
+	}
+
+	return result, nil
+}
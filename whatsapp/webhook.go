@@ -0,0 +1,101 @@
+package whatsapp
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// webhookTimeout bounds a single webhook delivery
+const webhookTimeout = 10 * time.Second
+
+// webhookSignatureHeader carries the HMAC-SHA256 of the body when a webhook
+// secret is configured
+const webhookSignatureHeader = "X-Webhook-Signature"
+
+// Webhook event names
+const (
+	WebhookEventConnection   = "connection"
+	WebhookEventMessage      = "message"
+	WebhookEventPresence     = "presence"
+	WebhookEventChatPresence = "chat_presence"
+	WebhookEventQR           = "qr"
+)
+
+// WebhookPayload is the body posted to the webhook URL
+type WebhookPayload struct {
+	Event     string      `json:"event"`
+	ClientID  string      `json:"client_id"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+// webhook delivers events to the configured URL
+type webhook struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// newWebhook creates a webhook sender, or returns nil if no URL is set
+func newWebhook(url string, secret string) *webhook {
+	if url == "" {
+		return nil
+	}
+	return &webhook{
+		url:    url,
+		secret: secret,
+		client: &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+// send posts an event in the background. Deliveries are best effort; failures
+// are only logged.
+func (w *webhook) send(payload WebhookPayload) {
+	if w == nil {
+		return
+	}
+
+	go func() {
+		if err := w.deliver(payload); err != nil {
+			slog.Warn("Failed to deliver webhook", "client", payload.ClientID, "event", payload.Event, "error", err)
+		}
+	}()
+}
+
+// deliver posts an event and waits for the response
+func (w *webhook) deliver(payload WebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.secret != "" {
+		mac := hmac.New(sha256.New, []byte(w.secret))
+		mac.Write(body)
+		req.Header.Set(webhookSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}